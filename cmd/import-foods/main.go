@@ -0,0 +1,92 @@
+// Command import-foods imports ingredient nutrients from a locally
+// downloaded food composition dataset: a USDA FoodData Central CSV download
+// or an Open Food Facts CSV export. Running it again re-imports only what
+// changed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/cvele/recipe/pkg/config"
+	"github.com/cvele/recipe/pkg/db"
+	"github.com/cvele/recipe/pkg/fooddata"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	dataset := flag.String("dataset", fooddata.DatasetFoodDataCentral, "dataset format: usda-fdc or open-food-facts")
+	path := flag.String("path", "", "directory of the extracted FoodData Central CSV files, or the Open Food Facts CSV file")
+	mappingsPath := flag.String("mappings", "", "CSV file mapping ingredients to foods (ingredient,food_id,grams_per_unit)")
+	matchNames := flag.Bool("match-names", false, "match ingredients without a mapping to foods with the same name")
+	overwrite := flag.Bool("overwrite", false, "replace nutrients that were entered by hand")
+	dryRun := flag.Bool("dry-run", false, "report the changes without saving them")
+	flag.Parse()
+
+	if *path == "" {
+		log.Fatal("path is required")
+	}
+
+	var read fooddata.Reader
+	switch *dataset {
+	case fooddata.DatasetFoodDataCentral:
+		read = func(keep fooddata.KeepFunc) ([]fooddata.Food, error) {
+			return fooddata.ReadFoodDataCentral(*path, keep)
+		}
+	case fooddata.DatasetOpenFoodFacts:
+		read = func(keep fooddata.KeepFunc) ([]fooddata.Food, error) {
+			return fooddata.ReadOpenFoodFacts(*path, keep)
+		}
+	default:
+		log.Fatalf("Unsupported dataset: %s", *dataset)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+	db, err := db.InitDB(cfg)
+	if err != nil {
+		log.Fatalf("Error initializing database: %v", err)
+	}
+	defer db.Close()
+
+	importer := fooddata.NewImporter(repositories.NewGormIngredientRepository(db), units.NewUnitConverter("g", "ml"), *dataset)
+	if *mappingsPath != "" {
+		mappings, err := fooddata.ReadMappings(*mappingsPath)
+		if err != nil {
+			log.Fatalf("Error reading mappings: %v", err)
+		}
+		importer.SetMappings(mappings)
+	}
+	importer.SetMatchNames(*matchNames)
+	importer.SetOverwrite(*overwrite)
+	importer.SetDryRun(*dryRun)
+
+	report, err := importer.Import(read)
+	if err != nil {
+		log.Fatalf("Error importing foods: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INGREDIENT\tFOOD\tOUTCOME\tREASON")
+	for _, change := range report.Changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", change.Ingredient, change.FoodID, change.Outcome, change.Reason)
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d updated, %d unchanged, %d skipped, %d failed\n",
+		report.Count(fooddata.OutcomeUpdated),
+		report.Count(fooddata.OutcomeUnchanged),
+		report.Count(fooddata.OutcomeSkipped),
+		report.Count(fooddata.OutcomeFailed),
+	)
+	if *dryRun {
+		fmt.Println("Dry run, nothing was saved")
+	}
+}
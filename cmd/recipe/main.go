@@ -1,15 +1,29 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/cvele/recipe/pkg/config"
 	"github.com/cvele/recipe/pkg/controllers"
 	"github.com/cvele/recipe/pkg/db"
+	"github.com/cvele/recipe/pkg/jobs"
+	"github.com/cvele/recipe/pkg/planner"
 	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
 
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 )
 
+// shutdownTimeout is how long requests in flight may take once the server
+// is asked to stop.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -28,12 +42,89 @@ func main() {
 	}
 	defer db.Close()
 
-	repo := repositories.NewGormRecipeRepository(db)
-	controller := controllers.NewRecipeController(repo)
+	recipeRepo := repositories.NewGormRecipeRepository(db)
+	recipeController := controllers.NewRecipeController(recipeRepo)
+
+	ingredientRepo := repositories.NewGormIngredientRepository(db)
+	ingredientController := controllers.NewIngredientController(ingredientRepo)
+
+	mealSlotRepo := repositories.NewGormMealSlotRepository(db)
+	mealSlotController := controllers.NewMealSlotController(mealSlotRepo)
+
+	mealPlanRepo := repositories.NewGormMealPlanRepository(db)
+	mealPlanController := controllers.NewMealPlanController(mealPlanRepo, mealSlotRepo)
+
+	householdRepo := repositories.NewGormHouseholdRepository(db)
+	householdController := controllers.NewHouseholdController(householdRepo)
+
+	collectionRepo := repositories.NewGormCollectionRepository(db)
+	collectionController := controllers.NewCollectionController(collectionRepo)
+
+	unitConverter := units.NewUnitConverter("g", "ml")
+	nutritionController := controllers.NewNutritionController(recipeRepo, mealPlanRepo, unitConverter)
+
+	plannerService := planner.NewService(recipeRepo, mealPlanRepo, householdRepo, mealSlotRepo, unitConverter, planner.GeneticTuning{
+		PopulationSize: cfg.PlannerPopulationSize,
+		MaxGenerations: cfg.PlannerMaxGenerations,
+		CrossoverRate:  cfg.PlannerCrossoverRate,
+		MutationRate:   cfg.PlannerMutationRate,
+
+		StagnationWindow:    cfg.PlannerStagnationWindow,
+		StagnationThreshold: cfg.PlannerStagnationThreshold,
+		Elitism:             cfg.PlannerElitism,
+
+		Islands:           cfg.PlannerIslands,
+		MigrationInterval: cfg.PlannerMigrationInterval,
+		Migrants:          cfg.PlannerMigrants,
+	})
+	plannerService.SetTuningLimits(planner.TuningLimits{
+		MaxPopulationSize: cfg.PlannerLimitPopulationSize,
+		MaxGenerations:    cfg.PlannerLimitGenerations,
+		MaxIslands:        cfg.PlannerLimitIslands,
+		MaxAnnealingSteps: cfg.PlannerLimitAnnealingSteps,
+	})
+	plannerController := controllers.NewPlannerController(plannerService)
+
+	planningJobRepo := repositories.NewGormPlanningJobRepository(db)
+	planningRunner := jobs.NewPlanningRunner(planningJobRepo, plannerService, cfg.PlannerWorkers, cfg.PlannerQueueSize)
+	if err := planningRunner.Recover(); err != nil {
+		log.Fatalf("Error recovering planning jobs: %v", err)
+	}
+	planningRunner.Start()
+	planningJobController := controllers.NewPlanningJobController(planningRunner, planningJobRepo)
 
 	router := gin.Default()
-	controller.RegisterRoutes(router.Group("/api"))
+	api := router.Group("/api")
+	recipeController.RegisterRoutes(api)
+	ingredientController.RegisterRoutes(api)
+	mealSlotController.RegisterRoutes(api)
+	mealPlanController.RegisterRoutes(api)
+	householdController.RegisterRoutes(api)
+	collectionController.RegisterRoutes(api)
+	nutritionController.RegisterRoutes(api)
+	plannerController.RegisterRoutes(api)
+	planningJobController.RegisterRoutes(api)
 
-	log.Infof("Starting server on port %s", cfg.ServerPort)
-	router.Run(":" + cfg.ServerPort)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: ":" + cfg.ServerPort, Handler: router}
+	go func() {
+		log.Infof("Starting server on port %s", cfg.ServerPort)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Error running server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info("Shutting down")
+
+	// Requests in flight get a while to finish, then running planning jobs
+	// are marked as interrupted
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("Error shutting down server: %v", err)
+	}
+	planningRunner.Shutdown()
 }
@@ -0,0 +1,101 @@
+// Command tune searches genetic planner parameters over a synthetic recipe
+// catalog, prints the quality-versus-time curve and optionally writes the
+// recommended tuning to the config file as the planner defaults.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cvele/recipe/pkg/config"
+	"github.com/cvele/recipe/pkg/fixtures"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/planner"
+	"github.com/cvele/recipe/pkg/tuner"
+	"github.com/cvele/recipe/pkg/units"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	out := flag.String("out", config.DefaultEnvFile, "config file to write the recommended defaults to")
+	write := flag.Bool("write", false, "write the recommended defaults to -out")
+	recipes := flag.Int("recipes", 200, "number of recipes in the synthetic catalog")
+	seeds := flag.Int("seeds", 5, "seeded runs per candidate")
+	tolerance := flag.Float64("tolerance", 0.01, "fitness, relative to the best, that may be given up for speed")
+	flag.Parse()
+
+	if *recipes <= 0 || *seeds <= 0 {
+		log.Fatal("recipes and seeds must be positive")
+	}
+
+	runSeeds := make([]int64, *seeds)
+	for i := range runSeeds {
+		runSeeds[i] = int64(i + 1)
+	}
+
+	repo := fixtures.NewRecipeRepository(fixtures.Recipes(*recipes, 1))
+	params := fixtures.WeekOfLunches(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	t := tuner.NewTuner(repo, params, models.Lunch, units.NewUnitConverter("g", "ml"), runSeeds)
+
+	// A single island keeps the runs comparable across machines
+	base := planner.GeneticTuning{
+		StagnationWindow:    10,
+		StagnationThreshold: 0.01,
+		Islands:             1,
+		MigrationInterval:   5,
+		Migrants:            1,
+	}
+	tunings := tuner.DefaultSpace.Tunings(base)
+
+	log.Infof("Evaluating %d tunings with %d seeds over %d recipes", len(tunings), *seeds, *recipes)
+	results, err := t.Search(tunings, func(result tuner.Result) {
+		log.Debugf("%s: fitness %.2f in %s", describe(result.Tuning), result.Fitness, result.Duration)
+	})
+	if err != nil {
+		log.Fatalf("Error tuning the planner: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POPULATION\tGENERATIONS\tCROSSOVER\tMUTATION\tFITNESS\tTIME")
+	for _, result := range tuner.Front(results) {
+		fmt.Fprintf(w, "%d\t%d\t%.2f\t%.2f\t%.2f\t%s\n",
+			result.Tuning.PopulationSize,
+			result.Tuning.MaxGenerations,
+			result.Tuning.CrossoverRate,
+			result.Tuning.MutationRate,
+			result.Fitness,
+			result.Duration.Round(time.Microsecond),
+		)
+	}
+	w.Flush()
+
+	recommended, ok := tuner.Recommend(results, *tolerance)
+	if !ok {
+		log.Fatal("No tuning was evaluated")
+	}
+	fmt.Printf("\nRecommended: %s (fitness %.2f in %s)\n", describe(recommended.Tuning), recommended.Fitness, recommended.Duration.Round(time.Microsecond))
+
+	if !*write {
+		return
+	}
+	err = config.UpdateEnvFile(*out, map[string]string{
+		"PLANNER_POPULATION_SIZE": strconv.Itoa(recommended.Tuning.PopulationSize),
+		"PLANNER_MAX_GENERATIONS": strconv.Itoa(recommended.Tuning.MaxGenerations),
+		"PLANNER_CROSSOVER_RATE":  strconv.FormatFloat(recommended.Tuning.CrossoverRate, 'g', -1, 64),
+		"PLANNER_MUTATION_RATE":   strconv.FormatFloat(recommended.Tuning.MutationRate, 'g', -1, 64),
+	})
+	if err != nil {
+		log.Fatalf("Error writing %s: %v", *out, err)
+	}
+	log.Infof("Wrote the recommended defaults to %s", *out)
+}
+
+func describe(tuning planner.GeneticTuning) string {
+	return fmt.Sprintf("population %d, generations %d, crossover %.2f, mutation %.2f",
+		tuning.PopulationSize, tuning.MaxGenerations, tuning.CrossoverRate, tuning.MutationRate)
+}
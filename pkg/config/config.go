@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"runtime"
 	"strconv"
 )
 
@@ -14,14 +16,128 @@ type Config struct {
 	DBType     string
 	LogLevel   string
 	ServerPort string
+
+	// Defaults for genetic planner runs that don't specify their own tuning
+	PlannerPopulationSize int
+	PlannerMaxGenerations int
+	PlannerCrossoverRate  float64
+	PlannerMutationRate   float64
+
+	PlannerStagnationWindow    int
+	PlannerStagnationThreshold float64
+	PlannerElitism             int
+
+	// Island model: populations evolving in parallel, by default one per CPU
+	PlannerIslands           int
+	PlannerMigrationInterval int
+	PlannerMigrants          int
+
+	// Largest tunings a planning request may ask for
+	PlannerLimitPopulationSize int
+	PlannerLimitGenerations    int
+	PlannerLimitIslands        int
+	PlannerLimitAnnealingSteps int
+
+	// Background planning job worker pool
+	PlannerWorkers   int
+	PlannerQueueSize int
 }
 
+// DefaultEnvFile is read by LoadConfig unless CONFIG_FILE names another file.
+// Variables already in the environment take precedence over it.
+const DefaultEnvFile = ".env"
+
 func LoadConfig() (*Config, error) {
+	if err := loadEnvFile(getEnv("CONFIG_FILE", DefaultEnvFile)); err != nil {
+		return nil, err
+	}
+
 	dbPort, err := strconv.Atoi(os.Getenv("DB_PORT"))
 	if err != nil {
 		return nil, err
 	}
 
+	populationSize, err := getEnvInt("PLANNER_POPULATION_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+	maxGenerations, err := getEnvInt("PLANNER_MAX_GENERATIONS", 50)
+	if err != nil {
+		return nil, err
+	}
+	crossoverRate, err := getEnvFloat("PLANNER_CROSSOVER_RATE", 0.7)
+	if err != nil {
+		return nil, err
+	}
+	mutationRate, err := getEnvFloat("PLANNER_MUTATION_RATE", 0.1)
+	if err != nil {
+		return nil, err
+	}
+
+	stagnationWindow, err := getEnvInt("PLANNER_STAGNATION_WINDOW", 10)
+	if err != nil {
+		return nil, err
+	}
+	stagnationThreshold, err := getEnvFloat("PLANNER_STAGNATION_THRESHOLD", 0.01)
+	if err != nil {
+		return nil, err
+	}
+	elitism, err := getEnvInt("PLANNER_ELITISM", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	islands, err := getEnvInt("PLANNER_ISLANDS", runtime.NumCPU())
+	if err != nil {
+		return nil, err
+	}
+	migrationInterval, err := getEnvInt("PLANNER_MIGRATION_INTERVAL", 5)
+	if err != nil {
+		return nil, err
+	}
+	migrants, err := getEnvInt("PLANNER_MIGRANTS", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	limitPopulationSize, err := getEnvInt("PLANNER_LIMIT_POPULATION_SIZE", 1000)
+	if err != nil {
+		return nil, err
+	}
+	limitGenerations, err := getEnvInt("PLANNER_LIMIT_GENERATIONS", 1000)
+	if err != nil {
+		return nil, err
+	}
+	// The default of one island per CPU must stay within the limit
+	defaultLimitIslands := 64
+	if runtime.NumCPU() > defaultLimitIslands {
+		defaultLimitIslands = runtime.NumCPU()
+	}
+	limitIslands, err := getEnvInt("PLANNER_LIMIT_ISLANDS", defaultLimitIslands)
+	if err != nil {
+		return nil, err
+	}
+	limitAnnealingSteps, err := getEnvInt("PLANNER_LIMIT_ANNEALING_STEPS", 1000000)
+	if err != nil {
+		return nil, err
+	}
+
+	workers, err := getEnvInt("PLANNER_WORKERS", 2)
+	if err != nil {
+		return nil, err
+	}
+	queueSize, err := getEnvInt("PLANNER_QUEUE_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+	// Without a worker queued jobs would never run
+	if workers < 1 {
+		return nil, fmt.Errorf("PLANNER_WORKERS must be at least 1, got %d", workers)
+	}
+	if queueSize < 0 {
+		return nil, fmt.Errorf("PLANNER_QUEUE_SIZE must not be negative, got %d", queueSize)
+	}
+
 	return &Config{
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     dbPort,
@@ -31,6 +147,27 @@ func LoadConfig() (*Config, error) {
 		DBType:     getEnv("DB_TYPE", "mysql"),
 		LogLevel:   getEnv("LOG_LEVEL", "info"),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
+
+		PlannerPopulationSize: populationSize,
+		PlannerMaxGenerations: maxGenerations,
+		PlannerCrossoverRate:  crossoverRate,
+		PlannerMutationRate:   mutationRate,
+
+		PlannerStagnationWindow:    stagnationWindow,
+		PlannerStagnationThreshold: stagnationThreshold,
+		PlannerElitism:             elitism,
+
+		PlannerIslands:           islands,
+		PlannerMigrationInterval: migrationInterval,
+		PlannerMigrants:          migrants,
+
+		PlannerLimitPopulationSize: limitPopulationSize,
+		PlannerLimitGenerations:    limitGenerations,
+		PlannerLimitIslands:        limitIslands,
+		PlannerLimitAnnealingSteps: limitAnnealingSteps,
+
+		PlannerWorkers:   workers,
+		PlannerQueueSize: queueSize,
 	}, nil
 }
 
@@ -40,3 +177,17 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) (int, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return strconv.Atoi(value)
+	}
+	return fallback, nil
+}
+
+func getEnvFloat(key string, fallback float64) (float64, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return strconv.ParseFloat(value, 64)
+	}
+	return fallback, nil
+}
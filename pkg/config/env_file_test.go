@@ -0,0 +1,67 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cvele/recipe/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	assert.NoError(t, os.WriteFile(path, []byte("# planner\nDB_HOST=db\nPLANNER_POPULATION_SIZE=100\n"), 0644))
+
+	err := config.UpdateEnvFile(path, map[string]string{
+		"PLANNER_POPULATION_SIZE": "50",
+		"PLANNER_MUTATION_RATE":   "0.05",
+	})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "# planner\nDB_HOST=db\nPLANNER_POPULATION_SIZE=50\nPLANNER_MUTATION_RATE=0.05\n", string(data))
+}
+
+func TestLoadConfig_EnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	assert.NoError(t, os.WriteFile(path, []byte("DB_PORT=3306\nPLANNER_POPULATION_SIZE=\"40\"\nPLANNER_MAX_GENERATIONS=20\n"), 0644))
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("PLANNER_MAX_GENERATIONS", "30")
+	// Unset the file's variables again once the test is done
+	t.Setenv("DB_PORT", "")
+	os.Unsetenv("DB_PORT")
+	t.Setenv("PLANNER_POPULATION_SIZE", "")
+	os.Unsetenv("PLANNER_POPULATION_SIZE")
+
+	cfg, err := config.LoadConfig()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3306, cfg.DBPort)
+	assert.Equal(t, 40, cfg.PlannerPopulationSize)
+	// The environment wins over the file
+	assert.Equal(t, 30, cfg.PlannerMaxGenerations)
+}
+
+func TestLoadConfig_PlannerWorkers(t *testing.T) {
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), ".env"))
+	t.Setenv("DB_PORT", "3306")
+
+	for env, value := range map[string]string{
+		"PLANNER_WORKERS":    "0",
+		"PLANNER_QUEUE_SIZE": "-1",
+	} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			_, err := config.LoadConfig()
+			assert.ErrorContains(t, err, env)
+		})
+	}
+
+	// An unbuffered queue is allowed
+	t.Setenv("PLANNER_QUEUE_SIZE", "0")
+	cfg, err := config.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cfg.PlannerQueueSize)
+}
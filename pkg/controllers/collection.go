@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+)
+
+type CollectionController struct {
+	repo repositories.CollectionRepository
+}
+
+func NewCollectionController(repo repositories.CollectionRepository) *CollectionController {
+	return &CollectionController{repo: repo}
+}
+
+func (cc *CollectionController) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/collections", cc.getCollections)
+	r.POST("/collections", cc.createCollection)
+	r.GET("/collections/:id", cc.getCollectionByID)
+	r.PUT("/collections/:id", cc.updateCollection)
+	r.DELETE("/collections/:id", cc.deleteCollection)
+	r.PUT("/collections/:id/recipes/:recipe_id", cc.addRecipe)
+	r.DELETE("/collections/:id/recipes/:recipe_id", cc.removeRecipe)
+}
+
+// getCollections lists a user's collections with their recipes.
+func (cc *CollectionController) getCollections(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Query("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	collections, err := cc.repo.FindByUserID(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, collections)
+}
+
+func (cc *CollectionController) getCollectionByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	collection, err := cc.repo.FindByID(uint(id))
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+func (cc *CollectionController) createCollection(c *gin.Context) {
+	var collection models.Collection
+	if err := c.ShouldBindJSON(&collection); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := collection.Validate(); err != nil {
+		respondWithValidationError(c, err)
+		return
+	}
+	collection.ID = 0
+	err := cc.repo.Create(&collection)
+	if errors.Is(err, repositories.ErrUnknownRecipe) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown recipe in recipe_ids"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, collection)
+}
+
+// updateCollection saves the collection. Its recipes are replaced with the
+// request's recipe_ids when they are given and kept otherwise.
+func (cc *CollectionController) updateCollection(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	var collection models.Collection
+	if err := c.ShouldBindJSON(&collection); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := collection.Validate(); err != nil {
+		respondWithValidationError(c, err)
+		return
+	}
+	collection.ID = uint(id)
+	err = cc.repo.Update(&collection)
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if errors.Is(err, repositories.ErrUnknownRecipe) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown recipe in recipe_ids"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+func (cc *CollectionController) deleteCollection(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	err = cc.repo.Delete(uint(id))
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// addRecipe puts a recipe in the collection.
+func (cc *CollectionController) addRecipe(c *gin.Context) {
+	id, recipeID, ok := collectionRecipeParams(c)
+	if !ok {
+		return
+	}
+	err := cc.repo.AddRecipe(id, recipeID)
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if errors.Is(err, repositories.ErrUnknownRecipe) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// removeRecipe takes a recipe out of the collection.
+func (cc *CollectionController) removeRecipe(c *gin.Context) {
+	id, recipeID, ok := collectionRecipeParams(c)
+	if !ok {
+		return
+	}
+	err := cc.repo.RemoveRecipe(id, recipeID)
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// collectionRecipeParams returns the collection and recipe IDs in the path,
+// or responds with an error and returns false.
+func collectionRecipeParams(c *gin.Context) (uint, uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return 0, 0, false
+	}
+	recipeID, err := strconv.Atoi(c.Param("recipe_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipe ID format"})
+		return 0, 0, false
+	}
+	return uint(id), uint(recipeID), true
+}
@@ -0,0 +1,170 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cvele/recipe/pkg/controllers"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type CollectionRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *CollectionRepositoryMock) FindByID(id uint) (*models.Collection, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Collection), args.Error(1)
+}
+
+func (m *CollectionRepositoryMock) FindByUserID(userID uint) ([]models.Collection, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Collection), args.Error(1)
+}
+
+func (m *CollectionRepositoryMock) Create(collection *models.Collection) error {
+	args := m.Called(collection)
+	return args.Error(0)
+}
+
+func (m *CollectionRepositoryMock) Update(collection *models.Collection) error {
+	args := m.Called(collection)
+	return args.Error(0)
+}
+
+func (m *CollectionRepositoryMock) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *CollectionRepositoryMock) AddRecipe(collectionID uint, recipeID uint) error {
+	args := m.Called(collectionID, recipeID)
+	return args.Error(0)
+}
+
+func (m *CollectionRepositoryMock) RemoveRecipe(collectionID uint, recipeID uint) error {
+	args := m.Called(collectionID, recipeID)
+	return args.Error(0)
+}
+
+func newCollectionRouter(repo *CollectionRepositoryMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controllers.NewCollectionController(repo).RegisterRoutes(router.Group("/api"))
+	return router
+}
+
+func TestCollectionController_Create(t *testing.T) {
+	mockRepo := new(CollectionRepositoryMock)
+	mockRepo.On("Create", mock.AnythingOfType("*models.Collection")).Return(nil)
+
+	body := []byte(`{"user_id": 7, "name": "Weeknight dinners", "recipe_ids": [3, 5]}`)
+
+	w := httptest.NewRecorder()
+	newCollectionRouter(mockRepo).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/collections", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	collection := mockRepo.Calls[0].Arguments.Get(0).(*models.Collection)
+	assert.Equal(t, uint(7), collection.UserID)
+	assert.Equal(t, "Weeknight dinners", collection.Name)
+	assert.Equal(t, []uint{3, 5}, collection.RecipeIDs)
+}
+
+func TestCollectionController_CreateValidation(t *testing.T) {
+	mockRepo := new(CollectionRepositoryMock)
+	mockRepo.On("Create", mock.AnythingOfType("*models.Collection")).Return(repositories.ErrUnknownRecipe)
+	router := newCollectionRouter(mockRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/collections", bytes.NewReader([]byte(`{"name": " ", "recipe_ids": [0]}`))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response struct {
+		Details models.ValidationErrors `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.ValidationErrors{
+		{Field: "user_id", Message: "is required"},
+		{Field: "name", Message: "is required"},
+		{Field: "recipe_ids[0]", Message: "is required"},
+	}, response.Details)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+
+	// Recipes that don't exist are rejected by the repository
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/collections", bytes.NewReader([]byte(`{"user_id": 7, "name": "Soups", "recipe_ids": [99]}`))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCollectionController_Update(t *testing.T) {
+	mockRepo := new(CollectionRepositoryMock)
+	mockRepo.On("Update", mock.AnythingOfType("*models.Collection")).Return(nil).Once()
+	mockRepo.On("Update", mock.AnythingOfType("*models.Collection")).Return(gorm.ErrRecordNotFound)
+	router := newCollectionRouter(mockRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/collections/2", bytes.NewReader([]byte(`{"user_id": 7, "name": "Soups"}`))))
+	assert.Equal(t, http.StatusOK, w.Code)
+	collection := mockRepo.Calls[0].Arguments.Get(0).(*models.Collection)
+	assert.Equal(t, uint(2), collection.ID)
+	// Without recipe_ids the recipes are kept
+	assert.Nil(t, collection.RecipeIDs)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/collections/9", bytes.NewReader([]byte(`{"user_id": 7, "name": "Soups", "recipe_ids": []}`))))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, []uint{}, mockRepo.Calls[1].Arguments.Get(0).(*models.Collection).RecipeIDs)
+}
+
+func TestCollectionController_Recipes(t *testing.T) {
+	mockRepo := new(CollectionRepositoryMock)
+	mockRepo.On("AddRecipe", uint(2), uint(5)).Return(nil)
+	mockRepo.On("AddRecipe", uint(2), uint(99)).Return(repositories.ErrUnknownRecipe)
+	mockRepo.On("AddRecipe", uint(9), uint(5)).Return(gorm.ErrRecordNotFound)
+	mockRepo.On("RemoveRecipe", uint(2), uint(5)).Return(nil)
+	router := newCollectionRouter(mockRepo)
+
+	for _, test := range []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodPut, "/api/collections/2/recipes/5", http.StatusNoContent},
+		{http.MethodPut, "/api/collections/2/recipes/99", http.StatusNotFound},
+		{http.MethodPut, "/api/collections/9/recipes/5", http.StatusNotFound},
+		{http.MethodPut, "/api/collections/2/recipes/soup", http.StatusBadRequest},
+		{http.MethodDelete, "/api/collections/2/recipes/5", http.StatusNoContent},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		assert.Equal(t, test.code, w.Code, test.method+" "+test.path)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCollectionController_GetByUser(t *testing.T) {
+	mockRepo := new(CollectionRepositoryMock)
+	mockRepo.On("FindByUserID", uint(7)).Return([]models.Collection{
+		{UserID: 7, Name: "Soups", Recipes: []models.Recipe{{ID: 5, Title: "Minestrone"}}},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	newCollectionRouter(mockRepo).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/collections?user_id=7", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var collections []models.Collection
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &collections))
+	if assert.Len(t, collections, 1) {
+		assert.Equal(t, "Minestrone", collections[0].Recipes[0].Title)
+	}
+}
@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// respondWithValidationError reports every invalid field when err holds
+// models.ValidationErrors, and reports err as a plain bad request otherwise.
+func respondWithValidationError(c *gin.Context, err error) {
+	var validationErrors models.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validationErrors})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
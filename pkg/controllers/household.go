@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+)
+
+type HouseholdController struct {
+	repo repositories.HouseholdRepository
+}
+
+func NewHouseholdController(repo repositories.HouseholdRepository) *HouseholdController {
+	return &HouseholdController{repo: repo}
+}
+
+func (hc *HouseholdController) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/households", hc.getHouseholds)
+	r.POST("/households", hc.createHousehold)
+	r.GET("/households/:id", hc.getHouseholdByID)
+	r.PUT("/households/:id", hc.updateHousehold)
+	r.DELETE("/households/:id", hc.deleteHousehold)
+}
+
+// getHouseholds lists a user's households with their members.
+func (hc *HouseholdController) getHouseholds(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Query("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	households, err := hc.repo.FindByUserID(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, households)
+}
+
+func (hc *HouseholdController) getHouseholdByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	household, err := hc.repo.FindByID(uint(id))
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Household not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, household)
+}
+
+func (hc *HouseholdController) createHousehold(c *gin.Context) {
+	var household models.Household
+	if err := c.ShouldBindJSON(&household); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := household.Validate(); err != nil {
+		respondWithValidationError(c, err)
+		return
+	}
+	household.ID = 0
+	for i := range household.Members {
+		household.Members[i].ID = 0
+	}
+	if err := hc.repo.Create(&household); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, household)
+}
+
+// updateHousehold saves the household and replaces its members with the
+// ones in the request.
+func (hc *HouseholdController) updateHousehold(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	var household models.Household
+	if err := c.ShouldBindJSON(&household); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := household.Validate(); err != nil {
+		respondWithValidationError(c, err)
+		return
+	}
+	household.ID = uint(id)
+	err = hc.repo.Update(&household)
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Household not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, household)
+}
+
+func (hc *HouseholdController) deleteHousehold(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	err = hc.repo.Delete(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,125 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cvele/recipe/pkg/controllers"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type HouseholdRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *HouseholdRepositoryMock) FindByID(id uint) (*models.Household, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Household), args.Error(1)
+}
+
+func (m *HouseholdRepositoryMock) FindByUserID(userID uint) ([]models.Household, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Household), args.Error(1)
+}
+
+func (m *HouseholdRepositoryMock) Create(household *models.Household) error {
+	args := m.Called(household)
+	return args.Error(0)
+}
+
+func (m *HouseholdRepositoryMock) Update(household *models.Household) error {
+	args := m.Called(household)
+	return args.Error(0)
+}
+
+func (m *HouseholdRepositoryMock) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func newHouseholdRouter(repo *HouseholdRepositoryMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controllers.NewHouseholdController(repo).RegisterRoutes(router.Group("/api"))
+	return router
+}
+
+func TestHouseholdController_Create(t *testing.T) {
+	mockRepo := new(HouseholdRepositoryMock)
+	mockRepo.On("Create", mock.AnythingOfType("*models.Household")).Return(nil)
+
+	body := []byte(`{
+		"user_id": 7,
+		"name": "Home",
+		"members": [
+			{"name": "Ana", "age": 34, "activity_level": "active", "dietary_restrictions": ["peanut"], "target_nutrients": {"calories": 2600}, "max_nutrients": {"calories": 3000}},
+			{"name": "Luka", "age": 3, "meals": ["breakfast", "lunch"], "target_nutrients": {"calories": 1000}, "max_nutrients": {"calories": 1200}}
+		]
+	}`)
+
+	w := httptest.NewRecorder()
+	newHouseholdRouter(mockRepo).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/households", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	household := mockRepo.Calls[0].Arguments.Get(0).(*models.Household)
+	assert.Equal(t, uint(7), household.UserID)
+	assert.Len(t, household.Members, 2)
+	assert.Equal(t, models.ActivityActive, household.Members[0].ActivityLevel)
+	assert.Equal(t, models.StringList{"peanut"}, household.Members[0].DietaryRestrictions)
+	assert.Equal(t, models.MealTypes{models.Breakfast, models.Lunch}, household.Members[1].Meals)
+}
+
+func TestHouseholdController_CreateValidation(t *testing.T) {
+	mockRepo := new(HouseholdRepositoryMock)
+
+	body := []byte(`{"members": [{"age": -1, "activity_level": "lazy", "target_nutrients": {"calories": 500}, "max_nutrients": {"calories": 400}}]}`)
+
+	w := httptest.NewRecorder()
+	newHouseholdRouter(mockRepo).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/households", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response struct {
+		Details []models.ValidationError `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	var fields []string
+	for _, detail := range response.Details {
+		fields = append(fields, detail.Field)
+	}
+	assert.ElementsMatch(t, []string{"name", "members[0].name", "members[0].age", "members[0].activity_level", "members[0].max_nutrients.calories"}, fields)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestHouseholdController_GetNotFound(t *testing.T) {
+	mockRepo := new(HouseholdRepositoryMock)
+	mockRepo.On("FindByID", uint(3)).Return(nil, gorm.ErrRecordNotFound)
+
+	w := httptest.NewRecorder()
+	newHouseholdRouter(mockRepo).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/households/3", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHouseholdController_Update(t *testing.T) {
+	mockRepo := new(HouseholdRepositoryMock)
+	mockRepo.On("Update", mock.AnythingOfType("*models.Household")).Return(nil)
+
+	body := []byte(`{"name": "Cabin", "members": [{"name": "Ana"}]}`)
+
+	w := httptest.NewRecorder()
+	newHouseholdRouter(mockRepo).ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/households/5", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	household := mockRepo.Calls[0].Arguments.Get(0).(*models.Household)
+	assert.Equal(t, uint(5), household.ID)
+	assert.Equal(t, "Cabin", household.Name)
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+)
+
+type IngredientController struct {
+	repo repositories.IngredientRepository
+}
+
+func NewIngredientController(repo repositories.IngredientRepository) *IngredientController {
+	return &IngredientController{repo: repo}
+}
+
+func (ic *IngredientController) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/ingredients", ic.searchIngredients)
+	r.POST("/ingredients", ic.createIngredient)
+	r.GET("/ingredients/:id", ic.getIngredientByID)
+	r.PUT("/ingredients/:id", ic.updateIngredient)
+	r.DELETE("/ingredients/:id", ic.deleteIngredient)
+}
+
+// searchIngredients lists ingredients matching the optional q parameter by
+// name or alias. The total number of matches is sent in X-Total-Count.
+func (ic *IngredientController) searchIngredients(c *gin.Context) {
+	offset, limit, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ingredients, total, err := ic.repo.Search(c.Query("q"), offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	c.JSON(http.StatusOK, ingredients)
+}
+
+func (ic *IngredientController) getIngredientByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	ingredient, err := ic.repo.FindByID(uint(id))
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ingredient)
+}
+
+func (ic *IngredientController) createIngredient(c *gin.Context) {
+	var ingredient models.Ingredient
+	if err := c.ShouldBindJSON(&ingredient); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ingredient.ID = 0
+	err := ic.repo.Create(&ingredient)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, ingredient)
+}
+
+func (ic *IngredientController) updateIngredient(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	var ingredient models.Ingredient
+	if err := c.ShouldBindJSON(&ingredient); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ingredient.ID = uint(id)
+	err = ic.repo.Update(&ingredient)
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ingredient)
+}
+
+func (ic *IngredientController) deleteIngredient(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	err = ic.repo.Delete(uint(id))
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,151 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cvele/recipe/pkg/controllers"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type IngredientRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *IngredientRepositoryMock) FindByID(id uint) (*models.Ingredient, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Ingredient), args.Error(1)
+}
+
+func (m *IngredientRepositoryMock) FindAll() ([]models.Ingredient, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Ingredient), args.Error(1)
+}
+
+func (m *IngredientRepositoryMock) Search(query string, offset int, limit int) ([]models.Ingredient, int, error) {
+	args := m.Called(query, offset, limit)
+	return args.Get(0).([]models.Ingredient), args.Int(1), args.Error(2)
+}
+
+func (m *IngredientRepositoryMock) Create(ingredient *models.Ingredient) error {
+	args := m.Called(ingredient)
+	return args.Error(0)
+}
+
+func (m *IngredientRepositoryMock) Update(ingredient *models.Ingredient) error {
+	args := m.Called(ingredient)
+	return args.Error(0)
+}
+
+func (m *IngredientRepositoryMock) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func newIngredientRouter(repo *IngredientRepositoryMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controllers.NewIngredientController(repo).RegisterRoutes(router.Group("/api"))
+	return router
+}
+
+func TestIngredientController_Search(t *testing.T) {
+	mockRepo := new(IngredientRepositoryMock)
+	mockRepo.On("Search", "onion", 20, 10).Return([]models.Ingredient{{Name: "Spring Onion"}}, 21, nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/ingredients?q=onion&offset=20&limit=10", nil)
+	newIngredientRouter(mockRepo).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "21", w.Header().Get("X-Total-Count"))
+
+	var ingredients []models.Ingredient
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &ingredients))
+	assert.Len(t, ingredients, 1)
+	assert.Equal(t, "Spring Onion", ingredients[0].Name)
+}
+
+func TestIngredientController_SearchPagination(t *testing.T) {
+	mockRepo := new(IngredientRepositoryMock)
+	mockRepo.On("Search", "", 0, 100).Return([]models.Ingredient{}, 0, nil)
+
+	router := newIngredientRouter(mockRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ingredients?limit=1000", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ingredients?offset=-1", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestIngredientController_GetByID(t *testing.T) {
+	mockRepo := new(IngredientRepositoryMock)
+	mockRepo.On("FindByID", uint(1)).Return(&models.Ingredient{ID: 1, Name: "Egg"}, nil)
+	mockRepo.On("FindByID", uint(2)).Return(nil, gorm.ErrRecordNotFound)
+
+	router := newIngredientRouter(mockRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ingredients/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ingredients/2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ingredients/abc", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestIngredientController_CreateAndUpdate(t *testing.T) {
+	mockRepo := new(IngredientRepositoryMock)
+	mockRepo.On("Create", mock.AnythingOfType("*models.Ingredient")).Return(nil)
+	mockRepo.On("Update", mock.MatchedBy(func(i *models.Ingredient) bool { return i.ID == 5 })).Return(nil)
+
+	router := newIngredientRouter(mockRepo)
+	body := []byte(`{"name":"Flour","unit":"kg","unit_type":"mass","price_per_unit":120,"aliases":[{"name":"plain flour"}],"nutrients":{"calories":364}}`)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/ingredients", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	created := mockRepo.Calls[0].Arguments.Get(0).(*models.Ingredient)
+	assert.Equal(t, "kg", created.Unit)
+	assert.Equal(t, 364.0, created.Nutrients.Calories)
+	assert.Equal(t, "plain flour", created.Aliases[0].Name)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/ingredients/5", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIngredientController_Delete(t *testing.T) {
+	mockRepo := new(IngredientRepositoryMock)
+	mockRepo.On("Delete", uint(3)).Return(nil)
+	mockRepo.On("Delete", uint(9)).Return(gorm.ErrRecordNotFound)
+	router := newIngredientRouter(mockRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/ingredients/3", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/ingredients/9", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockRepo.AssertExpectations(t)
+}
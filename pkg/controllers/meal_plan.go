@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+)
+
+type MealPlanController struct {
+	repo         repositories.MealPlanRepository
+	mealSlotRepo repositories.MealSlotRepository
+}
+
+func NewMealPlanController(repo repositories.MealPlanRepository, mealSlotRepo repositories.MealSlotRepository) *MealPlanController {
+	return &MealPlanController{repo: repo, mealSlotRepo: mealSlotRepo}
+}
+
+type moveMealRequest struct {
+	MealTime time.Time       `json:"meal_time" binding:"required"`
+	MealType models.MealType `json:"meal_type" binding:"required"`
+}
+
+type changeServingsRequest struct {
+	Servings int `json:"servings" binding:"required,min=1"`
+}
+
+func (mc *MealPlanController) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/meal-plans", mc.getMealPlans)
+	r.GET("/meal-plans/:id", mc.getMealPlanByID)
+	r.GET("/meal-plans/:id/explanation", mc.explainMealPlan)
+	r.PUT("/meal-plans/:id/slot", mc.moveMeal)
+	r.PUT("/meal-plans/:id/servings", mc.changeServings)
+	r.POST("/meal-plans/:id/cooked", mc.markCooked)
+	r.DELETE("/meal-plans/:id", mc.deleteMealPlan)
+}
+
+// getMealPlans lists a user's meals in the range [from, to). Both bounds
+// accept RFC 3339 timestamps or YYYY-MM-DD dates.
+func (mc *MealPlanController) getMealPlans(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Query("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	from, err := parseDateParam(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+		return
+	}
+	to, err := parseDateParam(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+		return
+	}
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+		return
+	}
+	mealPlans, err := mc.repo.FindByUserIDAndDateRange(uint(userID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, mealPlans)
+}
+
+func (mc *MealPlanController) getMealPlanByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	mealPlan, err := mc.repo.FindByID(uint(id))
+	if err != nil {
+		respondWithMealPlanError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, mealPlan)
+}
+
+// explainMealPlan says why the planner chose a meal, from the fitness
+// breakdown stored with it.
+func (mc *MealPlanController) explainMealPlan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	mealPlan, err := mc.repo.FindByID(uint(id))
+	if err != nil {
+		respondWithMealPlanError(c, err)
+		return
+	}
+	if mealPlan.Fitness == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan was not created by the planner"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"meal_plan_id": mealPlan.ID,
+		"fitness":      mealPlan.Fitness,
+		"violations":   mealPlan.Fitness.Violations(),
+		"explanation":  mealPlan.Fitness.Explain(),
+	})
+}
+
+func (mc *MealPlanController) moveMeal(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	var req moveMealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	slot, err := mc.mealSlotRepo.FindByName(req.MealType)
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown meal slot"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := mc.repo.UpdateSlot(uint(id), req.MealTime, *slot); err != nil {
+		respondWithMealPlanError(c, err)
+		return
+	}
+	mc.getMealPlanByID(c)
+}
+
+func (mc *MealPlanController) changeServings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	var req changeServingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := mc.repo.UpdateServings(uint(id), req.Servings); err != nil {
+		respondWithMealPlanError(c, err)
+		return
+	}
+	mc.getMealPlanByID(c)
+}
+
+func (mc *MealPlanController) markCooked(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	if err := mc.repo.MarkCooked(uint(id), time.Now()); err != nil {
+		respondWithMealPlanError(c, err)
+		return
+	}
+	mc.getMealPlanByID(c)
+}
+
+func (mc *MealPlanController) deleteMealPlan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	err = mc.repo.Delete(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func respondWithMealPlanError(c *gin.Context, err error) {
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func parseDateParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("missing date")
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,209 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cvele/recipe/pkg/controllers"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MealPlanRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MealPlanRepositoryMock) Create(mealPlan *models.MealPlan) error {
+	args := m.Called(mealPlan)
+	return args.Error(0)
+}
+
+func (m *MealPlanRepositoryMock) CreateAll(mealPlans []models.MealPlan) error {
+	args := m.Called(mealPlans)
+	return args.Error(0)
+}
+
+func (m *MealPlanRepositoryMock) Replace(oldIDs []uint, mealPlans []models.MealPlan) error {
+	args := m.Called(oldIDs, mealPlans)
+	return args.Error(0)
+}
+
+func (m *MealPlanRepositoryMock) FindByID(id uint) (*models.MealPlan, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MealPlan), args.Error(1)
+}
+
+func (m *MealPlanRepositoryMock) FindByUserID(userID uint) ([]*models.MealPlan, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]*models.MealPlan), args.Error(1)
+}
+
+func (m *MealPlanRepositoryMock) FindByUserIDAndDateRange(userID uint, from time.Time, to time.Time) ([]*models.MealPlan, error) {
+	args := m.Called(userID, from, to)
+	return args.Get(0).([]*models.MealPlan), args.Error(1)
+}
+
+func (m *MealPlanRepositoryMock) UpdateSlot(id uint, mealTime time.Time, slot models.MealSlot) error {
+	args := m.Called(id, mealTime, slot)
+	return args.Error(0)
+}
+
+func (m *MealPlanRepositoryMock) UpdateServings(id uint, servings int) error {
+	args := m.Called(id, servings)
+	return args.Error(0)
+}
+
+func (m *MealPlanRepositoryMock) MarkCooked(id uint, cookedAt time.Time) error {
+	args := m.Called(id, cookedAt)
+	return args.Error(0)
+}
+
+func (m *MealPlanRepositoryMock) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func newMealPlanRouter(repo *MealPlanRepositoryMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controllers.NewMealPlanController(repo, newDefaultMealSlotRepository()).RegisterRoutes(router.Group("/api"))
+	return router
+}
+
+func TestMealPlanController_GetMealPlans(t *testing.T) {
+	from := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 6, 12, 0, 0, 0, 0, time.UTC)
+
+	mockRepo := new(MealPlanRepositoryMock)
+	mockRepo.On("FindByUserIDAndDateRange", uint(7), from, to).Return([]*models.MealPlan{
+		{UserID: 7, MealType: models.Dinner, MealTime: from.Add(19 * time.Hour)},
+	}, nil)
+
+	router := newMealPlanRouter(mockRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/meal-plans?user_id=7&from=2023-06-05&to=2023-06-12", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var mealPlans []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &mealPlans))
+	assert.Len(t, mealPlans, 1)
+	assert.Equal(t, "dinner", mealPlans[0]["meal_type"])
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/meal-plans?user_id=7&from=2023-06-12&to=2023-06-05", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/meal-plans?from=2023-06-05&to=2023-06-12", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestMealPlanController_ExplainMealPlan(t *testing.T) {
+	fitness := &models.FitnessBreakdown{}
+	fitness.Add(models.ObjectiveScore{Objective: "calories", Value: 450, Target: 500, Min: 400, Max: 700, Penalty: 50})
+	fitness.Add(models.ObjectiveScore{Objective: "protein", Value: 10, Target: 30, Min: 20, Max: 40, Penalty: 20, Violation: models.ViolationBelowMin})
+	fitness.Add(models.ObjectiveScore{Objective: models.ObjectiveCost, Value: 350, Penalty: 350})
+	fitness.Add(models.ObjectiveScore{Objective: models.ObjectiveVariety, Value: 1, Penalty: 50})
+
+	mockRepo := new(MealPlanRepositoryMock)
+	mockRepo.On("FindByID", uint(3)).Return(&models.MealPlan{ID: 3, Fitness: fitness}, nil)
+	mockRepo.On("FindByID", uint(4)).Return(&models.MealPlan{ID: 4}, nil)
+
+	router := newMealPlanRouter(mockRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/meal-plans/3/explanation", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Fitness     models.FitnessBreakdown `json:"fitness"`
+		Violations  []models.ObjectiveScore `json:"violations"`
+		Explanation []string                `json:"explanation"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 470.0, response.Fitness.Total)
+	assert.Len(t, response.Violations, 1)
+	assert.Equal(t, []string{
+		"Calories of 450.0 is within limits, 50.0 from the target of 500.0.",
+		"Protein of 10.0 is below the minimum of 20.0.",
+		"It costs 3.50.",
+		"The recipe also appears once elsewhere in the plan, adding 50.0 to the penalty.",
+		"Total penalty: 470.0 (lower is better).",
+	}, response.Explanation)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/meal-plans/4/explanation", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMealPlanController_MoveMeal(t *testing.T) {
+	mealTime := time.Date(2023, 6, 9, 19, 0, 0, 0, time.UTC)
+
+	mockRepo := new(MealPlanRepositoryMock)
+	dinner, _ := models.DefaultMealSlot(models.Dinner)
+	mockRepo.On("UpdateSlot", uint(3), mealTime, dinner).Return(nil)
+	mockRepo.On("FindByID", uint(3)).Return(&models.MealPlan{ID: 3, MealTime: mealTime, MealType: models.Dinner}, nil)
+
+	router := newMealPlanRouter(mockRepo)
+
+	body := []byte(`{"meal_time":"2023-06-09T19:00:00Z","meal_type":"dinner"}`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/meal-plans/3/slot", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+
+	body = []byte(`{"meal_time":"2023-06-09T19:00:00Z","meal_type":"elevenses"}`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/meal-plans/3/slot", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestMealPlanController_ChangeServings(t *testing.T) {
+	mockRepo := new(MealPlanRepositoryMock)
+	mockRepo.On("UpdateServings", uint(4), 6).Return(nil)
+	mockRepo.On("UpdateServings", uint(5), 2).Return(gorm.ErrRecordNotFound)
+	mockRepo.On("FindByID", uint(4)).Return(&models.MealPlan{ID: 4, Servings: 6}, nil)
+
+	router := newMealPlanRouter(mockRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/meal-plans/4/servings", bytes.NewReader([]byte(`{"servings":6}`))))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/meal-plans/5/servings", bytes.NewReader([]byte(`{"servings":2}`))))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/meal-plans/4/servings", bytes.NewReader([]byte(`{"servings":0}`))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestMealPlanController_MarkCookedAndDelete(t *testing.T) {
+	mockRepo := new(MealPlanRepositoryMock)
+	mockRepo.On("MarkCooked", uint(8), mock.AnythingOfType("time.Time")).Return(nil)
+	mockRepo.On("FindByID", uint(8)).Return(&models.MealPlan{ID: 8}, nil)
+	mockRepo.On("Delete", uint(8)).Return(nil)
+
+	router := newMealPlanRouter(mockRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/8/cooked", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/meal-plans/8", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockRepo.AssertExpectations(t)
+}
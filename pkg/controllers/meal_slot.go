@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+)
+
+type MealSlotController struct {
+	repo repositories.MealSlotRepository
+}
+
+func NewMealSlotController(repo repositories.MealSlotRepository) *MealSlotController {
+	return &MealSlotController{repo: repo}
+}
+
+func (mc *MealSlotController) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/meal-slots", mc.getMealSlots)
+	r.POST("/meal-slots", mc.createMealSlot)
+	r.GET("/meal-slots/:id", mc.getMealSlotByID)
+	r.PUT("/meal-slots/:id", mc.updateMealSlot)
+	r.DELETE("/meal-slots/:id", mc.deleteMealSlot)
+}
+
+// getMealSlots lists the meal slots in the order of their default times.
+func (mc *MealSlotController) getMealSlots(c *gin.Context) {
+	slots, err := mc.repo.FindAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, slots)
+}
+
+func (mc *MealSlotController) getMealSlotByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	slot, err := mc.repo.FindByID(uint(id))
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal slot not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, slot)
+}
+
+func (mc *MealSlotController) createMealSlot(c *gin.Context) {
+	var slot models.MealSlot
+	if err := c.ShouldBindJSON(&slot); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := slot.Validate(); err != nil {
+		respondWithValidationError(c, err)
+		return
+	}
+	_, err := mc.repo.FindByName(slot.Name)
+	if err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "A meal slot with this name already exists"})
+		return
+	}
+	if !gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	slot.ID = 0
+	if err := mc.repo.Create(&slot); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, slot)
+}
+
+// updateMealSlot saves the slot's label, default time, duration and share.
+// Its name can't be changed; the request may leave it out.
+func (mc *MealSlotController) updateMealSlot(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	var slot models.MealSlot
+	if err := c.ShouldBindJSON(&slot); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	existing, err := mc.repo.FindByID(uint(id))
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal slot not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if slot.Name != "" && slot.Name != existing.Name {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The name of a meal slot can't be changed"})
+		return
+	}
+	slot.ID = existing.ID
+	slot.Name = existing.Name
+	if err := slot.Validate(); err != nil {
+		respondWithValidationError(c, err)
+		return
+	}
+	err = mc.repo.Update(&slot)
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal slot not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, slot)
+}
+
+// deleteMealSlot deletes the slot. Recipes are no longer planned for it;
+// meals already planned keep its name.
+func (mc *MealSlotController) deleteMealSlot(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	if err := mc.repo.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,175 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cvele/recipe/pkg/controllers"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MealSlotRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MealSlotRepositoryMock) FindAll() ([]models.MealSlot, error) {
+	args := m.Called()
+	return args.Get(0).([]models.MealSlot), args.Error(1)
+}
+
+func (m *MealSlotRepositoryMock) FindByID(id uint) (*models.MealSlot, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MealSlot), args.Error(1)
+}
+
+func (m *MealSlotRepositoryMock) FindByName(name models.MealType) (*models.MealSlot, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MealSlot), args.Error(1)
+}
+
+func (m *MealSlotRepositoryMock) Create(slot *models.MealSlot) error {
+	args := m.Called(slot)
+	return args.Error(0)
+}
+
+func (m *MealSlotRepositoryMock) Update(slot *models.MealSlot) error {
+	args := m.Called(slot)
+	return args.Error(0)
+}
+
+func (m *MealSlotRepositoryMock) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// newDefaultMealSlotRepository returns a repository with the default meal
+// slots.
+func newDefaultMealSlotRepository() *MealSlotRepositoryMock {
+	repo := new(MealSlotRepositoryMock)
+	for _, slot := range models.DefaultMealSlots {
+		slot := slot
+		repo.On("FindByName", slot.Name).Return(&slot, nil)
+	}
+	repo.On("FindByName", mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	return repo
+}
+
+func newMealSlotRouter(repo *MealSlotRepositoryMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controllers.NewMealSlotController(repo).RegisterRoutes(router.Group("/api"))
+	return router
+}
+
+func TestMealSlotController_Create(t *testing.T) {
+	mockRepo := newDefaultMealSlotRepository()
+	mockRepo.On("Create", mock.AnythingOfType("*models.MealSlot")).Return(nil)
+	router := newMealSlotRouter(mockRepo)
+
+	body := []byte(`{"name": "second_breakfast", "label": "Second breakfast", "default_time": "10:30", "duration": 20, "share": 0.1}`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-slots", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockRepo.AssertCalled(t, "Create", &models.MealSlot{
+		Name:        "second_breakfast",
+		Label:       "Second breakfast",
+		DefaultTime: "10:30",
+		Duration:    20,
+		Share:       0.1,
+	})
+
+	// Slot names are unique
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-slots", bytes.NewReader([]byte(`{"name": "lunch", "share": 0.35}`))))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestMealSlotController_CreateValidation(t *testing.T) {
+	mockRepo := newDefaultMealSlotRepository()
+	router := newMealSlotRouter(mockRepo)
+
+	body := []byte(`{"name": "Pre-workout", "default_time": "25:00", "duration": -5, "share": 1.5}`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-slots", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response struct {
+		Details models.ValidationErrors `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.ValidationErrors{
+		{Field: "name", Message: "must be at most 50 lower case letters and digits separated by underscores"},
+		{Field: "default_time", Message: "must be a time of day as HH:MM"},
+		{Field: "duration", Message: "must not be negative"},
+		{Field: "share", Message: "must be greater than 0 and at most 1"},
+	}, response.Details)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+
+	// Nutrient limits are scaled by the share, so it can't be left out
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-slots", bytes.NewReader([]byte(`{"name": "pre_workout"}`))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.ValidationErrors{
+		{Field: "share", Message: "must be greater than 0 and at most 1"},
+	}, response.Details)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestMealSlotController_Update(t *testing.T) {
+	mockRepo := new(MealSlotRepositoryMock)
+	mockRepo.On("FindByID", uint(2)).Return(&models.MealSlot{Model: gorm.Model{ID: 2}, Name: models.Lunch, DefaultTime: "12:30"}, nil)
+	mockRepo.On("FindByID", uint(9)).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("Update", mock.AnythingOfType("*models.MealSlot")).Return(nil)
+	router := newMealSlotRouter(mockRepo)
+
+	// The name may be left out and is kept
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/meal-slots/2", bytes.NewReader([]byte(`{"label": "School lunchbox", "default_time": "11:45", "duration": 25, "share": 0.3}`))))
+	assert.Equal(t, http.StatusOK, w.Code)
+	slot := mockRepo.Calls[1].Arguments.Get(0).(*models.MealSlot)
+	assert.Equal(t, uint(2), slot.ID)
+	assert.Equal(t, models.Lunch, slot.Name)
+	assert.Equal(t, "11:45", slot.DefaultTime)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/meal-slots/2", bytes.NewReader([]byte(`{"name": "brunch"}`))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/meal-slots/9", bytes.NewReader([]byte(`{"label": "Brunch", "share": 0.4}`))))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockRepo.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestMealSlotController_ListAndDelete(t *testing.T) {
+	mockRepo := new(MealSlotRepositoryMock)
+	mockRepo.On("FindAll").Return(models.DefaultMealSlots, nil)
+	mockRepo.On("Delete", uint(5)).Return(nil)
+	router := newMealSlotRouter(mockRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/meal-slots", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var slots []models.MealSlot
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &slots))
+	assert.Len(t, slots, len(models.DefaultMealSlots))
+	assert.Equal(t, "08:00", slots[0].DefaultTime)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/meal-slots/5", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/nutrition"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+)
+
+type NutritionController struct {
+	recipeRepo    repositories.RecipeRepositoryInterface
+	mealPlanRepo  repositories.MealPlanRepository
+	unitConverter units.UnitConverterInterface
+}
+
+func NewNutritionController(recipeRepo repositories.RecipeRepositoryInterface, mealPlanRepo repositories.MealPlanRepository, unitConverter units.UnitConverterInterface) *NutritionController {
+	return &NutritionController{
+		recipeRepo:    recipeRepo,
+		mealPlanRepo:  mealPlanRepo,
+		unitConverter: unitConverter,
+	}
+}
+
+func (nc *NutritionController) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/nutrition/targets", nc.recommendTargets)
+	r.GET("/recipes/:id/nutrition", nc.getRecipeNutrition)
+	r.GET("/meal-plans/nutrition", nc.summarizeMealPlans)
+}
+
+// getRecipeNutrition returns the recipe's nutrition facts in total and per
+// serving. With format=html or format=svg it renders them as a nutrition
+// label instead.
+func (nc *NutritionController) getRecipeNutrition(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "html" && format != "svg" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, html or svg"})
+		return
+	}
+
+	recipe, err := nc.recipeRepo.GetRecipeByID(uint(id))
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	facts := nutrition.RecipeFacts(*recipe, nc.unitConverter)
+	if format == "json" {
+		c.JSON(http.StatusOK, facts)
+		return
+	}
+
+	render, contentType := nutrition.RenderLabelHTML, "text/html; charset=utf-8"
+	if format == "svg" {
+		render, contentType = nutrition.RenderLabelSVG, "image/svg+xml"
+	}
+	var label bytes.Buffer
+	if err := render(&label, facts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, contentType, label.Bytes())
+}
+
+// summarizeMealPlans adds up the nutrients of a user's meals in the range
+// [from, to) per day and in total. Both bounds accept RFC 3339 timestamps
+// or YYYY-MM-DD dates.
+func (nc *NutritionController) summarizeMealPlans(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Query("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	from, err := parseDateParam(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+		return
+	}
+	to, err := parseDateParam(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+		return
+	}
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+		return
+	}
+
+	mealPlans, err := nc.mealPlanRepo.FindByUserIDAndDateRange(uint(userID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	plans := make([]models.MealPlan, len(mealPlans))
+	for i, mealPlan := range mealPlans {
+		plans[i] = *mealPlan
+	}
+	c.JSON(http.StatusOK, nutrition.Summarize(plans, nc.unitConverter))
+}
+
+// recommendTargets returns the recommended daily intake for the profile in
+// the request body, with the energy figures it is based on in kcal.
+func (nc *NutritionController) recommendTargets(c *gin.Context) {
+	var profile models.Profile
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := profile.Validate(); err != nil {
+		respondWithValidationError(c, err)
+		return
+	}
+	if err := profile.ValidateRecommendable(); err != nil {
+		respondWithValidationError(c, err)
+		return
+	}
+
+	intake := nutrition.Recommend(profile)
+	c.JSON(http.StatusOK, gin.H{
+		"basal_metabolic_rate": nutrition.BasalMetabolicRate(profile),
+		"energy_expenditure":   nutrition.EnergyExpenditure(profile),
+		"target_nutrients":     intake.TargetNutrients,
+		"min_nutrients":        intake.MinNutrients,
+		"max_nutrients":        intake.MaxNutrients,
+	})
+}
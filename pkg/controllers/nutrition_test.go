@@ -0,0 +1,193 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cvele/recipe/pkg/controllers"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/nutrition"
+	"github.com/cvele/recipe/pkg/units"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNutritionRouter(recipeRepo *RecipeRepositoryMock, mealPlanRepo *MealPlanRepositoryMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controllers.NewNutritionController(recipeRepo, mealPlanRepo, units.NewUnitConverter("g", "ml")).RegisterRoutes(router.Group("/api"))
+	return router
+}
+
+func TestNutritionController_RecommendTargets(t *testing.T) {
+	body := []byte(`{"age": 30, "sex": "male", "weight": 80, "height": 180, "activity_level": "moderate"}`)
+
+	w := httptest.NewRecorder()
+	newNutritionRouter(new(RecipeRepositoryMock), new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/nutrition/targets", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		BasalMetabolicRate float64                  `json:"basal_metabolic_rate"`
+		EnergyExpenditure  float64                  `json:"energy_expenditure"`
+		TargetNutrients    models.NutritionalValues `json:"target_nutrients"`
+		MaxNutrients       models.NutritionalValues `json:"max_nutrients"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.InDelta(t, 1780, response.BasalMetabolicRate, 1e-9)
+	assert.InDelta(t, 2759, response.EnergyExpenditure, 1e-9)
+	assert.InDelta(t, 2759, response.TargetNutrients.Calories, 1e-9)
+	assert.Greater(t, response.MaxNutrients.Protein, response.TargetNutrients.Protein)
+}
+
+func TestNutritionController_RecommendTargetsValidation(t *testing.T) {
+	for _, body := range []string{`{"age": 30, "sex": "other", "weight": 80}`, `{"age": 30}`} {
+		w := httptest.NewRecorder()
+		newNutritionRouter(new(RecipeRepositoryMock), new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/nutrition/targets", bytes.NewReader([]byte(body))))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	for body, field := range map[string]string{
+		`{"sex": "male", "weight": 70, "height": 175}`: "age",
+		`{"age": 30, "sex": "male", "weight": 70}`:     "height",
+	} {
+		w := httptest.NewRecorder()
+		newNutritionRouter(new(RecipeRepositoryMock), new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/nutrition/targets", bytes.NewReader([]byte(body))))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		var response struct {
+			Details models.ValidationErrors `json:"details"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		if assert.Len(t, response.Details, 1, body) {
+			assert.Equal(t, field, response.Details[0].Field, body)
+		}
+	}
+
+	// Children's rates only need the weight
+	w := httptest.NewRecorder()
+	newNutritionRouter(new(RecipeRepositoryMock), new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/nutrition/targets", bytes.NewReader([]byte(`{"age": 8, "weight": 25}`))))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestNutritionController_SummarizeMealPlans(t *testing.T) {
+	from := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	recipe := breakfastRecipes()[0]
+	mealPlanRepo := new(MealPlanRepositoryMock)
+	mealPlanRepo.On("FindByUserIDAndDateRange", uint(3), from, to).Return([]*models.MealPlan{
+		{Recipe: &recipe, Servings: 4, MealTime: from.Add(8 * time.Hour)},
+		{Recipe: &recipe, Servings: 2, MealTime: from.Add(32 * time.Hour)},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	newNutritionRouter(new(RecipeRepositoryMock), mealPlanRepo).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/meal-plans/nutrition?user_id=3&from=2023-06-05&to=2023-06-12", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var summary nutrition.PlanNutrition
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Len(t, summary.Days, 2)
+	// 100 g of oats at 4 kcal/g make two servings
+	assert.InDelta(t, 800, summary.Days[0].Nutrients.Calories, 1e-9)
+	assert.InDelta(t, 1200, summary.Total.Calories, 1e-9)
+}
+
+func nutritionRecipe() *models.Recipe {
+	return &models.Recipe{
+		ID:       4,
+		Title:    "Pancakes",
+		Servings: 4,
+		Version:  2,
+		RecipeIngredients: &[]models.RecipeIngredient{
+			{
+				IngredientID: 1,
+				Ingredient: models.Ingredient{
+					Name:         "Flour",
+					UnitType:     "mass",
+					Quantity:     100,
+					QuantityUnit: "g",
+					Nutrients:    models.NutritionalValues{Calories: 364, Protein: 10, Carbs: 76, Sodium: 2},
+				},
+				Quantity: 0.2,
+				Unit:     "kg",
+			},
+			{
+				IngredientID: 2,
+				Ingredient: models.Ingredient{
+					Name:         "Milk",
+					UnitType:     "volume",
+					Quantity:     1,
+					QuantityUnit: "cups",
+					Nutrients:    models.NutritionalValues{Calories: 149, Fat: 8, Calcium: 276},
+				},
+				Quantity: 2,
+				Unit:     "cups",
+			},
+			{
+				IngredientID: 3,
+				Ingredient:   models.Ingredient{Name: "Baking powder", UnitType: "mass"},
+				Quantity:     5,
+				Unit:         "g",
+			},
+		},
+	}
+}
+
+func TestNutritionController_GetRecipeNutrition(t *testing.T) {
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipeByID", uint(4)).Return(nutritionRecipe(), nil)
+
+	w := httptest.NewRecorder()
+	newNutritionRouter(recipeRepo, new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/4/nutrition", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var facts nutrition.Facts
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &facts))
+	assert.Equal(t, 2, facts.Version)
+	assert.InDelta(t, 1026, facts.Total.Calories, 1e-9)
+	assert.InDelta(t, 256.5, facts.PerServing.Calories, 1e-9)
+	assert.InDelta(t, 138, facts.PerServing.Calcium, 1e-9)
+	assert.InDelta(t, 138.0/1300*100, facts.PercentDailyValues.Calcium, 1e-9)
+	assert.False(t, facts.Complete)
+	assert.Len(t, facts.Ingredients, 3)
+	assert.InDelta(t, 728, facts.Ingredients[0].Nutrients.Calories, 1e-9)
+	assert.Empty(t, facts.Ingredients[0].Missing)
+	assert.Equal(t, "the ingredient has no nutrient data", facts.Ingredients[2].Missing)
+}
+
+func TestNutritionController_GetRecipeNutritionLabel(t *testing.T) {
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipeByID", uint(4)).Return(nutritionRecipe(), nil)
+	router := newNutritionRouter(recipeRepo, new(MealPlanRepositoryMock))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/4/nutrition?format=html", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "Nutrition Facts")
+	assert.Contains(t, w.Body.String(), `<td class="calories percent">260</td>`)
+	assert.Contains(t, w.Body.String(), "Baking powder: the ingredient has no nutrient data")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/4/nutrition?format=svg", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<svg")
+	assert.Contains(t, w.Body.String(), "Calcium 140mg")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/4/nutrition?format=pdf", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestNutritionController_GetRecipeNutritionNotFound(t *testing.T) {
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipeByID", uint(9)).Return(nil, gorm.ErrRecordNotFound)
+
+	w := httptest.NewRecorder()
+	newNutritionRouter(recipeRepo, new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/9/nutrition", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
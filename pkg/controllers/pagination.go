@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parsePagination reads the offset and limit query parameters, applying the
+// default and maximum page size.
+func parsePagination(c *gin.Context) (int, int, error) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		return 0, 0, errors.New("Invalid offset")
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageLimit)))
+	if err != nil || limit <= 0 {
+		return 0, 0, errors.New("Invalid limit")
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	return offset, limit, nil
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/planner"
+	"github.com/gin-gonic/gin"
+)
+
+type PlannerController struct {
+	service *planner.Service
+}
+
+func NewPlannerController(service *planner.Service) *PlannerController {
+	return &PlannerController{service: service}
+}
+
+func (pc *PlannerController) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/meal-plans/generate", pc.generateMealPlans)
+}
+
+// generateMealPlans runs the genetic planner and returns one meal per day.
+// With persist set the meals are also stored for the user.
+func (pc *PlannerController) generateMealPlans(c *gin.Context) {
+	var req planner.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mealPlans, err := pc.service.Plan(c.Request.Context(), req, nil)
+	if err != nil {
+		respondWithPlanningError(c, err)
+		return
+	}
+
+	if req.Persist {
+		c.JSON(http.StatusCreated, mealPlans)
+		return
+	}
+	c.JSON(http.StatusOK, mealPlans)
+}
+
+func respondWithPlanningError(c *gin.Context, err error) {
+	var validationErrors models.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrors):
+		respondWithValidationError(c, err)
+	case errors.Is(err, planner.ErrNoRecipes):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,532 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cvele/recipe/pkg/controllers"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/planner"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type RecipeRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *RecipeRepositoryMock) GetAllRecipes() ([]models.Recipe, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Recipe), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) FindRecipes(filter repositories.RecipeFilter) ([]models.Recipe, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.Recipe), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) GetRecipeByID(id uint) (*models.Recipe, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Recipe), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) CreateRecipe(recipe *models.Recipe) error {
+	args := m.Called(recipe)
+	return args.Error(0)
+}
+
+func (m *RecipeRepositoryMock) UpdateRecipe(recipe *models.Recipe) error {
+	args := m.Called(recipe)
+	return args.Error(0)
+}
+
+func (m *RecipeRepositoryMock) DeleteRecipe(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *RecipeRepositoryMock) GetRandomRecipeByType(mealType models.MealType) (*models.Recipe, error) {
+	args := m.Called(mealType)
+	return args.Get(0).(*models.Recipe), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) GetRecipesByType(mealType models.MealType) ([]models.Recipe, error) {
+	args := m.Called(mealType)
+	return args.Get(0).([]models.Recipe), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) GetRecipeVersions(id uint) ([]models.RecipeVersion, error) {
+	args := m.Called(id)
+	return args.Get(0).([]models.RecipeVersion), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) GetRecipeVersion(id uint, version int) (*models.Recipe, error) {
+	args := m.Called(id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Recipe), args.Error(1)
+}
+
+var testTuning = planner.GeneticTuning{
+	PopulationSize: 10,
+	MaxGenerations: 5,
+	CrossoverRate:  0.7,
+	MutationRate:   0.1,
+}
+
+func newPlannerRouter(recipeRepo *RecipeRepositoryMock, mealPlanRepo *MealPlanRepositoryMock) *gin.Engine {
+	return newHouseholdPlannerRouter(recipeRepo, mealPlanRepo, nil)
+}
+
+func newHouseholdPlannerRouter(recipeRepo *RecipeRepositoryMock, mealPlanRepo *MealPlanRepositoryMock, householdRepo repositories.HouseholdRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	unitConverter := units.NewUnitConverter("g", "ml")
+	service := planner.NewService(recipeRepo, mealPlanRepo, householdRepo, newDefaultMealSlotRepository(), unitConverter, testTuning)
+	controllers.NewPlannerController(service).RegisterRoutes(router.Group("/api"))
+	return router
+}
+
+func breakfastRecipes() []models.Recipe {
+	return []models.Recipe{
+		{
+			ID:        1,
+			Title:     "Porridge",
+			Servings:  2,
+			Version:   3,
+			MealSlots: models.MealTypes{models.Breakfast},
+			RecipeIngredients: &[]models.RecipeIngredient{
+				{
+					Ingredient: models.Ingredient{
+						Name:         "Oats",
+						UnitType:     "mass",
+						PricePerUnit: 1,
+						Nutrients:    models.NutritionalValues{Calories: 4},
+					},
+					Quantity: 100,
+					Unit:     "g",
+				},
+			},
+		},
+	}
+}
+
+func TestPlannerController_Generate(t *testing.T) {
+	recipes := breakfastRecipes()
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipesByType", models.Breakfast).Return(recipes, nil)
+	mealPlanRepo := new(MealPlanRepositoryMock)
+
+	body := []byte(`{
+		"start_date": "2023-06-05T00:00:00Z",
+		"end_date": "2023-06-08T00:00:00Z",
+		"meal_time": "2023-06-05T08:30:00Z",
+		"servings": 4,
+		"max_budget": 1000,
+		"meal_type": "breakfast",
+		"tuning": {"population_size": 4}
+	}`)
+
+	w := httptest.NewRecorder()
+	newPlannerRouter(recipeRepo, mealPlanRepo).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var mealPlans []models.MealPlan
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &mealPlans))
+	assert.Len(t, mealPlans, 3)
+	for i, mealPlan := range mealPlans {
+		assert.Equal(t, time.Date(2023, 6, 5+i, 8, 30, 0, 0, time.UTC), mealPlan.MealTime.UTC())
+		assert.Equal(t, 4, mealPlan.Servings)
+		assert.Equal(t, 3, mealPlan.RecipeVersion)
+		assert.Equal(t, models.Breakfast, mealPlan.MealType)
+		assert.Equal(t, 30, mealPlan.Duration)
+		assert.NotNil(t, mealPlan.Fitness)
+	}
+
+	// Scaling individuals must not leak into the repository's recipes
+	assert.Equal(t, 100.0, (*recipes[0].RecipeIngredients)[0].Quantity)
+	mealPlanRepo.AssertNotCalled(t, "CreateAll", mock.Anything)
+}
+
+func TestPlannerController_GeneratePersist(t *testing.T) {
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipesByType", models.Breakfast).Return(breakfastRecipes(), nil)
+	mealPlanRepo := new(MealPlanRepositoryMock)
+	mealPlanRepo.On("CreateAll", mock.MatchedBy(func(mealPlans []models.MealPlan) bool {
+		return len(mealPlans) == 2 && mealPlans[0].UserID == 9
+	})).Return(nil)
+
+	body := []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-07T00:00:00Z","servings":2,"meal_type":"breakfast","user_id":9,"persist":true}`)
+
+	w := httptest.NewRecorder()
+	newPlannerRouter(recipeRepo, mealPlanRepo).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mealPlanRepo.AssertExpectations(t)
+}
+
+func TestPlannerController_GenerateRegenerateDay(t *testing.T) {
+	recipes := breakfastRecipes()
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipesByType", models.Breakfast).Return(recipes, nil)
+
+	kept := &models.MealPlan{ID: 11, UserID: 9, RecipeID: 1, Recipe: &recipes[0], Servings: 2, MealType: models.Breakfast, MealTime: time.Date(2023, 6, 5, 8, 0, 0, 0, time.UTC)}
+	stale := &models.MealPlan{ID: 12, UserID: 9, RecipeID: 1, Recipe: &recipes[0], Servings: 2, MealType: models.Breakfast, MealTime: time.Date(2023, 6, 6, 8, 0, 0, 0, time.UTC)}
+	dinner := &models.MealPlan{ID: 13, UserID: 9, RecipeID: 5, MealType: models.Dinner, MealTime: time.Date(2023, 6, 6, 19, 0, 0, 0, time.UTC)}
+
+	mealPlanRepo := new(MealPlanRepositoryMock)
+	mealPlanRepo.On("FindByUserIDAndDateRange", uint(9), mock.Anything, mock.Anything).Return([]*models.MealPlan{kept, stale, dinner}, nil)
+	mealPlanRepo.On("Replace", []uint{12}, mock.MatchedBy(func(mealPlans []models.MealPlan) bool {
+		return len(mealPlans) == 2 && mealPlans[0].ID == 11 && mealPlans[1].ID == 0
+	})).Return(nil)
+
+	body := []byte(`{
+		"start_date": "2023-06-05T00:00:00Z",
+		"end_date": "2023-06-07T00:00:00Z",
+		"servings": 2,
+		"meal_type": "breakfast",
+		"user_id": 9,
+		"persist": true,
+		"keep_existing": true,
+		"regenerate": ["2023-06-06T00:00:00Z"]
+	}`)
+
+	w := httptest.NewRecorder()
+	newPlannerRouter(recipeRepo, mealPlanRepo).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mealPlanRepo.AssertExpectations(t)
+}
+
+func TestPlannerController_GeneratePinnedValidation(t *testing.T) {
+	body := []byte(`{
+		"start_date": "2023-06-05T00:00:00Z",
+		"end_date": "2023-06-07T00:00:00Z",
+		"servings": 2,
+		"meal_type": "breakfast",
+		"pinned": [
+			{"recipe_id": 1, "meal_time": "2023-06-05T08:00:00Z"},
+			{"recipe_id": 2, "meal_time": "2023-06-05T12:00:00Z"},
+			{"meal_time": "2023-06-09T08:00:00Z"}
+		],
+		"regenerate": ["2023-06-06T00:00:00Z"]
+	}`)
+
+	w := httptest.NewRecorder()
+	newPlannerRouter(new(RecipeRepositoryMock), new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response struct {
+		Details []models.ValidationError `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	var fields []string
+	for _, detail := range response.Details {
+		fields = append(fields, detail.Field)
+	}
+	assert.ElementsMatch(t, []string{"pinned[1].meal_time", "pinned[2].recipe_id", "pinned[2].meal_time", "regenerate"}, fields)
+}
+
+func TestPlannerController_GeneratePinnedUnknownRecipe(t *testing.T) {
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipeByID", uint(9)).Return(nil, gorm.ErrRecordNotFound)
+
+	body := []byte(`{
+		"start_date": "2023-06-05T00:00:00Z",
+		"end_date": "2023-06-07T00:00:00Z",
+		"servings": 2,
+		"meal_type": "breakfast",
+		"pinned": [{"recipe_id": 9, "meal_time": "2023-06-05T08:00:00Z"}]
+	}`)
+
+	w := httptest.NewRecorder()
+	newPlannerRouter(recipeRepo, new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response struct {
+		Details []models.ValidationError `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []models.ValidationError{{Field: "pinned[0].recipe_id", Message: "does not exist"}}, response.Details)
+}
+
+func TestPlannerController_GenerateValidation(t *testing.T) {
+	body := []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-01T00:00:00Z","servings":0,"persist":true,"tuning":{"crossover_rate":2}}`)
+
+	w := httptest.NewRecorder()
+	newPlannerRouter(new(RecipeRepositoryMock), new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response struct {
+		Error   string                   `json:"error"`
+		Details []models.ValidationError `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	var fields []string
+	for _, detail := range response.Details {
+		fields = append(fields, detail.Field)
+	}
+	assert.ElementsMatch(t, []string{"end_date", "servings", "meal_type", "tuning.crossover_rate", "user_id"}, fields)
+}
+
+func TestPlannerController_GenerateTuningOff(t *testing.T) {
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipesByType", models.Breakfast).Return(breakfastRecipes(), nil)
+
+	defaults := testTuning
+	defaults.StagnationWindow = 5
+	defaults.StagnationThreshold = 0.01
+	defaults.Elitism = 2
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	service := planner.NewService(recipeRepo, new(MealPlanRepositoryMock), nil, newDefaultMealSlotRepository(), units.NewUnitConverter("g", "ml"), defaults)
+	controllers.NewPlannerController(service).RegisterRoutes(router.Group("/api"))
+
+	// A single recipe can't improve, so only a disabled window runs every
+	// generation
+	body := []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-06T00:00:00Z","servings":2,"meal_type":"breakfast","tuning":{"max_generations":30,"stagnation_window":-1,"elitism":-1}}`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var mealPlans []models.MealPlan
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &mealPlans))
+	if assert.Len(t, mealPlans, 1) {
+		assert.Equal(t, string(planner.TerminationMaxGenerations), mealPlans[0].Fitness.Termination)
+		assert.Equal(t, 30, mealPlans[0].Fitness.Generations)
+	}
+
+	body = []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-06T00:00:00Z","servings":2,"meal_type":"breakfast","tuning":{"max_generations":30}}`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &mealPlans))
+	if assert.Len(t, mealPlans, 1) {
+		assert.Equal(t, string(planner.TerminationStagnation), mealPlans[0].Fitness.Termination)
+	}
+}
+
+func TestPlannerController_GenerateTuningLimits(t *testing.T) {
+	body := []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-06T00:00:00Z","servings":2,"meal_type":"breakfast","tuning":{"population_size":1000000,"max_generations":5000,"islands":10000}}`)
+
+	w := httptest.NewRecorder()
+	newPlannerRouter(new(RecipeRepositoryMock), new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response struct {
+		Details []models.ValidationError `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.ElementsMatch(t, []models.ValidationError{
+		{Field: "tuning.population_size", Message: "must be at most 1000"},
+		{Field: "tuning.max_generations", Message: "must be at most 1000"},
+		{Field: "tuning.islands", Message: "must be at most 64"},
+	}, response.Details)
+
+	body = []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-06T00:00:00Z","servings":2,"meal_type":"breakfast","algorithm":"annealing","annealing":{"steps":2000000000}}`)
+	w = httptest.NewRecorder()
+	newPlannerRouter(new(RecipeRepositoryMock), new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []models.ValidationError{
+		{Field: "annealing.steps", Message: "must be at most 1000000"},
+	}, response.Details)
+}
+
+func TestPlannerController_GenerateAlgorithm(t *testing.T) {
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipesByType", models.Breakfast).Return(breakfastRecipes(), nil)
+
+	for _, algorithm := range []string{"annealing", "exact"} {
+		body := []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-07T00:00:00Z","servings":2,"meal_type":"breakfast","algorithm":"` + algorithm + `","annealing":{"steps":100}}`)
+
+		w := httptest.NewRecorder()
+		newPlannerRouter(recipeRepo, new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+		assert.Equal(t, http.StatusOK, w.Code, algorithm)
+
+		var mealPlans []models.MealPlan
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &mealPlans))
+		assert.Len(t, mealPlans, 2, algorithm)
+	}
+
+	body := []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-07T00:00:00Z","servings":2,"algorithm":"tabu"}`)
+	w := httptest.NewRecorder()
+	newPlannerRouter(recipeRepo, new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPlannerController_GenerateMealSlot(t *testing.T) {
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipesByType", models.Breakfast).Return(breakfastRecipes(), nil)
+	router := newPlannerRouter(recipeRepo, new(MealPlanRepositoryMock))
+
+	// Without a meal time meals are planned at the slot's default time
+	body := []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-07T00:00:00Z","servings":2,"meal_type":"breakfast"}`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var mealPlans []models.MealPlan
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &mealPlans))
+	assert.Len(t, mealPlans, 2)
+	for i, mealPlan := range mealPlans {
+		assert.Equal(t, time.Date(2023, 6, 5+i, 8, 0, 0, 0, time.UTC), mealPlan.MealTime.UTC())
+	}
+
+	for _, mealType := range []string{`"elevenses"`, `""`} {
+		body = []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-07T00:00:00Z","servings":2,"meal_type":` + mealType + `}`)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"meal_type"`)
+	}
+}
+
+func TestPlannerController_GenerateNoRecipes(t *testing.T) {
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipesByType", models.Dinner).Return([]models.Recipe{}, nil)
+
+	body := []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-06T00:00:00Z","servings":2,"meal_type":"dinner"}`)
+
+	w := httptest.NewRecorder()
+	newPlannerRouter(recipeRepo, new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestPlannerController_GenerateHousehold(t *testing.T) {
+	recipes := breakfastRecipes()
+	recipes = append(recipes, models.Recipe{
+		ID:        2,
+		Title:     "Scrambled eggs",
+		Servings:  1,
+		Version:   1,
+		MealSlots: models.MealTypes{models.Breakfast},
+		RecipeIngredients: &[]models.RecipeIngredient{
+			{
+				Ingredient: models.Ingredient{
+					Name:         "Eggs",
+					UnitType:     "mass",
+					PricePerUnit: 2,
+					Nutrients:    models.NutritionalValues{Calories: 1.5, Protein: 0.13},
+				},
+				Quantity: 150,
+				Unit:     "g",
+			},
+		},
+	})
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipesByType", models.Breakfast).Return(recipes, nil)
+
+	householdRepo := new(HouseholdRepositoryMock)
+	householdRepo.On("FindByID", uint(4)).Return(&models.Household{
+		UserID: 1,
+		Name:   "Home",
+		Members: []models.HouseholdMember{
+			{Name: "Toddler", DietaryRestrictions: models.StringList{"oat"}, TargetNutrients: models.NutritionalValues{Calories: 1000}, MaxNutrients: models.NutritionalValues{Calories: 1200}},
+			{Name: "Athlete", TargetNutrients: models.NutritionalValues{Calories: 3500}, MaxNutrients: models.NutritionalValues{Calories: 4000}},
+			{Name: "Night owl", Meals: models.MealTypes{models.Dinner}},
+		},
+	}, nil)
+	householdRepo.On("FindByID", uint(9)).Return(nil, gorm.ErrRecordNotFound)
+
+	router := newHouseholdPlannerRouter(recipeRepo, new(MealPlanRepositoryMock), householdRepo)
+
+	body := []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-07T00:00:00Z","user_id":1,"household_id":4,"meal_type":"breakfast"}`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var mealPlans []models.MealPlan
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &mealPlans))
+	assert.Len(t, mealPlans, 2)
+	for _, mealPlan := range mealPlans {
+		// The toddler doesn't eat oats, and 0.5 + 1.75 portions need 3 servings
+		assert.Equal(t, uint(2), mealPlan.RecipeID)
+		assert.Equal(t, 3, mealPlan.Servings)
+
+		members := make(map[string]bool)
+		for _, score := range mealPlan.Fitness.Objectives {
+			if score.Member != "" {
+				members[score.Member] = true
+			}
+		}
+		assert.Equal(t, map[string]bool{"Toddler": true, "Athlete": true}, members)
+	}
+
+	// Households can't be used without their owner
+	for body, field := range map[string]models.ValidationError{
+		`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-07T00:00:00Z","user_id":1,"household_id":9,"meal_type":"breakfast"}`:  {Field: "household_id", Message: "does not exist"},
+		`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-07T00:00:00Z","user_id":2,"household_id":4,"meal_type":"breakfast"}`:  {Field: "household_id", Message: "does not belong to the user"},
+		`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-07T00:00:00Z","servings":3,"household_id":4,"meal_type":"breakfast"}`: {Field: "user_id", Message: "is required to plan for a household"},
+	} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader([]byte(body))))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response struct {
+			Details []models.ValidationError `json:"details"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []models.ValidationError{field}, response.Details, body)
+	}
+}
+
+func TestPlannerController_GenerateProfile(t *testing.T) {
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipesByType", models.Breakfast).Return(breakfastRecipes(), nil)
+
+	// A quarter of the day's 2759 kcal for two, with the calorie maximum overridden
+	body := []byte(`{
+		"start_date": "2023-06-05T00:00:00Z",
+		"end_date": "2023-06-06T00:00:00Z",
+		"servings": 2,
+		"meal_type": "breakfast",
+		"algorithm": "exact",
+		"max_nutrients": {"calories": 1600},
+		"profile": {"age": 30, "sex": "male", "weight": 80, "height": 180, "activity_level": "moderate"}
+	}`)
+
+	w := httptest.NewRecorder()
+	newPlannerRouter(recipeRepo, new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var mealPlans []models.MealPlan
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &mealPlans))
+	assert.Len(t, mealPlans, 1)
+	for _, score := range mealPlans[0].Fitness.Objectives {
+		if score.Objective == "calories" {
+			assert.InDelta(t, 2759*0.25*2, score.Target, 1e-9)
+			assert.Equal(t, 1600.0, score.Max)
+		}
+	}
+
+	body = []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-06T00:00:00Z","servings":2,"profile":{"age":30}}`)
+	w = httptest.NewRecorder()
+	newPlannerRouter(recipeRepo, new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Profiles without an age, or an adult's height, are rejected rather
+	// than given made-up targets
+	for profile, field := range map[string]string{
+		`{"weight": 70, "height": 175}`: "profile.age",
+		`{"age": 30, "weight": 70}`:     "profile.height",
+	} {
+		body = []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-06T00:00:00Z","servings":2,"meal_type":"breakfast","profile":` + profile + `}`)
+		w = httptest.NewRecorder()
+		newPlannerRouter(recipeRepo, new(MealPlanRepositoryMock)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/meal-plans/generate", bytes.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, profile)
+		assert.Contains(t, w.Body.String(), `"field":"`+field+`"`, profile)
+	}
+}
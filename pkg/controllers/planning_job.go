@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cvele/recipe/pkg/jobs"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/planner"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+)
+
+// heartbeatInterval keeps idle event streams from being closed by proxies.
+const heartbeatInterval = 15 * time.Second
+
+type PlanningJobController struct {
+	runner jobs.PlanningRunnerInterface
+	repo   repositories.PlanningJobRepository
+}
+
+func NewPlanningJobController(runner jobs.PlanningRunnerInterface, repo repositories.PlanningJobRepository) *PlanningJobController {
+	return &PlanningJobController{runner: runner, repo: repo}
+}
+
+func (jc *PlanningJobController) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/planning-jobs", jc.submitJob)
+	r.GET("/planning-jobs/:id", jc.getJob)
+	r.POST("/planning-jobs/:id/cancel", jc.cancelJob)
+	r.GET("/planning-jobs/:id/result", jc.getJobResult)
+	r.GET("/planning-jobs/:id/events", jc.streamJobEvents)
+}
+
+// submitJob queues a planning request and returns the job to poll.
+func (jc *PlanningJobController) submitJob(c *gin.Context) {
+	var req planner.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, err := jc.runner.Submit(req)
+	if errors.Is(err, jobs.ErrQueueFull) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	var validationErrors models.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		respondWithValidationError(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Location", fmt.Sprintf("%s/%d", c.FullPath(), job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (jc *PlanningJobController) getJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	job, err := jc.repo.FindByID(uint(id))
+	if err != nil {
+		respondWithPlanningJobError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+func (jc *PlanningJobController) cancelJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	job, err := jc.runner.Cancel(uint(id))
+	if errors.Is(err, jobs.ErrJobFinished) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": job.Status})
+		return
+	}
+	if err != nil {
+		respondWithPlanningJobError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// getJobResult returns the planned meals of a succeeded job.
+func (jc *PlanningJobController) getJobResult(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	job, err := jc.repo.FindByID(uint(id))
+	if err != nil {
+		respondWithPlanningJobError(c, err)
+		return
+	}
+	if job.Status != models.PlanningJobSucceeded {
+		c.JSON(http.StatusConflict, gin.H{"error": "Planning job has no result", "status": job.Status, "job_error": job.Error})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(job.Result))
+}
+
+// streamJobEvents streams a job's progress as Server-Sent Events. Each
+// generation is sent as a "progress" event; a final "status" event carries
+// the job once it has finished.
+func (jc *PlanningJobController) streamJobEvents(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	// Subscribe before checking the status so the end of the job can't be
+	// missed in between
+	events, unsubscribe := jc.runner.Subscribe(uint(id))
+	defer unsubscribe()
+
+	job, err := jc.repo.FindByID(uint(id))
+	if err != nil {
+		respondWithPlanningJobError(c, err)
+		return
+	}
+	if job.Status.IsFinished() {
+		c.SSEvent("status", job)
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	// Stream manually rather than with c.Stream, which relies on the
+	// deprecated http.CloseNotifier
+	for {
+		select {
+		case progress, ok := <-events:
+			if !ok {
+				if job, err := jc.repo.FindByID(uint(id)); err == nil {
+					c.SSEvent("status", job)
+				}
+				return
+			}
+			c.SSEvent("progress", progress)
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", gin.H{"status": "waiting"})
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+func respondWithPlanningJobError(c *gin.Context, err error) {
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Planning job not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,181 @@
+package controllers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cvele/recipe/pkg/controllers"
+	"github.com/cvele/recipe/pkg/jobs"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/planner"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type PlanningRunnerMock struct {
+	mock.Mock
+}
+
+func (m *PlanningRunnerMock) Submit(req planner.Request) (*models.PlanningJob, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PlanningJob), args.Error(1)
+}
+
+func (m *PlanningRunnerMock) Cancel(id uint) (*models.PlanningJob, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PlanningJob), args.Error(1)
+}
+
+func (m *PlanningRunnerMock) Subscribe(id uint) (<-chan planner.Progress, func()) {
+	args := m.Called(id)
+	return args.Get(0).(chan planner.Progress), func() {}
+}
+
+type PlanningJobRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *PlanningJobRepositoryMock) Create(job *models.PlanningJob) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *PlanningJobRepositoryMock) FindByID(id uint) (*models.PlanningJob, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PlanningJob), args.Error(1)
+}
+
+func (m *PlanningJobRepositoryMock) FindByStatus(status models.PlanningJobStatus) ([]*models.PlanningJob, error) {
+	args := m.Called(status)
+	return args.Get(0).([]*models.PlanningJob), args.Error(1)
+}
+
+func (m *PlanningJobRepositoryMock) Update(job *models.PlanningJob) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *PlanningJobRepositoryMock) UpdateProgress(id uint, progress float64) error {
+	args := m.Called(id, progress)
+	return args.Error(0)
+}
+
+func newPlanningJobRouter(runner *PlanningRunnerMock, repo *PlanningJobRepositoryMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controllers.NewPlanningJobController(runner, repo).RegisterRoutes(router.Group("/api"))
+	return router
+}
+
+func TestPlanningJobController_Submit(t *testing.T) {
+	job := &models.PlanningJob{Status: models.PlanningJobQueued}
+	job.ID = 12
+
+	runner := new(PlanningRunnerMock)
+	runner.On("Submit", mock.MatchedBy(func(req planner.Request) bool { return req.Servings == 2 })).Return(job, nil).Once()
+	runner.On("Submit", mock.Anything).Return(nil, jobs.ErrQueueFull).Once()
+
+	router := newPlanningJobRouter(runner, new(PlanningJobRepositoryMock))
+	body := []byte(`{"start_date":"2023-06-05T00:00:00Z","end_date":"2023-06-07T00:00:00Z","servings":2}`)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/planning-jobs", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/api/planning-jobs/12", w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/planning-jobs", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestPlanningJobController_StatusAndResult(t *testing.T) {
+	repo := new(PlanningJobRepositoryMock)
+	repo.On("FindByID", uint(1)).Return(&models.PlanningJob{Status: models.PlanningJobRunning, Progress: 0.5}, nil)
+	repo.On("FindByID", uint(2)).Return(&models.PlanningJob{Status: models.PlanningJobSucceeded, Result: `[{"RecipeID":3}]`}, nil)
+	repo.On("FindByID", uint(3)).Return(nil, gorm.ErrRecordNotFound)
+
+	router := newPlanningJobRouter(new(PlanningRunnerMock), repo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/planning-jobs/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"progress":0.5`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/planning-jobs/1/result", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/planning-jobs/2/result", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"RecipeID":3}]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/planning-jobs/3", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPlanningJobController_Cancel(t *testing.T) {
+	runner := new(PlanningRunnerMock)
+	runner.On("Cancel", uint(4)).Return(&models.PlanningJob{Status: models.PlanningJobCancelled}, nil)
+	runner.On("Cancel", uint(5)).Return(&models.PlanningJob{Status: models.PlanningJobSucceeded}, jobs.ErrJobFinished)
+
+	router := newPlanningJobRouter(runner, new(PlanningJobRepositoryMock))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/planning-jobs/4/cancel", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/planning-jobs/5/cancel", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestPlanningJobController_StreamEvents(t *testing.T) {
+	events := make(chan planner.Progress, 2)
+	events <- planner.Progress{Day: 0, Days: 1, Generation: 0, BestRecipeID: 3, BestRecipeTitle: "Shakshuka"}
+	events <- planner.Progress{Day: 0, Days: 1, Generation: 1, BestRecipeID: 3, TerminationReason: planner.TerminationMaxGenerations}
+	close(events)
+
+	runner := new(PlanningRunnerMock)
+	runner.On("Subscribe", uint(6)).Return(events)
+	runner.On("Subscribe", uint(7)).Return(make(chan planner.Progress))
+
+	repo := new(PlanningJobRepositoryMock)
+	repo.On("FindByID", uint(6)).Return(&models.PlanningJob{Status: models.PlanningJobRunning}, nil).Once()
+	repo.On("FindByID", uint(6)).Return(&models.PlanningJob{Status: models.PlanningJobSucceeded}, nil).Once()
+	repo.On("FindByID", uint(7)).Return(&models.PlanningJob{Status: models.PlanningJobFailed, Error: "boom"}, nil)
+
+	router := newPlanningJobRouter(runner, repo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/planning-jobs/6/events", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, "event:progress\ndata:{\"day\":0,\"days\":1,\"generation\":0")
+	assert.Contains(t, body, "\"best_recipe_title\":\"Shakshuka\"")
+	assert.Contains(t, body, "\"termination_reason\":\"max_generations\"")
+	assert.Contains(t, body, "event:status\ndata:")
+	assert.Contains(t, body, "\"status\":\"succeeded\"")
+
+	// Finished jobs only send their final status
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/planning-jobs/7/events", nil))
+	assert.NotContains(t, w.Body.String(), "event:progress")
+	assert.Contains(t, w.Body.String(), "\"status\":\"failed\"")
+}
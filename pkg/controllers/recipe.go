@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/cvele/recipe/pkg/models"
 	"github.com/cvele/recipe/pkg/repositories"
 	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
 )
 
 type RecipeController struct {
@@ -23,10 +27,35 @@ func (rc *RecipeController) RegisterRoutes(r *gin.RouterGroup) {
 	r.GET("/recipes/:id", rc.getRecipeByID)
 	r.PUT("/recipes/:id", rc.updateRecipe)
 	r.DELETE("/recipes/:id", rc.deleteRecipe)
+	r.GET("/recipes/:id/versions", rc.getRecipeVersions)
+	r.GET("/recipes/:id/versions/:version", rc.getRecipeVersion)
+	r.POST("/recipes/:id/versions/:version/revert", rc.revertRecipe)
+	r.GET("/recipes/:id/diff", rc.diffRecipeVersions)
 }
 
+// getAllRecipes lists the recipes, optionally only those with every tag
+// query parameter, the cuisine and in the collection_id collection.
 func (rc *RecipeController) getAllRecipes(c *gin.Context) {
-	recipes, err := rc.repo.GetAllRecipes()
+	filter := repositories.RecipeFilter{
+		Tags:    c.QueryArray("tag"),
+		Cuisine: c.Query("cuisine"),
+	}
+	if collectionID := c.Query("collection_id"); collectionID != "" {
+		id, err := strconv.Atoi(collectionID)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID format"})
+			return
+		}
+		filter.CollectionID = uint(id)
+	}
+
+	var recipes []models.Recipe
+	var err error
+	if len(filter.Tags) == 0 && filter.Cuisine == "" && filter.CollectionID == 0 {
+		recipes, err = rc.repo.GetAllRecipes()
+	} else {
+		recipes, err = rc.repo.FindRecipes(filter)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -41,10 +70,20 @@ func (rc *RecipeController) getRecipeByID(c *gin.Context) {
 		return
 	}
 	recipe, err := rc.repo.GetRecipeByID(uint(id))
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	etag := recipeETag(recipe.Version)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
 	c.JSON(http.StatusOK, recipe)
 }
 
@@ -54,14 +93,28 @@ func (rc *RecipeController) createRecipe(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := recipe.Validate(); err != nil {
+		respondWithValidationError(c, err)
+		return
+	}
+	recipe.Complete()
 	err := rc.repo.CreateRecipe(&recipe)
+	if errors.Is(err, repositories.ErrUnknownMealSlot) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown meal slot in meal_slots"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.Header("ETag", recipeETag(recipe.Version))
 	c.JSON(http.StatusCreated, recipe)
 }
 
+// updateRecipe saves the recipe as its next version. The update must name
+// the version it's based on, in an If-Match header or the body's version,
+// and is rejected when the recipe changed since. If-Match: * updates
+// whichever version is the latest.
 func (rc *RecipeController) updateRecipe(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -73,13 +126,24 @@ func (rc *RecipeController) updateRecipe(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	recipe.ID = uint(id)
-	err = rc.repo.UpdateRecipe(&recipe)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		base, err := parseIfMatch(ifMatch)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		recipe.Version = base
+	} else if recipe.Version <= 0 {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "The version the update is based on is required, as an If-Match header or the version field"})
 		return
 	}
-	c.JSON(http.StatusOK, recipe)
+	if err := recipe.Validate(); err != nil {
+		respondWithValidationError(c, err)
+		return
+	}
+	recipe.Complete()
+	recipe.ID = uint(id)
+	rc.saveVersion(c, &recipe)
 }
 
 func (rc *RecipeController) deleteRecipe(c *gin.Context) {
@@ -95,3 +159,177 @@ func (rc *RecipeController) deleteRecipe(c *gin.Context) {
 	}
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// getRecipeVersions lists the recipe's versions, oldest first.
+func (rc *RecipeController) getRecipeVersions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	versions, err := rc.repo.GetRecipeVersions(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(versions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// getRecipeVersion returns a version of the recipe. Versions don't change,
+// so they can be cached by their ETag like the latest one.
+func (rc *RecipeController) getRecipeVersion(c *gin.Context) {
+	recipe, ok := rc.findVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+	etag := recipeETag(recipe.Version)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, recipe)
+}
+
+// revertRecipe saves the content of an earlier version as the recipe's new
+// version. With an If-Match header it's rejected when the latest version
+// isn't the one in the header.
+func (rc *RecipeController) revertRecipe(c *gin.Context) {
+	base := 0
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		var err error
+		if base, err = parseIfMatch(ifMatch); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	recipe, ok := rc.findVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+	recipe.Version = base
+	rc.saveVersion(c, recipe)
+}
+
+// saveVersion saves the recipe as its next version and responds with it, or
+// with the latest version when the recipe changed since the one recipe is
+// based on.
+func (rc *RecipeController) saveVersion(c *gin.Context, recipe *models.Recipe) {
+	err := rc.repo.UpdateRecipe(recipe)
+	if errors.Is(err, repositories.ErrVersionConflict) {
+		current, err := rc.repo.GetRecipeByID(recipe.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("ETag", recipeETag(current.Version))
+		c.JSON(http.StatusConflict, gin.H{
+			"error":           "Recipe was changed since version " + strconv.Itoa(recipe.Version),
+			"current_version": current.Version,
+			"recipe":          current,
+		})
+		return
+	}
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	if errors.Is(err, repositories.ErrUnknownMealSlot) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown meal slot in meal_slots"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("ETag", recipeETag(recipe.Version))
+	c.JSON(http.StatusOK, recipe)
+}
+
+// diffRecipeVersions compares the recipe's versions from and to. to
+// defaults to the latest version.
+func (rc *RecipeController) diffRecipeVersions(c *gin.Context) {
+	from, ok := rc.findVersion(c, c.Query("from"))
+	if !ok {
+		return
+	}
+
+	var to *models.Recipe
+	if c.Query("to") == "" {
+		id, _ := strconv.Atoi(c.Param("id"))
+		latest, err := rc.repo.GetRecipeByID(uint(id))
+		if gorm.IsRecordNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		to = latest
+	} else if to, ok = rc.findVersion(c, c.Query("to")); !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DiffRecipes(*from, *to))
+}
+
+// findVersion returns the version of the recipe in the path, or responds
+// with an error and returns false.
+func (rc *RecipeController) findVersion(c *gin.Context, versionParam string) (*models.Recipe, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return nil, false
+	}
+	version, err := strconv.Atoi(versionParam)
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return nil, false
+	}
+
+	recipe, err := rc.repo.GetRecipeVersion(uint(id), version)
+	if gorm.IsRecordNotFoundError(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe version not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return recipe, true
+}
+
+// recipeETag returns the ETag of a recipe's version.
+func recipeETag(version int) string {
+	return fmt.Sprintf("%q", strconv.Itoa(version))
+}
+
+// parseIfMatch returns the version in an If-Match header, or 0 for *.
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 || header != recipeETag(version) {
+		return 0, errors.New(`Invalid If-Match header, expected a single recipe ETag such as "3"`)
+	}
+	return version, nil
+}
+
+// etagMatches reports whether an If-None-Match header lists etag. Weak
+// ETags match their strong counterpart.
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,374 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cvele/recipe/pkg/controllers"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newRecipeRouter(repo *RecipeRepositoryMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controllers.NewRecipeController(repo).RegisterRoutes(router.Group("/api"))
+	return router
+}
+
+func TestRecipeController_CreateWithSteps(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("CreateRecipe", mock.AnythingOfType("*models.Recipe")).Return(nil)
+
+	body := []byte(`{
+		"title": "Bread",
+		"description": "A simple loaf",
+		"servings": 8,
+		"preparation_time": 20,
+		"cook_time": 40,
+		"equipment": ["Oven"],
+		"ingredients": [{"ingredient_id": 1, "quantity": 500, "unit": "g"}, {"ingredient_id": 2, "quantity": 300, "unit": "ml"}],
+		"steps": [
+			{"text": "Mix flour and water", "active_time": 15, "equipment": ["Bowl"], "ingredient_ids": [1, 2]},
+			{"text": "Let the dough rise", "passive_time": 90},
+			{"text": "Bake", "passive_time": 40, "temperature": {"value": 220, "unit": "c"}, "equipment": ["oven", "Loaf tin"]}
+		]
+	}`)
+
+	w := httptest.NewRecorder()
+	newRecipeRouter(repo).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/recipes", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var recipe models.Recipe
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &recipe))
+	assert.Len(t, recipe.Steps, 3)
+	assert.Equal(t, []uint{1, 2}, recipe.Steps[0].IngredientIDs)
+	assert.Equal(t, &models.Temperature{Value: 220, Unit: "c"}, recipe.Steps[2].Temperature)
+	// The steps take longer than preparation and cooking
+	assert.Equal(t, 145, recipe.TotalTime)
+	assert.Equal(t, models.StringList{"Oven", "Bowl", "Loaf tin"}, recipe.Equipment)
+}
+
+func TestRecipeController_CreateWithTags(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("CreateRecipe", mock.AnythingOfType("*models.Recipe")).Return(nil)
+
+	body := []byte(`{"title": "Pad thai", "cuisine": " Thai ", "tags": ["Quick", "noodles", " quick "]}`)
+
+	w := httptest.NewRecorder()
+	newRecipeRouter(repo).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/recipes", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	saved := repo.Calls[0].Arguments.Get(0).(*models.Recipe)
+	assert.Equal(t, "Thai", saved.Cuisine)
+	assert.Equal(t, models.StringList{"quick", "noodles"}, saved.Tags)
+}
+
+func TestRecipeController_CreateWithMealSlots(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("CreateRecipe", mock.AnythingOfType("*models.Recipe")).Return(nil).Once()
+	repo.On("CreateRecipe", mock.AnythingOfType("*models.Recipe")).Return(repositories.ErrUnknownMealSlot)
+	router := newRecipeRouter(repo)
+
+	body := []byte(`{"title": "Protein oats", "meal_slots": ["Breakfast", "pre_workout", "breakfast "]}`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/recipes", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	saved := repo.Calls[0].Arguments.Get(0).(*models.Recipe)
+	assert.Equal(t, models.MealTypes{models.Breakfast, "pre_workout"}, saved.MealSlots)
+
+	// Slots that don't exist are rejected by the repository
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/recipes", bytes.NewReader([]byte(`{"title": "Toast", "meal_slots": ["elevenses"]}`))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/recipes", bytes.NewReader([]byte(`{"title": "Toast", "meal_slots": ["second breakfast"]}`))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	repo.AssertNumberOfCalls(t, "CreateRecipe", 2)
+}
+
+func TestRecipeController_Filter(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("GetAllRecipes").Return([]models.Recipe{{ID: 1}, {ID: 2}}, nil)
+	repo.On("FindRecipes", repositories.RecipeFilter{Tags: []string{"quick", "vegan"}, Cuisine: "thai", CollectionID: 3}).Return([]models.Recipe{{ID: 2}}, nil)
+	router := newRecipeRouter(repo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var recipes []models.Recipe
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &recipes))
+	assert.Len(t, recipes, 2)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes?tag=quick&tag=vegan&cuisine=thai&collection_id=3", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &recipes))
+	assert.Len(t, recipes, 1)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes?collection_id=soups", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRecipeController_CreateValidation(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+
+	body := []byte(`{
+		"title": "Bread",
+		"cook_time": -5,
+		"ingredients": [{"ingredient_id": 1, "quantity": 500, "unit": "g"}],
+		"steps": [
+			{"text": " ", "temperature": {"value": 220, "unit": "k"}},
+			{"text": "Add the salt", "active_time": -1, "ingredient_ids": [3]}
+		]
+	}`)
+
+	w := httptest.NewRecorder()
+	newRecipeRouter(repo).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/recipes", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response struct {
+		Details models.ValidationErrors `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.ValidationErrors{
+		{Field: "cook_time", Message: "must not be negative"},
+		{Field: "steps[0].text", Message: "is required"},
+		{Field: "steps[0].temperature.unit", Message: "must be one of c, f or gas-mark"},
+		{Field: "steps[1].active_time", Message: "must not be negative"},
+		{Field: "steps[1].ingredient_ids[0]", Message: "is not an ingredient of the recipe"},
+	}, response.Details)
+	repo.AssertNotCalled(t, "CreateRecipe", mock.Anything)
+}
+
+func TestRecipeController_UpdateWithSteps(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("UpdateRecipe", mock.AnythingOfType("*models.Recipe")).Return(nil)
+
+	body := []byte(`{"title": "Bread", "version": 2, "total_time": 180, "steps": [{"text": "Bake", "passive_time": 40}]}`)
+
+	w := httptest.NewRecorder()
+	newRecipeRouter(repo).ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/recipes/5", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	saved := repo.Calls[0].Arguments.Get(0).(*models.Recipe)
+	assert.Equal(t, uint(5), saved.ID)
+	assert.Equal(t, 2, saved.Version)
+	assert.Equal(t, 180, saved.TotalTime)
+	assert.Equal(t, models.RecipeSteps{{Text: "Bake", PassiveTime: 40}}, saved.Steps)
+}
+
+func TestRecipeController_UpdateIfMatch(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("UpdateRecipe", mock.AnythingOfType("*models.Recipe")).Run(func(args mock.Arguments) {
+		recipe := args.Get(0).(*models.Recipe)
+		recipe.Version++
+	}).Return(nil)
+
+	// The header wins over the body
+	req := httptest.NewRequest(http.MethodPut, "/api/recipes/5", bytes.NewReader([]byte(`{"title": "Bread", "version": 1}`)))
+	req.Header.Set("If-Match", `"3"`)
+	w := httptest.NewRecorder()
+	newRecipeRouter(repo).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+	assert.Equal(t, 4, repo.Calls[0].Arguments.Get(0).(*models.Recipe).Version)
+}
+
+func TestRecipeController_UpdateConflict(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("UpdateRecipe", mock.AnythingOfType("*models.Recipe")).Return(repositories.ErrVersionConflict)
+	repo.On("GetRecipeByID", uint(5)).Return(breadVersion(4, 450, nil), nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/recipes/5", bytes.NewReader([]byte(`{"title": "Bread"}`)))
+	req.Header.Set("If-Match", `"3"`)
+	w := httptest.NewRecorder()
+	newRecipeRouter(repo).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+
+	var response struct {
+		CurrentVersion int           `json:"current_version"`
+		Recipe         models.Recipe `json:"recipe"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 4, response.CurrentVersion)
+	assert.Equal(t, 450.0, (*response.Recipe.RecipeIngredients)[0].Quantity)
+}
+
+func TestRecipeController_UpdatePrecondition(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	router := newRecipeRouter(repo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/recipes/5", bytes.NewReader([]byte(`{"title": "Bread"}`))))
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+
+	for _, ifMatch := range []string{`W/"3"`, `"3", "4"`, `3`, `"abc"`} {
+		req := httptest.NewRequest(http.MethodPut, "/api/recipes/5", bytes.NewReader([]byte(`{"title": "Bread"}`)))
+		req.Header.Set("If-Match", ifMatch)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, ifMatch)
+	}
+	repo.AssertNotCalled(t, "UpdateRecipe", mock.Anything)
+}
+
+func TestRecipeController_ConditionalGet(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("GetRecipeByID", uint(5)).Return(breadVersion(3, 500, nil), nil)
+	repo.On("GetRecipeByID", uint(9)).Return(nil, gorm.ErrRecordNotFound)
+	router := newRecipeRouter(repo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/5", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/recipes/5", nil)
+	req.Header.Set("If-None-Match", `"2", W/"3"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/recipes/5", nil)
+	req.Header.Set("If-None-Match", `"2"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/9", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func breadVersion(version int, flour float64, steps models.RecipeSteps) *models.Recipe {
+	return &models.Recipe{
+		ID:       5,
+		Title:    "Bread",
+		Servings: 8,
+		Version:  version,
+		Steps:    steps,
+		RecipeIngredients: &[]models.RecipeIngredient{
+			{IngredientID: 1, Ingredient: models.Ingredient{Name: "Flour"}, Quantity: flour, Unit: "g"},
+			{IngredientID: 2, Ingredient: models.Ingredient{Name: "Water"}, Quantity: 300, Unit: "ml"},
+		},
+	}
+}
+
+func TestRecipeController_GetVersions(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("GetRecipeVersions", uint(5)).Return([]models.RecipeVersion{
+		{RecipeID: 5, Version: 1, Title: "Bread"},
+		{RecipeID: 5, Version: 2, Title: "Sourdough bread"},
+	}, nil)
+	repo.On("GetRecipeVersions", uint(6)).Return([]models.RecipeVersion{}, nil)
+	router := newRecipeRouter(repo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/5/versions", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var versions []models.RecipeVersion
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &versions))
+	assert.Len(t, versions, 2)
+	assert.Equal(t, "Sourdough bread", versions[1].Title)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/6/versions", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRecipeController_GetVersion(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("GetRecipeVersion", uint(5), 1).Return(breadVersion(1, 500, nil), nil)
+	repo.On("GetRecipeVersion", uint(5), 9).Return(nil, gorm.ErrRecordNotFound)
+	router := newRecipeRouter(repo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/5/versions/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var recipe models.Recipe
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &recipe))
+	assert.Equal(t, 1, recipe.Version)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/5/versions/9", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/5/versions/latest", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRecipeController_DiffVersions(t *testing.T) {
+	latest := breadVersion(3, 450, models.RecipeSteps{{Text: "Bake", PassiveTime: 40}})
+	latest.Title = "Sourdough bread"
+	*latest.RecipeIngredients = append((*latest.RecipeIngredients)[:1], models.RecipeIngredient{IngredientID: 3, Ingredient: models.Ingredient{Name: "Starter"}, Quantity: 100, Unit: "g"})
+
+	repo := new(RecipeRepositoryMock)
+	repo.On("GetRecipeVersion", uint(5), 1).Return(breadVersion(1, 500, models.RecipeSteps{}), nil)
+	repo.On("GetRecipeByID", uint(5)).Return(latest, nil)
+
+	w := httptest.NewRecorder()
+	newRecipeRouter(repo).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/recipes/5/diff?from=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var diff models.RecipeDiff
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+	assert.Equal(t, 1, diff.From)
+	assert.Equal(t, 3, diff.To)
+	assert.Len(t, diff.Fields, 2)
+	assert.Equal(t, "title", diff.Fields[0].Field)
+	assert.Equal(t, "Bread", diff.Fields[0].From)
+	assert.Equal(t, "Sourdough bread", diff.Fields[0].To)
+	assert.Equal(t, "steps", diff.Fields[1].Field)
+	assert.Equal(t, []models.IngredientChange{
+		{IngredientID: 1, Name: "Flour", Change: models.IngredientChanged, From: &models.IngredientAmount{Quantity: 500, Unit: "g"}, To: &models.IngredientAmount{Quantity: 450, Unit: "g"}},
+		{IngredientID: 2, Name: "Water", Change: models.IngredientRemoved, From: &models.IngredientAmount{Quantity: 300, Unit: "ml"}},
+		{IngredientID: 3, Name: "Starter", Change: models.IngredientAdded, To: &models.IngredientAmount{Quantity: 100, Unit: "g"}},
+	}, diff.Ingredients)
+}
+
+func TestRecipeController_Revert(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("GetRecipeVersion", uint(5), 1).Return(breadVersion(1, 500, nil), nil)
+	repo.On("UpdateRecipe", mock.AnythingOfType("*models.Recipe")).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Recipe).Version = 4
+	}).Return(nil)
+
+	w := httptest.NewRecorder()
+	newRecipeRouter(repo).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/recipes/5/versions/1/revert", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var recipe models.Recipe
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &recipe))
+	assert.Equal(t, 4, recipe.Version)
+	assert.Equal(t, 500.0, (*recipe.RecipeIngredients)[0].Quantity)
+	saved := repo.Calls[1].Arguments.Get(0).(*models.Recipe)
+	assert.Equal(t, uint(5), saved.ID)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+}
+
+func TestRecipeController_RevertConflict(t *testing.T) {
+	repo := new(RecipeRepositoryMock)
+	repo.On("GetRecipeVersion", uint(5), 1).Return(breadVersion(1, 500, nil), nil)
+	repo.On("UpdateRecipe", mock.AnythingOfType("*models.Recipe")).Return(repositories.ErrVersionConflict)
+	repo.On("GetRecipeByID", uint(5)).Return(breadVersion(4, 450, nil), nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/recipes/5/versions/1/revert", nil)
+	req.Header.Set("If-Match", `"3"`)
+	w := httptest.NewRecorder()
+	newRecipeRouter(repo).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 3, repo.Calls[1].Arguments.Get(0).(*models.Recipe).Version)
+}
@@ -27,7 +27,72 @@ func InitDB(cfg *config.Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
-	db.AutoMigrate(&models.Recipe{}, &models.Ingredient{})
+	db.AutoMigrate(&models.Recipe{}, &models.RecipeVersion{}, &models.Ingredient{}, &models.IngredientAlias{}, &models.MealPlan{}, &models.PlanningJob{}, &models.Household{}, &models.HouseholdMember{}, &models.Collection{}, &models.MealSlot{}, &models.RecipeMealSlot{})
+
+	if err := SeedMealSlots(db); err != nil {
+		return nil, err
+	}
+	if err := migrateMealSlots(db); err != nil {
+		return nil, err
+	}
 
 	return db, nil
 }
+
+// SeedMealSlots creates the default meal slots when there are no meal slots
+// yet. Default slots deleted later are not created again.
+func SeedMealSlots(db *gorm.DB) error {
+	var count int
+	if err := db.Unscoped().Model(&models.MealSlot{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, slot := range models.DefaultMealSlots {
+			slot := slot
+			if err := tx.Create(&slot).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateMealSlots moves the meal types of earlier versions, a number on
+// meal plans and a flag per meal type on recipes, to the default meal
+// slots.
+func migrateMealSlots(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if tx.Dialect().HasColumn("meal_plans", "meal_type") {
+			err := tx.Exec(`UPDATE meal_plans SET meal_slot = CASE meal_type
+				WHEN 0 THEN 'breakfast' WHEN 1 THEN 'lunch' WHEN 2 THEN 'dinner' WHEN 3 THEN 'snack' END
+				WHERE meal_slot IS NULL OR meal_slot = ''`).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.MealPlan{}).DropColumn("meal_type").Error; err != nil {
+				return err
+			}
+		}
+
+		for _, slot := range models.DefaultMealSlots {
+			column := "is_" + slot.Name.String()
+			if !tx.Dialect().HasColumn("recipes", column) {
+				continue
+			}
+			err := tx.Exec(`INSERT INTO recipe_meal_slots (recipe_id, meal_slot_id)
+				SELECT recipes.id, meal_slots.id FROM recipes, meal_slots
+				WHERE meal_slots.name = ? AND recipes.`+column+` = ?`, slot.Name, true).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Recipe{}).DropColumn(column).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,88 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvele/recipe/pkg/db"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gormDB, err := gorm.Open("postgres", sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { gormDB.Close() })
+	return gormDB, mock
+}
+
+func TestSeedMealSlots(t *testing.T) {
+	gormDB, mock := newMockDB(t)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "meal_slots"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	for i, slot := range models.DefaultMealSlots {
+		mock.ExpectQuery(`INSERT INTO "meal_slots"`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, slot.Name, slot.Label, slot.DefaultTime, slot.Duration, slot.Share).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(i + 1))
+	}
+	mock.ExpectCommit()
+
+	assert.NoError(t, db.SeedMealSlots(gormDB))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedMealSlots_AfterDelete(t *testing.T) {
+	gormDB, mock := newMockDB(t)
+
+	// The slot is deleted for good, so its name can be used again
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "recipe_meal_slots" WHERE \(meal_slot_id = \$1\)`).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM "meal_slots" WHERE \(id = \$1\)`).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, repositories.NewGormMealSlotRepository(gormDB).Delete(3))
+
+	// Seeding again leaves the remaining slots alone and doesn't bring
+	// the deleted one back
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "meal_slots"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	assert.NoError(t, db.SeedMealSlots(gormDB))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMealPlanUpdate_Unchanged(t *testing.T) {
+	gormDB, mock := newMockDB(t)
+	repo := repositories.NewGormMealPlanRepository(gormDB)
+
+	// An update that changes nothing affects no rows, but the meal plan
+	// exists
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM "meal_plans" .*"meal_plans"."id" = 5`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectExec(`UPDATE "meal_plans" SET`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assert.NoError(t, repo.UpdateServings(5, 2))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM "meal_plans" .*"meal_plans"."id" = 9`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	assert.True(t, gorm.IsRecordNotFoundError(repo.UpdateServings(9, 2)))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
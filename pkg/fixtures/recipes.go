@@ -0,0 +1,170 @@
+// Package fixtures generates synthetic recipe catalogs for tuning and
+// benchmarking the planners without a database.
+package fixtures
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+)
+
+var _ repositories.RecipeRepositoryInterface = (*RecipeRepository)(nil)
+
+// Recipes returns n lunch recipes of one to three ingredients with
+// pseudo-random servings, prices and nutrients. The same seed always gives
+// the same catalog. Nutrients are per gram and prices in cents per gram, so
+// a serving has roughly 150-900 kcal and costs 1-10.
+func Recipes(n int, seed int64) []models.Recipe {
+	rng := rand.New(rand.NewSource(seed))
+	recipes := make([]models.Recipe, n)
+	for i := range recipes {
+		servings := 1 + rng.Intn(4)
+		ingredients := make([]models.RecipeIngredient, 1+rng.Intn(3))
+		for j := range ingredients {
+			ingredients[j] = models.RecipeIngredient{
+				Ingredient: models.Ingredient{
+					Name:         fmt.Sprintf("Ingredient %d.%d", i+1, j+1),
+					UnitType:     "mass",
+					Unit:         "g",
+					PricePerUnit: 1 + rng.Intn(3),
+					Nutrients: models.NutritionalValues{
+						Calories: 0.5 + rng.Float64()*3.5,
+						Protein:  rng.Float64() * 0.3,
+						Fat:      rng.Float64() * 0.2,
+						Carbs:    rng.Float64() * 0.6,
+						Fiber:    rng.Float64() * 0.05,
+						Sugar:    rng.Float64() * 0.1,
+					},
+				},
+				Quantity: float64(servings * (40 + rng.Intn(120))),
+				Unit:     "g",
+			}
+		}
+
+		recipes[i] = models.Recipe{
+			ID:                uint(i + 1),
+			Title:             fmt.Sprintf("Recipe %d", i+1),
+			Servings:          servings,
+			Version:           1,
+			MealSlots:         models.MealTypes{models.Lunch},
+			RecipeIngredients: &ingredients,
+		}
+	}
+	return recipes
+}
+
+// RecipeRepository serves a fixed catalog from memory. It is read-only.
+type RecipeRepository struct {
+	recipes []models.Recipe
+}
+
+func NewRecipeRepository(recipes []models.Recipe) *RecipeRepository {
+	return &RecipeRepository{recipes: recipes}
+}
+
+var errReadOnly = errors.New("fixture repository is read-only")
+
+func (r *RecipeRepository) GetAllRecipes() ([]models.Recipe, error) {
+	return r.recipes, nil
+}
+
+func (r *RecipeRepository) GetRecipeByID(id uint) (*models.Recipe, error) {
+	for i := range r.recipes {
+		if r.recipes[i].ID == id {
+			return &r.recipes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("recipe %d not found", id)
+}
+
+// GetRecipeVersions returns the only version fixtures have, their current
+// one.
+func (r *RecipeRepository) GetRecipeVersions(id uint) ([]models.RecipeVersion, error) {
+	recipe, err := r.GetRecipeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return []models.RecipeVersion{models.NewRecipeVersion(*recipe)}, nil
+}
+
+func (r *RecipeRepository) GetRecipeVersion(id uint, version int) (*models.Recipe, error) {
+	recipe, err := r.GetRecipeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if recipe.Version != version {
+		return nil, fmt.Errorf("recipe %d has no version %d", id, version)
+	}
+	return recipe, nil
+}
+
+// FindRecipes returns the catalog's recipes with the filter's tags and
+// cuisine. The catalog has no collections.
+func (r *RecipeRepository) FindRecipes(filter repositories.RecipeFilter) ([]models.Recipe, error) {
+	var recipes []models.Recipe
+	for _, recipe := range r.recipes {
+		if filter.CollectionID != 0 || (filter.Cuisine != "" && !strings.EqualFold(recipe.Cuisine, strings.TrimSpace(filter.Cuisine))) {
+			continue
+		}
+		matches := true
+		for _, tag := range filter.Tags {
+			matches = matches && recipe.HasTag(tag)
+		}
+		if matches {
+			recipes = append(recipes, recipe)
+		}
+	}
+	return recipes, nil
+}
+
+func (r *RecipeRepository) CreateRecipe(recipe *models.Recipe) error {
+	return errReadOnly
+}
+
+func (r *RecipeRepository) UpdateRecipe(recipe *models.Recipe) error {
+	return errReadOnly
+}
+
+func (r *RecipeRepository) DeleteRecipe(id uint) error {
+	return errReadOnly
+}
+
+func (r *RecipeRepository) GetRandomRecipeByType(mealType models.MealType) (*models.Recipe, error) {
+	recipes, _ := r.GetRecipesByType(mealType)
+	if len(recipes) == 0 {
+		return nil, fmt.Errorf("no %s recipes", mealType)
+	}
+	return &recipes[rand.Intn(len(recipes))], nil
+}
+
+func (r *RecipeRepository) GetRecipesByType(mealType models.MealType) ([]models.Recipe, error) {
+	var recipes []models.Recipe
+	for _, recipe := range r.recipes {
+		if recipe.HasMealSlot(mealType) {
+			recipes = append(recipes, recipe)
+		}
+	}
+	return recipes, nil
+}
+
+// WeekOfLunches plans lunches for two from startDate for a week, with
+// limits that the catalogs from Recipes can meet but not trivially: good
+// plans keep most meals within them, while the targets still tell plans
+// within limits apart.
+func WeekOfLunches(startDate time.Time) models.MealPlanParams {
+	return models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 7),
+		Servings:        2,
+		TargetBudget:    5000,
+		MaxBudget:       7000,
+		MinNutrients:    models.NutritionalValues{Calories: 800, Protein: 25},
+		TargetNutrients: models.NutritionalValues{Calories: 1200, Protein: 60, Fat: 20, Carbs: 80},
+		MaxNutrients:    models.NutritionalValues{Calories: 1600, Protein: 120, Fat: 60, Carbs: 200, Fiber: 30, Sugar: 40},
+	}
+}
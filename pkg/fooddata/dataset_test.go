@@ -0,0 +1,107 @@
+package fooddata_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cvele/recipe/pkg/fooddata"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestReadFoodDataCentral(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"food.csv": `"fdc_id","data_type","description","food_category_id","publication_date"
+"1","sr_legacy_food","Oats","20","2019-04-01"
+"2","foundation_food","Lentils, dry","16","2021-10-28"
+"3","sr_legacy_food","Butter, salted","1","2019-04-01"
+`,
+		"nutrient.csv": `"id","name","unit_name","nutrient_nbr","rank"
+"1003","Protein","G","203","600"
+"1008","Energy","KCAL","208","300"
+"1062","Energy","KJ","268","400"
+"1087","Calcium, Ca","MG","301","5300"
+"1106","Vitamin A, RAE","UG","320","7420"
+"2048","Energy (Atwater Specific Factors)","KCAL","958","280"
+`,
+		"food_nutrient.csv": `"id","fdc_id","nutrient_id","amount"
+"10","1","1008","379"
+"11","1","1003","13.2"
+"12","1","1087","52"
+"13","1","1062","1586"
+"20","2","2048","352"
+"21","2","1003","23.6"
+"30","3","1008","717"
+"31","3","1106","684"
+`,
+	})
+
+	foods, err := fooddata.ReadFoodDataCentral(dir, func(food fooddata.Food) bool {
+		return food.ID != "3"
+	})
+	assert.NoError(t, err)
+	assert.Len(t, foods, 2)
+
+	assert.Equal(t, fooddata.DatasetFoodDataCentral, foods[0].Dataset)
+	assert.Equal(t, "Oats", foods[0].Name)
+	assert.Equal(t, "2019-04-01", foods[0].Version)
+	assert.Equal(t, 379.0, foods[0].Nutrients.Calories)
+	assert.Equal(t, 13.2, foods[0].Nutrients.Protein)
+	assert.Equal(t, 52.0, foods[0].Nutrients.Calcium)
+
+	// Energy falls back to the Atwater factors
+	assert.Equal(t, "2", foods[1].ID)
+	assert.Equal(t, 352.0, foods[1].Nutrients.Calories)
+
+	_, err = fooddata.ReadFoodDataCentral(t.TempDir(), nil)
+	assert.Error(t, err)
+}
+
+func TestReadOpenFoodFacts(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"products.csv": "code\tproduct_name\tlast_modified_t\tenergy_100g\tenergy-kcal_100g\tproteins_100g\tsodium_100g\tvitamin-d_100g\n" +
+			"3017620422003\tHazelnut spread\t1690000000\t2252\t539\t6.3\t0.041\t\n" +
+			"5000159407236\tOat drink\t1680000000\t209\t\t1\t0.04\t0.0000015\n",
+	})
+
+	foods, err := fooddata.ReadOpenFoodFacts(filepath.Join(dir, "products.csv"), nil)
+	assert.NoError(t, err)
+	assert.Len(t, foods, 2)
+
+	assert.Equal(t, fooddata.DatasetOpenFoodFacts, foods[0].Dataset)
+	assert.Equal(t, "3017620422003", foods[0].ID)
+	assert.Equal(t, "1690000000", foods[0].Version)
+	assert.Equal(t, 539.0, foods[0].Nutrients.Calories)
+	assert.Equal(t, 6.3, foods[0].Nutrients.Protein)
+	assert.InDelta(t, 41, foods[0].Nutrients.Sodium, 1e-9)
+	assert.Zero(t, foods[0].Nutrients.VitaminD)
+
+	// Energy in kJ when kcal are missing, vitamins in µg
+	assert.InDelta(t, 209/4.184, foods[1].Nutrients.Calories, 1e-9)
+	assert.InDelta(t, 1.5, foods[1].Nutrients.VitaminD, 1e-9)
+}
+
+func TestReadMappings(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"mappings.csv": "ingredient,food_id,grams_per_unit\nOats,1,\nMilk,2,1.03\n",
+		"invalid.csv":  "ingredient,food_id,grams_per_unit\nMilk,2,dense\n",
+	})
+
+	mappings, err := fooddata.ReadMappings(filepath.Join(dir, "mappings.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, []fooddata.Mapping{
+		{Ingredient: "Oats", FoodID: "1"},
+		{Ingredient: "Milk", FoodID: "2", GramsPerUnit: 1.03},
+	}, mappings)
+
+	_, err = fooddata.ReadMappings(filepath.Join(dir, "invalid.csv"))
+	assert.EqualError(t, err, "invalid.csv: line 2: grams_per_unit must be a positive number")
+}
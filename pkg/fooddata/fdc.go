@@ -0,0 +1,160 @@
+package fooddata
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// fdcNutrients maps FoodData Central nutrient numbers to nutrient names.
+// Energy falls back to the Atwater factors some foods are analysed with.
+var fdcNutrients = map[string]string{
+	"208": "calories",
+	"203": "protein",
+	"204": "fat",
+	"205": "carbs",
+	"291": "fiber",
+	"269": "sugar",
+	"606": "saturated_fat",
+	"601": "cholesterol",
+	"307": "sodium",
+	"306": "potassium",
+	"301": "calcium",
+	"303": "iron",
+	"320": "vitamin_a",
+	"401": "vitamin_c",
+	"328": "vitamin_d",
+}
+
+var fdcEnergyFallbacks = []string{"958", "957"}
+
+// ReadFoodDataCentral reads the foods kept by keep from a USDA FoodData
+// Central CSV download extracted to dir. It uses food.csv, nutrient.csv and
+// food_nutrient.csv; amounts are per 100 g. Food nutrients are only parsed
+// for kept foods, so keeping few foods reads the full dataset cheaply.
+func ReadFoodDataCentral(dir string, keep KeepFunc) ([]Food, error) {
+	if keep == nil {
+		keep = keepAll
+	}
+
+	foods, order, err := readFDCFoods(filepath.Join(dir, "food.csv"), keep)
+	if err != nil {
+		return nil, err
+	}
+	nutrients, err := readFDCNutrients(filepath.Join(dir, "nutrient.csv"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Amounts by food and nutrient number, so energy can fall back
+	amounts := make(map[string]map[string]fdcAmount)
+	err = readFile(filepath.Join(dir, "food_nutrient.csv"), ',', func(row csvRow) error {
+		foodID := row.get("fdc_id")
+		if _, ok := foods[foodID]; !ok {
+			return nil
+		}
+		nutrient, ok := nutrients[row.get("nutrient_id")]
+		if !ok {
+			return nil
+		}
+		amount, err := strconv.ParseFloat(row.get("amount"), 64)
+		if err != nil {
+			return fmt.Errorf("food %s nutrient %s: invalid amount %q", foodID, nutrient.number, row.get("amount"))
+		}
+		if amounts[foodID] == nil {
+			amounts[foodID] = make(map[string]fdcAmount)
+		}
+		amounts[foodID][nutrient.number] = fdcAmount{amount: amount, unit: nutrient.unit}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Food, 0, len(order))
+	for _, id := range order {
+		food := foods[id]
+		for number, name := range fdcNutrients {
+			amount, ok := amounts[id][number]
+			if !ok && name == "calories" {
+				amount, ok = energyFallback(amounts[id])
+			}
+			if !ok {
+				continue
+			}
+			if err := setNutrient(&food.Nutrients, name, amount.amount, amount.unit); err != nil {
+				return nil, fmt.Errorf("food %s: %w", id, err)
+			}
+		}
+		result = append(result, food)
+	}
+	return result, nil
+}
+
+type fdcNutrient struct {
+	number string
+	unit   string
+}
+
+type fdcAmount struct {
+	amount float64
+	unit   string
+}
+
+func energyFallback(amounts map[string]fdcAmount) (fdcAmount, bool) {
+	for _, number := range fdcEnergyFallbacks {
+		if amount, ok := amounts[number]; ok {
+			return amount, true
+		}
+	}
+	return fdcAmount{}, false
+}
+
+func isFDCNutrient(number string) bool {
+	if _, ok := fdcNutrients[number]; ok {
+		return true
+	}
+	for _, fallback := range fdcEnergyFallbacks {
+		if number == fallback {
+			return true
+		}
+	}
+	return false
+}
+
+// readFDCFoods returns the kept foods by ID and their IDs in file order.
+func readFDCFoods(path string, keep KeepFunc) (map[string]Food, []string, error) {
+	foods := make(map[string]Food)
+	var order []string
+	err := readFile(path, ',', func(row csvRow) error {
+		food := Food{
+			Dataset: DatasetFoodDataCentral,
+			ID:      row.get("fdc_id"),
+			Name:    row.get("description"),
+			Version: row.get("publication_date"),
+		}
+		if food.ID == "" || !keep(food) {
+			return nil
+		}
+		if _, ok := foods[food.ID]; !ok {
+			order = append(order, food.ID)
+		}
+		foods[food.ID] = food
+		return nil
+	})
+	return foods, order, err
+}
+
+// readFDCNutrients returns the imported nutrients by their ID.
+func readFDCNutrients(path string) (map[string]fdcNutrient, error) {
+	nutrients := make(map[string]fdcNutrient)
+	err := readFile(path, ',', func(row csvRow) error {
+		number := row.get("nutrient_nbr")
+		if !isFDCNutrient(number) {
+			return nil
+		}
+		nutrients[row.get("id")] = fdcNutrient{number: number, unit: row.get("unit_name")}
+		return nil
+	})
+	return nutrients, err
+}
@@ -0,0 +1,97 @@
+// Package fooddata reads locally downloaded food composition datasets and
+// imports their nutrients into the ingredient catalog.
+package fooddata
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cvele/recipe/pkg/models"
+)
+
+// Datasets the importer can read.
+const (
+	DatasetFoodDataCentral = "usda-fdc"
+	DatasetOpenFoodFacts   = "open-food-facts"
+)
+
+// Food is an entry of a food composition dataset. Nutrients are given per
+// 100 g of the food, in the units of models.Nutrients.
+type Food struct {
+	Dataset   string
+	ID        string
+	Name      string
+	Version   string
+	Nutrients models.NutritionalValues
+}
+
+// KeepFunc selects the foods a reader returns. It is called before the
+// nutrients are read, so it may only look at the food's ID and name.
+type KeepFunc func(food Food) bool
+
+func keepAll(Food) bool {
+	return true
+}
+
+// gramsPerUnit is the size of the mass units datasets use, in grams.
+var gramsPerUnit = map[string]float64{
+	"g":  1,
+	"mg": 1e-3,
+	"ug": 1e-6,
+	"µg": 1e-6,
+}
+
+// convertAmount converts a nutrient amount from the dataset's unit to the
+// nutrient's unit in models.Nutrients. Energy must already be in kcal.
+func convertAmount(amount float64, fromUnit string, toUnit string) (float64, error) {
+	from, to := strings.ToLower(fromUnit), strings.ToLower(toUnit)
+	if from == to {
+		return amount, nil
+	}
+	fromSize, ok := gramsPerUnit[from]
+	if !ok {
+		return 0, fmt.Errorf("unsupported nutrient unit %q", fromUnit)
+	}
+	toSize, ok := gramsPerUnit[to]
+	if !ok {
+		return 0, fmt.Errorf("unsupported nutrient unit %q", toUnit)
+	}
+	return amount * fromSize / toSize, nil
+}
+
+// setNutrient sets the named nutrient of values to amount given in unit.
+func setNutrient(values *models.NutritionalValues, name string, amount float64, unit string) error {
+	nutrient, ok := models.NutrientByName(name)
+	if !ok {
+		return fmt.Errorf("unknown nutrient %q", name)
+	}
+	converted, err := convertAmount(amount, unit, nutrient.Unit)
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]*float64{
+		"calories":      &values.Calories,
+		"protein":       &values.Protein,
+		"fat":           &values.Fat,
+		"carbs":         &values.Carbs,
+		"fiber":         &values.Fiber,
+		"sugar":         &values.Sugar,
+		"saturated_fat": &values.SaturatedFat,
+		"cholesterol":   &values.Cholesterol,
+		"sodium":        &values.Sodium,
+		"potassium":     &values.Potassium,
+		"calcium":       &values.Calcium,
+		"iron":          &values.Iron,
+		"vitamin_a":     &values.VitaminA,
+		"vitamin_c":     &values.VitaminC,
+		"vitamin_d":     &values.VitaminD,
+	}
+	*fields[name] = converted
+	return nil
+}
+
+// normalizeName is how food and ingredient names are compared.
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
@@ -0,0 +1,298 @@
+package fooddata
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
+)
+
+// Outcome is what an import did to an ingredient.
+type Outcome string
+
+const (
+	OutcomeUpdated   Outcome = "updated"
+	OutcomeUnchanged Outcome = "unchanged"
+	OutcomeSkipped   Outcome = "skipped"
+	OutcomeFailed    Outcome = "failed"
+)
+
+// Change is the outcome of importing a food into an ingredient.
+type Change struct {
+	Ingredient string
+	FoodID     string
+	Outcome    Outcome
+	Reason     string
+}
+
+// Report lists the ingredients an import matched to foods.
+type Report struct {
+	Changes []Change
+}
+
+// Count returns the number of changes with the outcome.
+func (r Report) Count(outcome Outcome) int {
+	count := 0
+	for _, change := range r.Changes {
+		if change.Outcome == outcome {
+			count++
+		}
+	}
+	return count
+}
+
+// Reader reads the foods kept by keep from a dataset.
+type Reader func(keep KeepFunc) ([]Food, error)
+
+// Importer updates ingredient nutrients from a food composition dataset.
+//
+// Ingredients are matched to foods by the mappings first, then by the food
+// they were imported from before and, when name matching is enabled, by a
+// food named like the ingredient or one of its aliases. Re-importing only
+// writes ingredients whose nutrients, basis or source changed, and
+// nutrients entered by hand are only replaced when overwriting.
+type Importer struct {
+	repo       repositories.IngredientRepository
+	converter  units.UnitConverterInterface
+	dataset    string
+	mappings   []Mapping
+	matchNames bool
+	overwrite  bool
+	dryRun     bool
+}
+
+func NewImporter(repo repositories.IngredientRepository, converter units.UnitConverterInterface, dataset string) *Importer {
+	return &Importer{
+		repo:      repo,
+		converter: converter,
+		dataset:   dataset,
+	}
+}
+
+func (i *Importer) SetMappings(mappings []Mapping) {
+	i.mappings = mappings
+}
+
+// SetMatchNames enables matching ingredients without a mapping to foods by
+// name.
+func (i *Importer) SetMatchNames(matchNames bool) {
+	i.matchNames = matchNames
+}
+
+// SetOverwrite allows replacing nutrients that were entered by hand.
+func (i *Importer) SetOverwrite(overwrite bool) {
+	i.overwrite = overwrite
+}
+
+// SetDryRun reports the changes without saving them.
+func (i *Importer) SetDryRun(dryRun bool) {
+	i.dryRun = dryRun
+}
+
+// assignment is the food an ingredient was matched to.
+type assignment struct {
+	foodID       string
+	gramsPerUnit float64
+}
+
+// Import reads the foods the ingredients are matched to with read and
+// updates the ingredients' nutrients.
+func (i *Importer) Import(read Reader) (Report, error) {
+	var report Report
+
+	ingredients, err := i.repo.FindAll()
+	if err != nil {
+		return report, err
+	}
+
+	byName := make(map[string][]int)
+	for index, ingredient := range ingredients {
+		for _, name := range ingredientNames(ingredient) {
+			byName[name] = append(byName[name], index)
+		}
+	}
+
+	assignments := make(map[int]assignment)
+	for _, mapping := range i.mappings {
+		matches := byName[normalizeName(mapping.Ingredient)]
+		if len(matches) != 1 {
+			reason := "no ingredient has this name"
+			if len(matches) > 1 {
+				reason = fmt.Sprintf("%d ingredients have this name", len(matches))
+			}
+			report.Changes = append(report.Changes, Change{Ingredient: mapping.Ingredient, FoodID: mapping.FoodID, Outcome: OutcomeFailed, Reason: reason})
+			continue
+		}
+		assigned := assignment{foodID: mapping.FoodID, gramsPerUnit: mapping.GramsPerUnit}
+		// Mappings may leave out the weight an earlier import of the food kept
+		if source := ingredients[matches[0]].Source; assigned.gramsPerUnit == 0 && source.Dataset == i.dataset && source.FoodID == mapping.FoodID {
+			assigned.gramsPerUnit = source.GramsPerUnit
+		}
+		assignments[matches[0]] = assigned
+	}
+	for index, ingredient := range ingredients {
+		if _, ok := assignments[index]; !ok && ingredient.Source.Dataset == i.dataset && ingredient.Source.FoodID != "" {
+			assignments[index] = assignment{foodID: ingredient.Source.FoodID, gramsPerUnit: ingredient.Source.GramsPerUnit}
+		}
+	}
+
+	// Only the foods that can match an ingredient are read
+	foodIDs := make(map[string]bool)
+	for _, assigned := range assignments {
+		foodIDs[assigned.foodID] = true
+	}
+	foods, err := read(func(food Food) bool {
+		return foodIDs[food.ID] || (i.matchNames && len(byName[normalizeName(food.Name)]) > 0)
+	})
+	if err != nil {
+		return report, err
+	}
+
+	foodsByID := make(map[string]Food, len(foods))
+	foodsByName := make(map[string][]Food)
+	for _, food := range foods {
+		foodsByID[food.ID] = food
+		name := normalizeName(food.Name)
+		foodsByName[name] = append(foodsByName[name], food)
+	}
+
+	for index, ingredient := range ingredients {
+		assigned, ok := assignments[index]
+		if !ok {
+			if !i.matchNames {
+				continue
+			}
+			candidates := namedFoods(ingredient, foodsByName)
+			if len(candidates) == 0 {
+				continue
+			}
+			if len(candidates) > 1 {
+				report.Changes = append(report.Changes, Change{Ingredient: ingredient.Name, Outcome: OutcomeSkipped, Reason: fmt.Sprintf("%d foods match the name, map one explicitly", len(candidates))})
+				continue
+			}
+			assigned = assignment{foodID: candidates[0].ID}
+		}
+
+		food, ok := foodsByID[assigned.foodID]
+		if !ok {
+			report.Changes = append(report.Changes, Change{Ingredient: ingredient.Name, FoodID: assigned.foodID, Outcome: OutcomeFailed, Reason: "food not found in the dataset"})
+			continue
+		}
+		report.Changes = append(report.Changes, i.apply(ingredient, food, assigned.gramsPerUnit))
+	}
+
+	return report, nil
+}
+
+// apply imports the food's nutrients into the ingredient.
+func (i *Importer) apply(ingredient models.Ingredient, food Food, gramsPerUnit float64) Change {
+	change := Change{Ingredient: ingredient.Name, FoodID: food.ID}
+
+	if ingredient.Source.Dataset == "" && ingredient.Nutrients != (models.NutritionalValues{}) && !i.overwrite {
+		change.Outcome = OutcomeSkipped
+		change.Reason = "nutrients were entered by hand, overwrite to replace them"
+		return change
+	}
+
+	imported, err := Normalize(food, ingredient, gramsPerUnit, i.converter)
+	if err != nil {
+		change.Outcome = OutcomeFailed
+		change.Reason = err.Error()
+		return change
+	}
+	if imported.Source == ingredient.Source &&
+		imported.Quantity == ingredient.Quantity &&
+		imported.QuantityUnit == ingredient.QuantityUnit &&
+		sameNutrients(imported.Nutrients, ingredient.Nutrients) {
+		change.Outcome = OutcomeUnchanged
+		return change
+	}
+
+	if !i.dryRun {
+		if err := i.repo.Update(&imported); err != nil {
+			change.Outcome = OutcomeFailed
+			change.Reason = err.Error()
+			return change
+		}
+	}
+	change.Outcome = OutcomeUpdated
+	return change
+}
+
+// Normalize returns the ingredient with the food's nutrients scaled to the
+// ingredient's Quantity and QuantityUnit. Ingredients without a basis get
+// one of the converter's default unit, which is how recipes scale the
+// nutrients. Bases that aren't a mass need gramsPerUnit, which is kept in
+// the ingredient's source for later imports.
+func Normalize(food Food, ingredient models.Ingredient, gramsPerUnit float64, converter units.UnitConverterInterface) (models.Ingredient, error) {
+	if ingredient.Quantity <= 0 || ingredient.QuantityUnit == "" {
+		ingredient.Quantity = 1
+		ingredient.QuantityUnit = ingredient.Unit
+		if ingredient.UnitType == "mass" || ingredient.UnitType == "volume" {
+			ingredient.QuantityUnit = converter.GetDefaultUnit(ingredient.UnitType)
+		}
+	}
+
+	var grams, keptGramsPerUnit float64
+	switch {
+	case converter.IsValidUnit(ingredient.QuantityUnit, "mass"):
+		converted, err := units.ConvertQuantity(converter, units.QuantityFromFloat(ingredient.Quantity), ingredient.QuantityUnit, "g", "mass")
+		if err != nil {
+			return ingredient, err
+		}
+		grams = converted.Float64()
+	case gramsPerUnit > 0:
+		grams = ingredient.Quantity * gramsPerUnit
+		keptGramsPerUnit = gramsPerUnit
+	default:
+		return ingredient, fmt.Errorf("grams per %s are needed to convert from per 100 g", ingredient.QuantityUnit)
+	}
+
+	ingredient.Nutrients = food.Nutrients.Scale(grams / 100)
+	ingredient.Source = models.NutrientSource{
+		Dataset:      food.Dataset,
+		FoodID:       food.ID,
+		Version:      food.Version,
+		GramsPerUnit: keptGramsPerUnit,
+	}
+	return ingredient, nil
+}
+
+// ingredientNames returns the normalized name and aliases of the ingredient.
+func ingredientNames(ingredient models.Ingredient) []string {
+	names := []string{normalizeName(ingredient.Name)}
+	for _, alias := range ingredient.Aliases {
+		names = append(names, normalizeName(alias.Name))
+	}
+	return names
+}
+
+// namedFoods returns the distinct foods named like the ingredient or one of
+// its aliases.
+func namedFoods(ingredient models.Ingredient, foodsByName map[string][]Food) []Food {
+	seen := make(map[string]bool)
+	var foods []Food
+	for _, name := range ingredientNames(ingredient) {
+		for _, food := range foodsByName[name] {
+			if !seen[food.ID] {
+				seen[food.ID] = true
+				foods = append(foods, food)
+			}
+		}
+	}
+	return foods
+}
+
+// sameNutrients reports whether a and b are equal up to the rounding of a
+// database round trip.
+func sameNutrients(a models.NutritionalValues, b models.NutritionalValues) bool {
+	others := b.Fields()
+	for i, value := range a.Fields() {
+		if math.Abs(value-others[i]) > 1e-9*math.Max(1, math.Abs(value)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,190 @@
+package fooddata_test
+
+import (
+	"testing"
+
+	"github.com/cvele/recipe/pkg/fooddata"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/units"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type IngredientRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *IngredientRepositoryMock) FindByID(id uint) (*models.Ingredient, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.Ingredient), args.Error(1)
+}
+
+func (m *IngredientRepositoryMock) FindAll() ([]models.Ingredient, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Ingredient), args.Error(1)
+}
+
+func (m *IngredientRepositoryMock) Search(query string, offset int, limit int) ([]models.Ingredient, int, error) {
+	args := m.Called(query, offset, limit)
+	return args.Get(0).([]models.Ingredient), args.Int(1), args.Error(2)
+}
+
+func (m *IngredientRepositoryMock) Create(ingredient *models.Ingredient) error {
+	args := m.Called(ingredient)
+	return args.Error(0)
+}
+
+func (m *IngredientRepositoryMock) Update(ingredient *models.Ingredient) error {
+	args := m.Called(ingredient)
+	return args.Error(0)
+}
+
+func (m *IngredientRepositoryMock) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+var foods = []fooddata.Food{
+	{Dataset: fooddata.DatasetFoodDataCentral, ID: "1", Name: "Oats", Version: "2019-04-01", Nutrients: models.NutritionalValues{Calories: 379, Protein: 13.2}},
+	{Dataset: fooddata.DatasetFoodDataCentral, ID: "2", Name: "Milk, whole", Version: "2019-04-01", Nutrients: models.NutritionalValues{Calories: 61, Calcium: 113}},
+	{Dataset: fooddata.DatasetFoodDataCentral, ID: "3", Name: "Butter", Version: "2019-04-01", Nutrients: models.NutritionalValues{Calories: 717}},
+	{Dataset: fooddata.DatasetFoodDataCentral, ID: "4", Name: "Butter", Version: "2021-10-28", Nutrients: models.NutritionalValues{Calories: 720}},
+}
+
+// readFoods returns the kept foods and records which were kept.
+func readFoods(kept *[]string) fooddata.Reader {
+	return func(keep fooddata.KeepFunc) ([]fooddata.Food, error) {
+		var result []fooddata.Food
+		for _, food := range foods {
+			if keep(food) {
+				*kept = append(*kept, food.ID)
+				result = append(result, food)
+			}
+		}
+		return result, nil
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	converter := units.NewUnitConverter("g", "ml")
+
+	// Without a basis, nutrients are per gram
+	ingredient, err := fooddata.Normalize(foods[0], models.Ingredient{Name: "Oats", Unit: "kg", UnitType: "mass"}, 0, converter)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, ingredient.Quantity)
+	assert.Equal(t, "g", ingredient.QuantityUnit)
+	assert.InDelta(t, 3.79, ingredient.Nutrients.Calories, 1e-9)
+	assert.Equal(t, models.NutrientSource{Dataset: fooddata.DatasetFoodDataCentral, FoodID: "1", Version: "2019-04-01"}, ingredient.Source)
+
+	ingredient, err = fooddata.Normalize(foods[0], models.Ingredient{Quantity: 1, QuantityUnit: "kg", UnitType: "mass"}, 0, converter)
+	assert.NoError(t, err)
+	assert.InDelta(t, 3790, ingredient.Nutrients.Calories, 1e-9)
+
+	ingredient, err = fooddata.Normalize(foods[1], models.Ingredient{Quantity: 100, QuantityUnit: "ml", UnitType: "volume"}, 1.03, converter)
+	assert.NoError(t, err)
+	assert.InDelta(t, 62.83, ingredient.Nutrients.Calories, 1e-9)
+	assert.Equal(t, 1.03, ingredient.Source.GramsPerUnit)
+
+	_, err = fooddata.Normalize(foods[1], models.Ingredient{Quantity: 100, QuantityUnit: "ml", UnitType: "volume"}, 0, converter)
+	assert.EqualError(t, err, "grams per ml are needed to convert from per 100 g")
+}
+
+func TestImporter_Import(t *testing.T) {
+	ingredients := []models.Ingredient{
+		{ID: 1, Name: "Rolled oats", Unit: "kg", UnitType: "mass", Aliases: []models.IngredientAlias{{Name: "oats"}}},
+		{ID: 2, Name: "Milk", Unit: "l", UnitType: "volume", Quantity: 100, QuantityUnit: "ml"},
+		{ID: 3, Name: "Butter", Unit: "kg", UnitType: "mass"},
+		{ID: 4, Name: "Salt", Unit: "kg", UnitType: "mass", Nutrients: models.NutritionalValues{Sodium: 387.6}},
+	}
+	repo := new(IngredientRepositoryMock)
+	repo.On("FindAll").Return(ingredients, nil)
+	repo.On("Update", mock.Anything).Return(nil)
+
+	importer := fooddata.NewImporter(repo, units.NewUnitConverter("g", "ml"), fooddata.DatasetFoodDataCentral)
+	importer.SetMappings([]fooddata.Mapping{
+		{Ingredient: "milk", FoodID: "2", GramsPerUnit: 1.03},
+		{Ingredient: "Salt", FoodID: "5"},
+		{Ingredient: "Cream", FoodID: "6"},
+	})
+	importer.SetMatchNames(true)
+
+	var kept []string
+	report, err := importer.Import(readFoods(&kept))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4"}, kept)
+	assert.Equal(t, []fooddata.Change{
+		{Ingredient: "Cream", FoodID: "6", Outcome: fooddata.OutcomeFailed, Reason: "no ingredient has this name"},
+		{Ingredient: "Rolled oats", FoodID: "1", Outcome: fooddata.OutcomeUpdated},
+		{Ingredient: "Milk", FoodID: "2", Outcome: fooddata.OutcomeUpdated},
+		{Ingredient: "Butter", Outcome: fooddata.OutcomeSkipped, Reason: "2 foods match the name, map one explicitly"},
+		{Ingredient: "Salt", FoodID: "5", Outcome: fooddata.OutcomeFailed, Reason: "food not found in the dataset"},
+	}, report.Changes)
+
+	oats := repo.Calls[1].Arguments.Get(0).(*models.Ingredient)
+	assert.Equal(t, uint(1), oats.ID)
+	assert.InDelta(t, 3.79, oats.Nutrients.Calories, 1e-9)
+	milk := repo.Calls[2].Arguments.Get(0).(*models.Ingredient)
+	assert.InDelta(t, 116.39, milk.Nutrients.Calcium, 1e-9)
+}
+
+func TestImporter_Reimport(t *testing.T) {
+	converter := units.NewUnitConverter("g", "ml")
+	oats, err := fooddata.Normalize(foods[0], models.Ingredient{ID: 1, Name: "Oats", Unit: "kg", UnitType: "mass"}, 0, converter)
+	assert.NoError(t, err)
+	butter, err := fooddata.Normalize(foods[2], models.Ingredient{ID: 2, Name: "Butter", Unit: "kg", UnitType: "mass"}, 0, converter)
+	assert.NoError(t, err)
+	butter.Nutrients.Calories = 7
+	salt := models.Ingredient{ID: 3, Name: "Salt", Unit: "kg", UnitType: "mass", Nutrients: models.NutritionalValues{Sodium: 387.6}}
+
+	repo := new(IngredientRepositoryMock)
+	repo.On("FindAll").Return([]models.Ingredient{oats, butter, salt}, nil)
+	repo.On("Update", mock.Anything).Return(nil)
+
+	// Previously imported foods are re-read without mappings or name matching
+	importer := fooddata.NewImporter(repo, converter, fooddata.DatasetFoodDataCentral)
+	importer.SetMappings([]fooddata.Mapping{{Ingredient: "Salt", FoodID: "4"}})
+	var kept []string
+	report, err := importer.Import(readFoods(&kept))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1", "3", "4"}, kept)
+	assert.Equal(t, []fooddata.Change{
+		{Ingredient: "Oats", FoodID: "1", Outcome: fooddata.OutcomeUnchanged},
+		{Ingredient: "Butter", FoodID: "3", Outcome: fooddata.OutcomeUpdated},
+		{Ingredient: "Salt", FoodID: "4", Outcome: fooddata.OutcomeSkipped, Reason: "nutrients were entered by hand, overwrite to replace them"},
+	}, report.Changes)
+	repo.AssertNumberOfCalls(t, "Update", 1)
+
+	// A dry run saves nothing, overwriting replaces hand-entered nutrients
+	importer.SetOverwrite(true)
+	importer.SetDryRun(true)
+	report, err = importer.Import(readFoods(&kept))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Count(fooddata.OutcomeUpdated))
+	repo.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestImporter_ReimportKeepsGramsPerUnit(t *testing.T) {
+	converter := units.NewUnitConverter("g", "ml")
+	milk, err := fooddata.Normalize(foods[1], models.Ingredient{ID: 1, Name: "Milk", Unit: "l", UnitType: "volume", Quantity: 100, QuantityUnit: "ml"}, 1.03, converter)
+	assert.NoError(t, err)
+	milk.Nutrients.Calories = 6
+
+	repo := new(IngredientRepositoryMock)
+	repo.On("FindAll").Return([]models.Ingredient{milk}, nil)
+	repo.On("Update", mock.Anything).Return(nil)
+
+	// Neither the stored source nor a mapping without the weight need it
+	// again
+	for _, mappings := range [][]fooddata.Mapping{nil, {{Ingredient: "Milk", FoodID: "2"}}} {
+		importer := fooddata.NewImporter(repo, converter, fooddata.DatasetFoodDataCentral)
+		importer.SetMappings(mappings)
+		var kept []string
+		report, err := importer.Import(readFoods(&kept))
+		assert.NoError(t, err)
+		assert.Equal(t, []fooddata.Change{{Ingredient: "Milk", FoodID: "2", Outcome: fooddata.OutcomeUpdated}}, report.Changes)
+	}
+
+	updated := repo.Calls[len(repo.Calls)-1].Arguments.Get(0).(*models.Ingredient)
+	assert.InDelta(t, 62.83, updated.Nutrients.Calories, 1e-9)
+	assert.Equal(t, 1.03, updated.Source.GramsPerUnit)
+}
@@ -0,0 +1,47 @@
+package fooddata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Mapping assigns a dataset food to an ingredient. GramsPerUnit is the
+// weight of the food in one of the ingredient's QuantityUnit and is needed
+// when that isn't a mass unit, for example the density for ml. Once
+// imported, it is kept with the ingredient's nutrient source.
+type Mapping struct {
+	Ingredient   string  // name or alias of the ingredient
+	FoodID       string  // ID of the food in the dataset
+	GramsPerUnit float64 // optional for ingredients measured by mass
+}
+
+// ReadMappings reads mappings from a CSV file with the columns ingredient,
+// food_id and, optionally, grams_per_unit.
+func ReadMappings(path string) ([]Mapping, error) {
+	var mappings []Mapping
+	line := 1
+	err := readFile(path, ',', func(row csvRow) error {
+		line++
+		mapping := Mapping{
+			Ingredient: strings.TrimSpace(row.get("ingredient")),
+			FoodID:     strings.TrimSpace(row.get("food_id")),
+		}
+		if mapping.Ingredient == "" || mapping.FoodID == "" {
+			return fmt.Errorf("line %d: ingredient and food_id are required", line)
+		}
+		if value := strings.TrimSpace(row.get("grams_per_unit")); value != "" {
+			grams, err := strconv.ParseFloat(value, 64)
+			if err != nil || grams <= 0 {
+				return fmt.Errorf("line %d: grams_per_unit must be a positive number", line)
+			}
+			mapping.GramsPerUnit = grams
+		}
+		mappings = append(mappings, mapping)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
@@ -0,0 +1,89 @@
+package fooddata
+
+import (
+	"strconv"
+	"strings"
+)
+
+// offNutrients maps Open Food Facts columns to nutrient names. Amounts are
+// per 100 g and, apart from energy, in grams.
+var offNutrients = map[string]string{
+	"energy-kcal_100g":   "calories",
+	"proteins_100g":      "protein",
+	"fat_100g":           "fat",
+	"carbohydrates_100g": "carbs",
+	"fiber_100g":         "fiber",
+	"sugars_100g":        "sugar",
+	"saturated-fat_100g": "saturated_fat",
+	"cholesterol_100g":   "cholesterol",
+	"sodium_100g":        "sodium",
+	"potassium_100g":     "potassium",
+	"calcium_100g":       "calcium",
+	"iron_100g":          "iron",
+	"vitamin-a_100g":     "vitamin_a",
+	"vitamin-c_100g":     "vitamin_c",
+	"vitamin-d_100g":     "vitamin_d",
+}
+
+const kilojoulesPerKilocalorie = 4.184
+
+// ReadOpenFoodFacts reads the foods kept by keep from an Open Food Facts
+// CSV export, which is tab separated. Products are identified by their
+// code and versioned by their last modification time. Empty cells are
+// missing values and leave the nutrient at zero.
+func ReadOpenFoodFacts(path string, keep KeepFunc) ([]Food, error) {
+	if keep == nil {
+		keep = keepAll
+	}
+
+	var foods []Food
+	err := readFile(path, '\t', func(row csvRow) error {
+		food := Food{
+			Dataset: DatasetOpenFoodFacts,
+			ID:      row.get("code"),
+			Name:    row.get("product_name"),
+			Version: row.get("last_modified_t"),
+		}
+		if food.ID == "" || !keep(food) {
+			return nil
+		}
+
+		for column, name := range offNutrients {
+			amount, ok := parseAmount(row.get(column))
+			if !ok {
+				continue
+			}
+			unit := "g"
+			if name == "calories" {
+				unit = "kcal"
+			}
+			if err := setNutrient(&food.Nutrients, name, amount, unit); err != nil {
+				return err
+			}
+		}
+		if food.Nutrients.Calories == 0 {
+			if kilojoules, ok := parseAmount(row.get("energy_100g")); ok {
+				food.Nutrients.Calories = kilojoules / kilojoulesPerKilocalorie
+			}
+		}
+
+		foods = append(foods, food)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return foods, nil
+}
+
+func parseAmount(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 {
+		return 0, false
+	}
+	return amount, true
+}
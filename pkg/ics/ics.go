@@ -22,7 +22,7 @@ func GenerateMealPlanICal(mealPlans []models.MealPlan) (string, error) {
 		event.SetModifiedAt(m.UpdatedAt)
 
 		event.SetStartAt(m.MealTime)
-		event.SetEndAt(m.MealTime.Add(time.Hour)) // @TODO: for now assuming each meal is an hour long
+		event.SetEndAt(m.MealTime.Add(mealDuration(m)))
 
 		event.SetSummary("Meal Plan for Recipe " + fmt.Sprint(m.RecipeID))
 		event.SetDescription(m.Recipe.Description)
@@ -30,3 +30,12 @@ func GenerateMealPlanICal(mealPlans []models.MealPlan) (string, error) {
 
 	return cal.Serialize(), nil
 }
+
+// mealDuration returns how long the meal takes, an hour when its meal slot
+// doesn't say.
+func mealDuration(m models.MealPlan) time.Duration {
+	if m.Duration <= 0 {
+		return time.Hour
+	}
+	return time.Duration(m.Duration) * time.Minute
+}
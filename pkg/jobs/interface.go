@@ -0,0 +1,12 @@
+package jobs
+
+import (
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/planner"
+)
+
+type PlanningRunnerInterface interface {
+	Submit(req planner.Request) (*models.PlanningJob, error)
+	Cancel(id uint) (*models.PlanningJob, error)
+	Subscribe(id uint) (<-chan planner.Progress, func())
+}
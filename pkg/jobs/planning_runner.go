@@ -0,0 +1,349 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/planner"
+	"github.com/cvele/recipe/pkg/repositories"
+	log "github.com/sirupsen/logrus"
+)
+
+var _ PlanningRunnerInterface = &PlanningRunner{}
+
+var (
+	ErrQueueFull   = errors.New("planning queue is full")
+	ErrJobFinished = errors.New("planning job has already finished")
+)
+
+// progressInterval limits how often a running job writes its progress to
+// the database.
+const progressInterval = time.Second
+
+// subscriberBuffer is how many progress updates a slow subscriber may fall
+// behind before older updates are dropped.
+const subscriberBuffer = 64
+
+// PlanningRunner executes planning jobs on a fixed number of workers. Job
+// state lives in the database so clients can poll it and so jobs left over
+// from a previous run can be recovered at startup.
+type PlanningRunner struct {
+	repo    repositories.PlanningJobRepository
+	service *planner.Service
+	workers int
+	queue   chan uint
+
+	mu      sync.Mutex
+	cancels map[uint]context.CancelFunc
+
+	subscribersMu sync.Mutex
+	subscribers   map[uint][]chan planner.Progress
+
+	ctx  context.Context
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+func NewPlanningRunner(repo repositories.PlanningJobRepository, service *planner.Service, workers int, queueSize int) *PlanningRunner {
+	ctx, stop := context.WithCancel(context.Background())
+	return &PlanningRunner{
+		repo:    repo,
+		service: service,
+		workers: workers,
+		queue:   make(chan uint, queueSize),
+		cancels: make(map[uint]context.CancelFunc),
+		ctx:     ctx,
+
+		subscribers: make(map[uint][]chan planner.Progress),
+		stop:        stop,
+	}
+}
+
+// Start launches the workers.
+func (r *PlanningRunner) Start() {
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+}
+
+// Shutdown stops the workers. Jobs that are still running are marked as
+// interrupted.
+func (r *PlanningRunner) Shutdown() {
+	r.stop()
+	r.wg.Wait()
+}
+
+// Recover handles jobs left over from a previous run. Running jobs cannot
+// be resumed mid-run and are marked as interrupted; queued jobs are queued
+// again as long as there is room.
+func (r *PlanningRunner) Recover() error {
+	running, err := r.repo.FindByStatus(models.PlanningJobRunning)
+	if err != nil {
+		return err
+	}
+	for _, job := range running {
+		if err := r.finishJob(job, models.PlanningJobInterrupted, ""); err != nil {
+			return err
+		}
+	}
+
+	queued, err := r.repo.FindByStatus(models.PlanningJobQueued)
+	if err != nil {
+		return err
+	}
+	for _, job := range queued {
+		if !r.enqueue(job.ID) {
+			if err := r.finishJob(job, models.PlanningJobInterrupted, ErrQueueFull.Error()); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Infof("Recovered planning jobs: %d interrupted, %d queued", len(running), len(queued))
+	return nil
+}
+
+// Submit validates req and queues it as a new job.
+func (r *PlanningRunner) Submit(req planner.Request) (*models.PlanningJob, error) {
+	req, err := r.service.Prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.PlanningJob{
+		UserID:  req.UserID,
+		Status:  models.PlanningJobQueued,
+		Request: string(encoded),
+	}
+	if err := r.repo.Create(job); err != nil {
+		return nil, err
+	}
+
+	if !r.enqueue(job.ID) {
+		if err := r.finishJob(job, models.PlanningJobFailed, ErrQueueFull.Error()); err != nil {
+			return nil, err
+		}
+		return nil, ErrQueueFull
+	}
+
+	return job, nil
+}
+
+// Cancel stops a queued or running job. Running jobs finish cancelling
+// asynchronously, so the returned job may still report running.
+func (r *PlanningRunner) Cancel(id uint) (*models.PlanningJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, err := r.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status.IsFinished() {
+		return job, ErrJobFinished
+	}
+
+	if cancel, ok := r.cancels[id]; ok {
+		cancel()
+		return job, nil
+	}
+
+	if err := r.finishJob(job, models.PlanningJobCancelled, ""); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Subscribe streams the progress of job id. The channel is closed when the
+// job finishes; call the returned function to stop listening earlier.
+// Progress updates are dropped, oldest first, when the reader falls behind.
+func (r *PlanningRunner) Subscribe(id uint) (<-chan planner.Progress, func()) {
+	ch := make(chan planner.Progress, subscriberBuffer)
+
+	r.subscribersMu.Lock()
+	r.subscribers[id] = append(r.subscribers[id], ch)
+	r.subscribersMu.Unlock()
+
+	unsubscribe := func() {
+		r.subscribersMu.Lock()
+		defer r.subscribersMu.Unlock()
+
+		subscribers := r.subscribers[id]
+		for i, sub := range subscribers {
+			if sub == ch {
+				r.subscribers[id] = append(subscribers[:i:i], subscribers[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+		if len(r.subscribers[id]) == 0 {
+			delete(r.subscribers, id)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+func (r *PlanningRunner) publish(id uint, progress planner.Progress) {
+	r.subscribersMu.Lock()
+	defer r.subscribersMu.Unlock()
+
+	for _, ch := range r.subscribers[id] {
+		select {
+		case ch <- progress:
+		default:
+			// Make room by dropping the oldest update
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- progress:
+			default:
+			}
+		}
+	}
+}
+
+func (r *PlanningRunner) closeSubscribers(id uint) {
+	r.subscribersMu.Lock()
+	defer r.subscribersMu.Unlock()
+
+	for _, ch := range r.subscribers[id] {
+		close(ch)
+	}
+	delete(r.subscribers, id)
+}
+
+func (r *PlanningRunner) enqueue(id uint) bool {
+	select {
+	case r.queue <- id:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *PlanningRunner) work() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case id := <-r.queue:
+			r.run(id)
+		}
+	}
+}
+
+func (r *PlanningRunner) run(id uint) {
+	job, ctx, ok := r.startJob(id)
+	if !ok {
+		return
+	}
+	defer r.releaseJob(id)
+
+	var req planner.Request
+	if err := json.Unmarshal([]byte(job.Request), &req); err != nil {
+		r.completeJob(job, nil, err)
+		return
+	}
+
+	lastUpdate := time.Now()
+	mealPlans, err := r.service.Plan(ctx, req, func(p planner.Progress) {
+		r.publish(id, p)
+		job.Progress = p.Fraction()
+		if time.Since(lastUpdate) < progressInterval {
+			return
+		}
+		lastUpdate = time.Now()
+		if err := r.repo.UpdateProgress(id, job.Progress); err != nil {
+			log.Warnf("Error updating progress of planning job %d: %v", id, err)
+		}
+	})
+
+	r.completeJob(job, mealPlans, err)
+}
+
+// startJob marks a queued job as running and registers its cancel function.
+// It reports false for jobs that were cancelled while they waited.
+func (r *PlanningRunner) startJob(id uint) (*models.PlanningJob, context.Context, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, err := r.repo.FindByID(id)
+	if err != nil {
+		log.Errorf("Error loading planning job %d: %v", id, err)
+		return nil, nil, false
+	}
+	if job.Status != models.PlanningJobQueued {
+		return nil, nil, false
+	}
+
+	now := time.Now()
+	job.Status = models.PlanningJobRunning
+	job.StartedAt = &now
+	if err := r.repo.Update(job); err != nil {
+		log.Errorf("Error starting planning job %d: %v", id, err)
+		return nil, nil, false
+	}
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	r.cancels[id] = cancel
+	return job, ctx, true
+}
+
+func (r *PlanningRunner) releaseJob(id uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cancel, ok := r.cancels[id]; ok {
+		cancel()
+		delete(r.cancels, id)
+	}
+}
+
+func (r *PlanningRunner) completeJob(job *models.PlanningJob, mealPlans []models.MealPlan, err error) {
+	var finishErr error
+	switch {
+	case err == nil:
+		result, marshalErr := json.Marshal(mealPlans)
+		if marshalErr != nil {
+			finishErr = r.finishJob(job, models.PlanningJobFailed, marshalErr.Error())
+			break
+		}
+		job.Result = string(result)
+		job.Progress = 1
+		finishErr = r.finishJob(job, models.PlanningJobSucceeded, "")
+	case errors.Is(err, context.Canceled) && r.ctx.Err() != nil:
+		finishErr = r.finishJob(job, models.PlanningJobInterrupted, "")
+	case errors.Is(err, context.Canceled):
+		finishErr = r.finishJob(job, models.PlanningJobCancelled, "")
+	default:
+		finishErr = r.finishJob(job, models.PlanningJobFailed, err.Error())
+	}
+
+	if finishErr != nil {
+		log.Errorf("Error saving planning job %d: %v", job.ID, finishErr)
+	}
+}
+
+func (r *PlanningRunner) finishJob(job *models.PlanningJob, status models.PlanningJobStatus, message string) error {
+	now := time.Now()
+	job.Status = status
+	job.Error = message
+	job.FinishedAt = &now
+	err := r.repo.Update(job)
+	r.closeSubscribers(job.ID)
+	return err
+}
@@ -0,0 +1,312 @@
+package jobs_test
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cvele/recipe/pkg/jobs"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/planner"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type RecipeRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *RecipeRepositoryMock) GetAllRecipes() ([]models.Recipe, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Recipe), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) FindRecipes(filter repositories.RecipeFilter) ([]models.Recipe, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.Recipe), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) GetRecipeByID(id uint) (*models.Recipe, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.Recipe), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) CreateRecipe(recipe *models.Recipe) error {
+	args := m.Called(recipe)
+	return args.Error(0)
+}
+
+func (m *RecipeRepositoryMock) UpdateRecipe(recipe *models.Recipe) error {
+	args := m.Called(recipe)
+	return args.Error(0)
+}
+
+func (m *RecipeRepositoryMock) DeleteRecipe(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *RecipeRepositoryMock) GetRandomRecipeByType(mealType models.MealType) (*models.Recipe, error) {
+	args := m.Called(mealType)
+	return args.Get(0).(*models.Recipe), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) GetRecipesByType(mealType models.MealType) ([]models.Recipe, error) {
+	args := m.Called(mealType)
+	return args.Get(0).([]models.Recipe), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) GetRecipeVersions(id uint) ([]models.RecipeVersion, error) {
+	args := m.Called(id)
+	return args.Get(0).([]models.RecipeVersion), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) GetRecipeVersion(id uint, version int) (*models.Recipe, error) {
+	args := m.Called(id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Recipe), args.Error(1)
+}
+
+// memoryJobRepository keeps planning jobs in memory.
+type memoryJobRepository struct {
+	mu     sync.Mutex
+	jobs   map[uint]models.PlanningJob
+	nextID uint
+}
+
+func newMemoryJobRepository() *memoryJobRepository {
+	return &memoryJobRepository{jobs: make(map[uint]models.PlanningJob)}
+}
+
+func (r *memoryJobRepository) Create(job *models.PlanningJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	job.ID = r.nextID
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *memoryJobRepository) FindByID(id uint) (*models.PlanningJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &job, nil
+}
+
+func (r *memoryJobRepository) FindByStatus(status models.PlanningJobStatus) ([]*models.PlanningJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []*models.PlanningJob
+	for id := uint(1); id <= r.nextID; id++ {
+		if job, ok := r.jobs[id]; ok && job.Status == status {
+			found = append(found, &job)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryJobRepository) Update(job *models.PlanningJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *memoryJobRepository) UpdateProgress(id uint, progress float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.jobs[id]
+	job.Progress = progress
+	r.jobs[id] = job
+	return nil
+}
+
+func newTestService() *planner.Service {
+	recipeRepo := new(RecipeRepositoryMock)
+	recipeRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{
+		{
+			ID:       1,
+			Servings: 1,
+			RecipeIngredients: &[]models.RecipeIngredient{
+				{
+					Ingredient: models.Ingredient{Name: "Rice", UnitType: "mass", PricePerUnit: 1},
+					Quantity:   80,
+					Unit:       "g",
+				},
+			},
+		},
+	}, nil)
+	recipeRepo.On("GetRecipeByID", uint(9)).Return((*models.Recipe)(nil), gorm.ErrRecordNotFound)
+
+	return planner.NewService(recipeRepo, nil, nil, nil, units.NewUnitConverter("g", "ml"), planner.GeneticTuning{
+		PopulationSize: 10,
+		MaxGenerations: 5,
+		CrossoverRate:  0.7,
+		MutationRate:   0.1,
+	})
+}
+
+func lunchRequest() planner.Request {
+	start := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	return planner.Request{
+		MealPlanParams: models.MealPlanParams{
+			StartDate: start,
+			EndDate:   start.AddDate(0, 0, 2),
+			Servings:  2,
+			MealType:  models.Lunch,
+		},
+	}
+}
+
+func waitForStatus(t *testing.T, repo *memoryJobRepository, id uint) *models.PlanningJob {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := repo.FindByID(id)
+		assert.NoError(t, err)
+		if job.Status.IsFinished() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("planning job %d did not finish", id)
+	return nil
+}
+
+func TestPlanningRunner_SubmitAndComplete(t *testing.T) {
+	repo := newMemoryJobRepository()
+	runner := jobs.NewPlanningRunner(repo, newTestService(), 2, 10)
+	runner.Start()
+	defer runner.Shutdown()
+
+	job, err := runner.Submit(lunchRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, models.PlanningJobQueued, job.Status)
+
+	job = waitForStatus(t, repo, job.ID)
+	assert.Equal(t, models.PlanningJobSucceeded, job.Status)
+	assert.Equal(t, 1.0, job.Progress)
+	assert.NotNil(t, job.StartedAt)
+	assert.NotNil(t, job.FinishedAt)
+
+	var mealPlans []models.MealPlan
+	assert.NoError(t, json.Unmarshal([]byte(job.Result), &mealPlans))
+	assert.Len(t, mealPlans, 2)
+}
+
+func TestPlanningRunner_Subscribe(t *testing.T) {
+	repo := newMemoryJobRepository()
+	runner := jobs.NewPlanningRunner(repo, newTestService(), 1, 10)
+
+	job, err := runner.Submit(lunchRequest())
+	assert.NoError(t, err)
+	events, unsubscribe := runner.Subscribe(job.ID)
+	defer unsubscribe()
+
+	runner.Start()
+	defer runner.Shutdown()
+
+	var received []planner.Progress
+	for progress := range events {
+		received = append(received, progress)
+	}
+
+	// Every day ends with the reason evolution stopped
+	var finishedDays int
+	for _, progress := range received {
+		assert.Equal(t, uint(1), progress.BestRecipeID)
+		if progress.TerminationReason != "" {
+			finishedDays++
+		}
+	}
+	assert.Equal(t, 2, finishedDays)
+	assert.Equal(t, planner.TerminationMaxGenerations, received[len(received)-1].TerminationReason)
+
+	job, _ = repo.FindByID(job.ID)
+	assert.Equal(t, models.PlanningJobSucceeded, job.Status)
+}
+
+func TestPlanningRunner_SubmitInvalid(t *testing.T) {
+	runner := jobs.NewPlanningRunner(newMemoryJobRepository(), newTestService(), 1, 10)
+
+	req := lunchRequest()
+	req.Servings = 0
+	_, err := runner.Submit(req)
+
+	var validationErrors models.ValidationErrors
+	assert.ErrorAs(t, err, &validationErrors)
+
+	// Pinned recipes are looked up before the job is queued
+	req = lunchRequest()
+	req.Pinned = []planner.PinnedMeal{{RecipeID: 9, MealTime: req.StartDate.Add(12 * time.Hour)}}
+	_, err = runner.Submit(req)
+
+	if assert.ErrorAs(t, err, &validationErrors) {
+		assert.Equal(t, models.ValidationErrors{{Field: "pinned[0].recipe_id", Message: "does not exist"}}, validationErrors)
+	}
+}
+
+func TestPlanningRunner_QueueFull(t *testing.T) {
+	repo := newMemoryJobRepository()
+	runner := jobs.NewPlanningRunner(repo, newTestService(), 1, 1)
+
+	_, err := runner.Submit(lunchRequest())
+	assert.NoError(t, err)
+
+	_, err = runner.Submit(lunchRequest())
+	assert.ErrorIs(t, err, jobs.ErrQueueFull)
+
+	job, _ := repo.FindByID(2)
+	assert.Equal(t, models.PlanningJobFailed, job.Status)
+}
+
+func TestPlanningRunner_CancelQueued(t *testing.T) {
+	repo := newMemoryJobRepository()
+	runner := jobs.NewPlanningRunner(repo, newTestService(), 1, 10)
+
+	job, err := runner.Submit(lunchRequest())
+	assert.NoError(t, err)
+
+	job, err = runner.Cancel(job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PlanningJobCancelled, job.Status)
+
+	_, err = runner.Cancel(job.ID)
+	assert.ErrorIs(t, err, jobs.ErrJobFinished)
+
+	// A cancelled job is skipped once a worker picks it up
+	runner.Start()
+	runner.Shutdown()
+	job, _ = repo.FindByID(job.ID)
+	assert.Equal(t, models.PlanningJobCancelled, job.Status)
+	assert.Nil(t, job.StartedAt)
+}
+
+func TestPlanningRunner_Recover(t *testing.T) {
+	encoded, _ := json.Marshal(lunchRequest())
+
+	repo := newMemoryJobRepository()
+	repo.Create(&models.PlanningJob{Status: models.PlanningJobRunning, Request: string(encoded)})
+	repo.Create(&models.PlanningJob{Status: models.PlanningJobQueued, Request: string(encoded)})
+
+	runner := jobs.NewPlanningRunner(repo, newTestService(), 1, 10)
+	assert.NoError(t, runner.Recover())
+
+	interrupted, _ := repo.FindByID(1)
+	assert.Equal(t, models.PlanningJobInterrupted, interrupted.Status)
+
+	runner.Start()
+	defer runner.Shutdown()
+
+	resumed := waitForStatus(t, repo, 2)
+	assert.Equal(t, models.PlanningJobSucceeded, resumed.Status)
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Collection is a user's cookbook: recipes they put together under a name.
+// RecipeIDs sets the recipes when a collection is created or updated; left
+// out, an update keeps the recipes it has.
+type Collection struct {
+	gorm.Model
+	UserID      uint     `json:"user_id" gorm:"not null;index"`
+	Name        string   `json:"name" gorm:"type:varchar(100);not null"`
+	Description string   `json:"description" gorm:"type:text"`
+	Recipes     []Recipe `json:"recipes" gorm:"many2many:collection_recipes;association_autoupdate:false;association_autocreate:false"`
+	RecipeIDs   []uint   `json:"recipe_ids,omitempty" gorm:"-"`
+}
+
+func (c Collection) Validate() error {
+	var errs ValidationErrors
+
+	if c.UserID == 0 {
+		errs.Add("user_id", "is required")
+	}
+	if strings.TrimSpace(c.Name) == "" {
+		errs.Add("name", "is required")
+	} else if len(c.Name) > 100 {
+		errs.Add("name", "must be at most 100 characters")
+	}
+	for i, id := range c.RecipeIDs {
+		if id == 0 {
+			errs.Add(fmt.Sprintf("recipe_ids[%d]", i), "is required")
+		}
+	}
+
+	return errs.Err()
+}
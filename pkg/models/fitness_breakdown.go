@@ -0,0 +1,175 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Objective names used in FitnessBreakdown besides the NutritionalValues
+// fields.
+const (
+	ObjectiveCost           = "cost"
+	ObjectiveBudget         = "budget"
+	ObjectiveVariety        = "variety"
+	ObjectiveCuisineVariety = "cuisine_variety"
+)
+
+// Violation says which limit an objective broke.
+type Violation string
+
+const (
+	ViolationBelowMin Violation = "below_min"
+	ViolationAboveMax Violation = "above_max"
+)
+
+// ObjectiveScore is one term of a meal's fitness. Penalty is what the term
+// added to the fitness; lower is better. Member names the diner a nutrient
+// was scored for when meals are scored per diner.
+type ObjectiveScore struct {
+	Objective string    `json:"objective"`
+	Member    string    `json:"member,omitempty"`
+	Value     float64   `json:"value"`
+	Target    float64   `json:"target"`
+	Min       float64   `json:"min"`
+	Max       float64   `json:"max"`
+	Penalty   float64   `json:"penalty"`
+	Violation Violation `json:"violation,omitempty"`
+}
+
+// FitnessBreakdown explains how the planner scored a meal. Total is the
+// meal's fitness and the sum of the objectives' penalties. Pinned meals are
+// scored too, but were not chosen by the planner. Generations and
+// Termination say how long the planner searched and why it stopped.
+// Unconverted names the recipe's ingredients whose quantities couldn't be
+// converted, which the nutrient scores leave out.
+type FitnessBreakdown struct {
+	Total       float64          `json:"total"`
+	Objectives  []ObjectiveScore `json:"objectives"`
+	Pinned      bool             `json:"pinned,omitempty"`
+	Generations int              `json:"generations,omitempty"`
+	Termination string           `json:"termination,omitempty"`
+	Unconverted []string         `json:"unconverted,omitempty"`
+}
+
+// Add appends score to the breakdown and adds its penalty to the total.
+func (b *FitnessBreakdown) Add(score ObjectiveScore) {
+	b.Objectives = append(b.Objectives, score)
+	b.Total += score.Penalty
+}
+
+// Violations returns the objectives that broke a limit.
+func (b FitnessBreakdown) Violations() []ObjectiveScore {
+	var violations []ObjectiveScore
+	for _, score := range b.Objectives {
+		if score.Violation != "" {
+			violations = append(violations, score)
+		}
+	}
+	return violations
+}
+
+// Explain renders the breakdown as sentences in objective order.
+// Nutrients without limits are left out. Costs and budgets are in cents.
+func (b FitnessBreakdown) Explain() []string {
+	var lines []string
+	if b.Pinned {
+		lines = append(lines, "This meal was pinned, so the planner planned the other days around it.")
+	}
+	if len(b.Unconverted) > 0 {
+		lines = append(lines, fmt.Sprintf("Its nutrients leave out %s, whose quantities couldn't be converted.", strings.Join(b.Unconverted, ", ")))
+	}
+
+	for _, score := range b.Objectives {
+		switch score.Objective {
+		case ObjectiveCost:
+			lines = append(lines, fmt.Sprintf("It costs %s.", formatCents(score.Value)))
+		case ObjectiveBudget:
+			lines = append(lines, explainLimit("Its cost", score, formatCents))
+		case ObjectiveVariety:
+			if score.Value > 0 {
+				lines = append(lines, fmt.Sprintf("The recipe also appears %s elsewhere in the plan, adding %.1f to the penalty.", times(int(score.Value)), score.Penalty))
+			}
+		case ObjectiveCuisineVariety:
+			if score.Value > 0 {
+				lines = append(lines, fmt.Sprintf("Its cuisine also appears %s elsewhere in the plan, adding %.1f to the penalty.", times(int(score.Value)), score.Penalty))
+			}
+		default:
+			if score.Target == 0 && score.Min == 0 && score.Max == 0 && score.Value == 0 {
+				continue
+			}
+			subject := capitalize(score.Objective)
+			if score.Member != "" {
+				subject = fmt.Sprintf("%s's %s", score.Member, score.Objective)
+			}
+			lines = append(lines, explainLimit(subject, score, formatAmount))
+		}
+	}
+
+	lines = append(lines, fmt.Sprintf("Total penalty: %.1f (lower is better).", b.Total))
+	if b.Generations > 0 {
+		lines = append(lines, fmt.Sprintf("The planner stopped after %d generations (%s).", b.Generations, b.Termination))
+	}
+	return lines
+}
+
+// Value stores the breakdown as JSON.
+func (b FitnessBreakdown) Value() (driver.Value, error) {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (b *FitnessBreakdown) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, b)
+	case string:
+		return json.Unmarshal([]byte(data), b)
+	default:
+		return errors.New("unsupported fitness breakdown value")
+	}
+}
+
+func explainLimit(subject string, score ObjectiveScore, format func(float64) string) string {
+	switch score.Violation {
+	case ViolationBelowMin:
+		return fmt.Sprintf("%s of %s is below the minimum of %s.", subject, format(score.Value), format(score.Min))
+	case ViolationAboveMax:
+		return fmt.Sprintf("%s of %s is above the maximum of %s.", subject, format(score.Value), format(score.Max))
+	default:
+		if score.Target == 0 {
+			return fmt.Sprintf("%s of %s is within limits.", subject, format(score.Value))
+		}
+		return fmt.Sprintf("%s of %s is within limits, %s from the target of %s.", subject, format(score.Value), format(math.Abs(score.Value-score.Target)), format(score.Target))
+	}
+}
+
+func formatAmount(value float64) string {
+	return fmt.Sprintf("%.1f", value)
+}
+
+func formatCents(value float64) string {
+	return fmt.Sprintf("%.2f", value/100)
+}
+
+func times(n int) string {
+	if n == 1 {
+		return "once"
+	}
+	return fmt.Sprintf("%d times", n)
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
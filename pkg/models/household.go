@@ -0,0 +1,308 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ActivityLevel is how physically active a household member is.
+type ActivityLevel string
+
+const (
+	ActivitySedentary  ActivityLevel = "sedentary"
+	ActivityLight      ActivityLevel = "light"
+	ActivityModerate   ActivityLevel = "moderate"
+	ActivityActive     ActivityLevel = "active"
+	ActivityVeryActive ActivityLevel = "very_active"
+)
+
+// ActivityLevels lists the valid activity levels from least to most active.
+var ActivityLevels = []ActivityLevel{ActivitySedentary, ActivityLight, ActivityModerate, ActivityActive, ActivityVeryActive}
+
+func (a ActivityLevel) IsValid() bool {
+	for _, level := range ActivityLevels {
+		if a == level {
+			return true
+		}
+	}
+	return false
+}
+
+// Sex selects the energy expenditure equations. Unset uses the average of
+// both.
+type Sex string
+
+const (
+	SexFemale Sex = "female"
+	SexMale   Sex = "male"
+)
+
+func (s Sex) IsValid() bool {
+	return s == SexFemale || s == SexMale
+}
+
+// Goal adjusts the energy target to lose, keep or gain weight.
+type Goal string
+
+const (
+	GoalLose     Goal = "lose"
+	GoalMaintain Goal = "maintain"
+	GoalGain     Goal = "gain"
+)
+
+func (g Goal) IsValid() bool {
+	return g == GoalLose || g == GoalMaintain || g == GoalGain
+}
+
+// ReferenceDailyCalories is the daily energy intake of one recipe serving
+// eaten at every meal. Members without an explicit portion eat their
+// calorie target's share of it.
+const ReferenceDailyCalories = 2000.0
+
+// Household is the people a user plans meals for.
+type Household struct {
+	gorm.Model
+	UserID  uint              `json:"user_id" gorm:"not null;index"`
+	Name    string            `json:"name" gorm:"type:varchar(100);not null"`
+	Members []HouseholdMember `json:"members" gorm:"foreignKey:HouseholdID"`
+}
+
+// AdultAge is the age from which a person's basal metabolic rate is
+// estimated from their height as well as their weight.
+const AdultAge = 18
+
+// Profile is what a person's recommended daily intake is based on. Every
+// field is optional, but intake can only be recommended once the weight,
+// the age and, for adults, the height are known.
+type Profile struct {
+	Age           int           `json:"age"`
+	Sex           Sex           `json:"sex" gorm:"type:varchar(16)"`
+	Weight        float64       `json:"weight"` // in kg
+	Height        float64       `json:"height"` // in cm
+	ActivityLevel ActivityLevel `json:"activity_level" gorm:"type:varchar(16)"`
+	Goal          Goal          `json:"goal" gorm:"type:varchar(16)"`
+}
+
+func (p Profile) Validate() error {
+	var errs ValidationErrors
+	p.validate(&errs, "")
+	return errs.Err()
+}
+
+// ValidateRecommendable reports the fields that intake recommendations
+// need but the profile lacks.
+func (p Profile) ValidateRecommendable() error {
+	var errs ValidationErrors
+	p.validateRecommendable(&errs, "")
+	return errs.Err()
+}
+
+// CanRecommend reports whether intake can be recommended for the profile.
+func (p Profile) CanRecommend() bool {
+	return p.ValidateRecommendable() == nil
+}
+
+// validateRecommendable adds the fields recommendations need that the
+// profile lacks to errs under prefix.
+func (p Profile) validateRecommendable(errs *ValidationErrors, prefix string) {
+	if p.Weight <= 0 {
+		errs.Add(prefix+"weight", "is required to recommend nutrient targets")
+	}
+	if p.Age <= 0 {
+		errs.Add(prefix+"age", "is required to recommend nutrient targets")
+	} else if p.Age >= AdultAge && p.Height <= 0 {
+		errs.Add(prefix+"height", "is required to recommend nutrient targets for adults")
+	}
+}
+
+// validate adds the profile's invalid fields to errs under prefix.
+func (p Profile) validate(errs *ValidationErrors, prefix string) {
+	if p.Age < 0 {
+		errs.Add(prefix+"age", "must not be negative")
+	}
+	if p.Sex != "" && !p.Sex.IsValid() {
+		errs.Add(prefix+"sex", "must be female or male")
+	}
+	if p.Weight < 0 {
+		errs.Add(prefix+"weight", "must not be negative")
+	}
+	if p.Height < 0 {
+		errs.Add(prefix+"height", "must not be negative")
+	}
+	if p.ActivityLevel != "" && !p.ActivityLevel.IsValid() {
+		errs.Add(prefix+"activity_level", "must be one of sedentary, light, moderate, active or very_active")
+	}
+	if p.Goal != "" && !p.Goal.IsValid() {
+		errs.Add(prefix+"goal", "must be one of lose, maintain or gain")
+	}
+}
+
+// HouseholdMember is one person in a household. Nutrient targets are daily
+// and spread over the meals by their slot's Share. Targets left at zero are
+// recommended from the member's profile when it has what that needs.
+//
+// Portion is how many recipe servings the member eats at a meal; zero
+// derives it from the member's calorie target. Meals lists the meal types
+// the member attends, all of them when empty. DietaryRestrictions are names
+// of ingredients the member doesn't eat.
+type HouseholdMember struct {
+	gorm.Model
+	Profile `gorm:"embedded"`
+
+	HouseholdID         uint              `json:"household_id" gorm:"not null;index"`
+	Name                string            `json:"name" gorm:"type:varchar(100);not null"`
+	DietaryRestrictions StringList        `json:"dietary_restrictions" gorm:"type:text"`
+	Meals               MealTypes         `json:"meals" gorm:"type:text"`
+	Portion             float64           `json:"portion"`
+	TargetNutrients     NutritionalValues `json:"target_nutrients" gorm:"embedded;embedded_prefix:target_"`
+	MinNutrients        NutritionalValues `json:"min_nutrients" gorm:"embedded;embedded_prefix:min_"`
+	MaxNutrients        NutritionalValues `json:"max_nutrients" gorm:"embedded;embedded_prefix:max_"`
+}
+
+// Attends reports whether the member eats meals of the type.
+func (m HouseholdMember) Attends(mealType MealType) bool {
+	return len(m.Meals) == 0 || m.Meals.Contains(mealType)
+}
+
+// PortionSize returns the servings the member eats at a meal: Portion when
+// set, otherwise their calorie target relative to ReferenceDailyCalories,
+// and one serving for members without a calorie target.
+func (m HouseholdMember) PortionSize() float64 {
+	if m.Portion > 0 {
+		return m.Portion
+	}
+	if m.TargetNutrients.Calories > 0 {
+		return m.TargetNutrients.Calories / ReferenceDailyCalories
+	}
+	return 1
+}
+
+// Diner returns the member's portion and nutrient limits for a meal in the
+// slot.
+func (m HouseholdMember) Diner(slot MealSlot) Diner {
+	share := slot.Share
+	return Diner{
+		Name:            m.Name,
+		Portion:         m.PortionSize(),
+		TargetNutrients: m.TargetNutrients.Scale(share),
+		MinNutrients:    m.MinNutrients.Scale(share),
+		MaxNutrients:    m.MaxNutrients.Scale(share),
+	}
+}
+
+// Diners returns the members attending meals in the slot.
+func (h Household) Diners(slot MealSlot) []Diner {
+	var diners []Diner
+	for _, member := range h.Members {
+		if member.Attends(slot.Name) {
+			diners = append(diners, member.Diner(slot))
+		}
+	}
+	return diners
+}
+
+// Restrictions returns the ingredients any member attending meals of the
+// type doesn't eat, without duplicates.
+func (h Household) Restrictions(mealType MealType) []string {
+	seen := make(map[string]bool)
+	var restrictions []string
+	for _, member := range h.Members {
+		if !member.Attends(mealType) {
+			continue
+		}
+		for _, restriction := range member.DietaryRestrictions {
+			key := strings.ToLower(strings.TrimSpace(restriction))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			restrictions = append(restrictions, restriction)
+		}
+	}
+	return restrictions
+}
+
+func (h Household) Validate() error {
+	var errs ValidationErrors
+
+	if strings.TrimSpace(h.Name) == "" {
+		errs.Add("name", "is required")
+	}
+	for i, member := range h.Members {
+		field := fmt.Sprintf("members[%d]", i)
+		if strings.TrimSpace(member.Name) == "" {
+			errs.Add(field+".name", "is required")
+		}
+		member.Profile.validate(&errs, field+".")
+		if member.Portion < 0 {
+			errs.Add(field+".portion", "must not be negative")
+		}
+		for j, meal := range member.Meals {
+			if !mealSlotName.MatchString(string(meal)) {
+				errs.Add(fmt.Sprintf("%s.meals[%d]", field, j), "must be the name of a meal slot")
+			}
+		}
+		validateNutrientLimits(&errs, field+".", member.MinNutrients, member.TargetNutrients, member.MaxNutrients)
+	}
+
+	return errs.Err()
+}
+
+// Diner is someone eating the planned meals: how many recipe servings they
+// eat and their nutrient limits for the meal.
+type Diner struct {
+	Name            string            `json:"name"`
+	Portion         float64           `json:"portion"`
+	TargetNutrients NutritionalValues `json:"target_nutrients"`
+	MinNutrients    NutritionalValues `json:"min_nutrients"`
+	MaxNutrients    NutritionalValues `json:"max_nutrients"`
+}
+
+// DinerServings returns the servings needed to give every diner their
+// portion.
+func DinerServings(diners []Diner) int {
+	portions := 0.0
+	for _, diner := range diners {
+		portions += diner.Portion
+	}
+	// Tolerate rounding in portions that add up to whole servings
+	return int(math.Ceil(portions - 1e-9))
+}
+
+// StringList is a list of strings stored as JSON.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	return jsonValue(l)
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	return scanJSON(value, l)
+}
+
+func jsonValue(v interface{}) (driver.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func scanJSON(value interface{}, dest interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, dest)
+	case string:
+		return json.Unmarshal([]byte(data), dest)
+	default:
+		return errors.New("unsupported JSON column value")
+	}
+}
@@ -8,14 +8,25 @@ import (
 
 type Ingredient struct {
 	gorm.Model
-	ID           uint    `gorm:"primary_key"`
-	Name         string  `json:"name" gorm:"type:varchar(100);not null"`
-	PricePerUnit int     `json:"price_per_unit" gorm:"type:int;not null"`        // price per unit in cents
-	Unit         float64 `json:"unit" gorm:"type:varchar(32);not null"`          // unit for price per unit for example kg or l
-	UnitType     string  `json:"unit_type" gorm:"type:varchar(32);not null"`     // type of the unit for example mass or volume
-	Quantity     float64 `json:"quantity" gorm:"type:decimal(10,2);not null"`    // quantity for which the nutrients are given (NutritionalValues)
-	QuantityUnit string  `json:"quantity_unit" gorm:"type:varchar(32);not null"` // unit of the quantity for which the nutrients are given
-	Nutrients    NutritionalValues
+	ID           uint              `gorm:"primary_key"`
+	Name         string            `json:"name" gorm:"type:varchar(100);not null"`
+	Aliases      []IngredientAlias `json:"aliases" gorm:"foreignKey:IngredientID"`
+	PricePerUnit int               `json:"price_per_unit" gorm:"type:int;not null"`        // price per unit in cents
+	Unit         string            `json:"unit" gorm:"type:varchar(32);not null"`          // unit for price per unit for example kg or l
+	UnitType     string            `json:"unit_type" gorm:"type:varchar(32);not null"`     // type of the unit for example mass or volume
+	Quantity     float64           `json:"quantity" gorm:"type:decimal(10,2);not null"`    // quantity for which the nutrients are given (NutritionalValues)
+	QuantityUnit string            `json:"quantity_unit" gorm:"type:varchar(32);not null"` // unit of the quantity for which the nutrients are given
+	Nutrients    NutritionalValues `json:"nutrients" gorm:"embedded;embedded_prefix:nutrient_"`
+	Source       NutrientSource    `json:"nutrient_source" gorm:"embedded;embedded_prefix:nutrient_source_"` // where imported nutrients come from
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
+
+// NutrientSource records the food composition dataset entry an ingredient's
+// nutrients were imported from. It is empty for nutrients entered by hand.
+type NutrientSource struct {
+	Dataset      string  `json:"dataset" gorm:"type:varchar(32)"`
+	FoodID       string  `json:"food_id" gorm:"type:varchar(64)"`
+	Version      string  `json:"version" gorm:"type:varchar(32)"`          // the dataset's publication or modification date of the food
+	GramsPerUnit float64 `json:"grams_per_unit" gorm:"type:decimal(10,4)"` // weight of one QuantityUnit, kept when that isn't a mass unit
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// IngredientAlias is an alternative name an ingredient can be found by, for
+// example "scallion" for spring onion.
+type IngredientAlias struct {
+	gorm.Model
+	IngredientID uint   `json:"ingredient_id" gorm:"not null;index"`
+	Name         string `json:"name" gorm:"type:varchar(100);not null"`
+}
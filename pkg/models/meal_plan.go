@@ -14,11 +14,15 @@ type MealPlan struct {
 	ID            uint `gorm:"primary_key"`
 	UserID        uint
 	RecipeID      uint
-	Recipe        *Recipe `gorm:"foreignKey:RecipeID"`
-	RecipeVersion int     `gorm:"not null"`
-	Servings      int     `gorm:"not null"`
+	Recipe        *Recipe  `gorm:"foreignKey:RecipeID"`
+	RecipeVersion int      `gorm:"not null"`
+	Servings      int      `gorm:"not null"`
+	MealType      MealType `json:"meal_type" gorm:"column:meal_slot;type:varchar(50);index"`
 	Synced        bool
-	MealTime      time.Time
+	MealTime      time.Time         `sql:"index"`
+	Duration      int               `json:"duration"`  // in minutes, zero when unknown
+	CookedAt      *time.Time        `json:"cooked_at"` // nil until the meal is marked as cooked
+	Fitness       *FitnessBreakdown `json:"fitness,omitempty" gorm:"type:text"`
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 	DeletedAt     *time.Time `sql:"index"`
@@ -49,7 +53,7 @@ func (mp *MealPlan) AdjustServings(converter units.UnitConverterInterface) error
 		return errors.New("invalid recipe servings (zero) in meal plan")
 	}
 
-	servingsRatio := float64(mp.Servings) / float64(mp.Recipe.Servings)
+	servingsRatio := units.NewQuantity(int64(mp.Servings), int64(mp.Recipe.Servings))
 
 	for i, recipeIngredient := range *mp.Recipe.RecipeIngredients {
 		ingredient := recipeIngredient.Ingredient
@@ -63,15 +67,15 @@ func (mp *MealPlan) AdjustServings(converter units.UnitConverterInterface) error
 		}
 
 		// Convert the RecipeIngredient's unit to the default unit
-		convertedQuantity, err := converter.ConvertUnits(recipeIngredient.Quantity, recipeIngredient.Unit, defaultUnit, ingredient.UnitType)
+		convertedQuantity, err := units.ConvertQuantity(converter, units.QuantityFromFloat(recipeIngredient.Quantity), recipeIngredient.Unit, defaultUnit, ingredient.UnitType)
 		if err != nil {
 			return err
 		}
 
-		// Adjust the quantity according to the servings ratio
-		adjustedQuantity := servingsRatio * convertedQuantity
+		// Adjust the quantity according to the servings ratio, exactly
+		adjustedQuantity := convertedQuantity.Mul(servingsRatio)
 
-		(*mp.Recipe.RecipeIngredients)[i].Quantity = adjustedQuantity
+		(*mp.Recipe.RecipeIngredients)[i].Quantity = adjustedQuantity.Float64()
 		(*mp.Recipe.RecipeIngredients)[i].Unit = defaultUnit
 	}
 
@@ -1,39 +1,101 @@
 package models
 
-import "time"
-
-type MealType int
-
-const (
-	Breakfast MealType = iota
-	Lunch
-	Dinner
-	Snack
+import (
+	"fmt"
+	"time"
 )
 
+// MealPlanParams describes the period to plan. Nutrient limits are per meal;
+// budgets are for the whole period, in cents.
+//
+// Without Diners the nutrient limits apply to all servings of a meal
+// together. With Diners each diner's portion is scored against their own
+// limits instead, and the plan's limits are not used. Recipes containing
+// an ExcludedIngredients ingredient are not planned.
+//
+// Only recipes with every one of Tags and, when Cuisines is set, one of the
+// Cuisines are planned, and none with an ExcludedTags tag or of an
+// ExcludedCuisines cuisine. Tags and cuisines are compared ignoring case.
 type MealPlanParams struct {
-	StartDate       time.Time
-	EndDate         time.Time
-	TargetBudget    float64
-	MaxBudget       float64
-	TargetNutrients NutritionalValues
-	MaxNutrients    NutritionalValues
-	MinNutrients    NutritionalValues
-	Servings        int
-	MealType        MealType
+	StartDate       time.Time         `json:"start_date"`
+	EndDate         time.Time         `json:"end_date"`
+	TargetBudget    float64           `json:"target_budget"`
+	MaxBudget       float64           `json:"max_budget"`
+	TargetNutrients NutritionalValues `json:"target_nutrients"`
+	MaxNutrients    NutritionalValues `json:"max_nutrients"`
+	MinNutrients    NutritionalValues `json:"min_nutrients"`
+	Servings        int               `json:"servings"`
+	MealType        MealType          `json:"meal_type"`
+
+	Diners              []Diner  `json:"diners,omitempty"`
+	ExcludedIngredients []string `json:"excluded_ingredients,omitempty"`
+	Tags                []string `json:"tags,omitempty"`
+	ExcludedTags        []string `json:"excluded_tags,omitempty"`
+	Cuisines            []string `json:"cuisines,omitempty"`
+	ExcludedCuisines    []string `json:"excluded_cuisines,omitempty"`
+}
+
+// Validate checks that the parameters describe a plannable period with
+// consistent budget and nutrient limits.
+func (p MealPlanParams) Validate() error {
+	var errs ValidationErrors
+
+	if p.StartDate.IsZero() {
+		errs.Add("start_date", "is required")
+	}
+	if !p.EndDate.After(p.StartDate) {
+		errs.Add("end_date", "must be after start_date")
+	}
+	if p.Servings <= 0 {
+		errs.Add("servings", "must be greater than zero")
+	}
+	if p.TargetBudget < 0 {
+		errs.Add("target_budget", "must not be negative")
+	}
+	if p.MaxBudget < p.TargetBudget {
+		errs.Add("max_budget", "must not be less than target_budget")
+	}
+
+	validateNutrientLimits(&errs, "", p.MinNutrients, p.TargetNutrients, p.MaxNutrients)
+
+	for i, diner := range p.Diners {
+		field := fmt.Sprintf("diners[%d].", i)
+		if diner.Portion <= 0 {
+			errs.Add(field+"portion", "must be greater than zero")
+		}
+		validateNutrientLimits(&errs, field, diner.MinNutrients, diner.TargetNutrients, diner.MaxNutrients)
+	}
+
+	return errs.Err()
+}
+
+// PlannedDiners returns the diners meals are scored for. Without Diners,
+// all servings are one diner with the plan's nutrient limits.
+func (p MealPlanParams) PlannedDiners() []Diner {
+	if len(p.Diners) > 0 {
+		return p.Diners
+	}
+	return []Diner{{
+		Portion:         float64(p.Servings),
+		TargetNutrients: p.TargetNutrients,
+		MinNutrients:    p.MinNutrients,
+		MaxNutrients:    p.MaxNutrients,
+	}}
 }
 
-func (m MealType) String() string {
-	switch m {
-	case Breakfast:
-		return "breakfast"
-	case Lunch:
-		return "lunch"
-	case Dinner:
-		return "dinner"
-	case Snack:
-		return "snack"
-	default:
-		return "unknown"
+// validateNutrientLimits checks that min <= target <= max for every
+// nutrient, reporting fields under prefix.
+func validateNutrientLimits(errs *ValidationErrors, prefix string, min NutritionalValues, target NutritionalValues, max NutritionalValues) {
+	mins, targets, maxes := min.Fields(), target.Fields(), max.Fields()
+	for i, field := range NutrientFieldNames {
+		if mins[i] < 0 {
+			errs.Add(prefix+"min_nutrients."+field, "must not be negative")
+		}
+		if targets[i] < mins[i] {
+			errs.Add(prefix+"target_nutrients."+field, "must not be less than min_nutrients."+field)
+		}
+		if maxes[i] < targets[i] {
+			errs.Add(prefix+"max_nutrients."+field, "must not be less than target_nutrients."+field)
+		}
 	}
 }
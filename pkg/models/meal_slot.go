@@ -0,0 +1,148 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// MealType is the name of a meal slot.
+type MealType string
+
+// The meal slots every installation starts with.
+const (
+	Breakfast MealType = "breakfast"
+	Lunch     MealType = "lunch"
+	Dinner    MealType = "dinner"
+	Snack     MealType = "snack"
+)
+
+func (m MealType) String() string {
+	return string(m)
+}
+
+// MealSlot is a meal recipes are planned for, such as brunch, a second
+// breakfast or a school lunchbox. Name identifies the slot in recipes,
+// meal plans and households and can't be changed. DefaultTime is the time
+// of day, as HH:MM, meals are planned at when a plan doesn't set one, and
+// Duration how long they take, in minutes. Share is the part of a day's
+// nutrients eaten at the meal and is required, since nutrient limits are
+// scaled by it.
+type MealSlot struct {
+	gorm.Model
+	Name        MealType `json:"name" gorm:"type:varchar(50);not null;unique_index"`
+	Label       string   `json:"label" gorm:"type:varchar(100)"`
+	DefaultTime string   `json:"default_time" gorm:"type:varchar(5)"`
+	Duration    int      `json:"duration"`
+	Share       float64  `json:"share"`
+}
+
+// DefaultMealSlots are created with the database.
+var DefaultMealSlots = []MealSlot{
+	{Name: Breakfast, Label: "Breakfast", DefaultTime: "08:00", Duration: 30, Share: 0.25},
+	{Name: Lunch, Label: "Lunch", DefaultTime: "12:30", Duration: 45, Share: 0.35},
+	{Name: Snack, Label: "Snack", DefaultTime: "16:00", Duration: 15, Share: 0.1},
+	{Name: Dinner, Label: "Dinner", DefaultTime: "19:00", Duration: 60, Share: 0.3},
+}
+
+// DefaultMealSlot returns the default slot with the name.
+func DefaultMealSlot(name MealType) (MealSlot, bool) {
+	for _, slot := range DefaultMealSlots {
+		if slot.Name == name {
+			return slot, true
+		}
+	}
+	return MealSlot{}, false
+}
+
+var mealSlotName = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+func (s MealSlot) Validate() error {
+	var errs ValidationErrors
+
+	if s.Name == "" {
+		errs.Add("name", "is required")
+	} else if len(s.Name) > 50 || !mealSlotName.MatchString(string(s.Name)) {
+		errs.Add("name", "must be at most 50 lower case letters and digits separated by underscores")
+	}
+	if len(s.Label) > 100 {
+		errs.Add("label", "must be at most 100 characters")
+	}
+	if _, err := s.timeOfDay(); err != nil {
+		errs.Add("default_time", "must be a time of day as HH:MM")
+	}
+	if s.Duration < 0 {
+		errs.Add("duration", "must not be negative")
+	}
+	if s.Share <= 0 || s.Share > 1 {
+		errs.Add("share", "must be greater than 0 and at most 1")
+	}
+
+	return errs.Err()
+}
+
+// At returns the slot's default time on the day of day. Slots without a
+// default time are at midnight.
+func (s MealSlot) At(day time.Time) time.Time {
+	offset, _ := s.timeOfDay()
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location()).Add(offset)
+}
+
+// timeOfDay returns DefaultTime as the time since midnight.
+func (s MealSlot) timeOfDay() (time.Duration, error) {
+	if s.DefaultTime == "" {
+		return 0, nil
+	}
+	t, err := time.Parse("15:04", s.DefaultTime)
+	if err != nil {
+		return 0, fmt.Errorf("invalid default time %q", s.DefaultTime)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// RecipeMealSlot links a recipe to a meal slot it can be planned for.
+type RecipeMealSlot struct {
+	RecipeID   uint `gorm:"primary_key;auto_increment:false"`
+	MealSlotID uint `gorm:"primary_key;auto_increment:false;index"`
+}
+
+// MealTypes is a list of meal types stored as JSON.
+type MealTypes []MealType
+
+func (m MealTypes) Value() (driver.Value, error) {
+	return jsonValue(m)
+}
+
+func (m *MealTypes) Scan(value interface{}) error {
+	return scanJSON(value, m)
+}
+
+// Contains reports whether the list has the meal type.
+func (m MealTypes) Contains(mealType MealType) bool {
+	for _, own := range m {
+		if own == mealType {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeMealTypes returns the meal types trimmed and in lower case,
+// without empty ones and duplicates.
+func normalizeMealTypes(mealTypes MealTypes) MealTypes {
+	if mealTypes == nil {
+		return nil
+	}
+	normalized := MealTypes{}
+	for _, mealType := range mealTypes {
+		mealType = MealType(strings.ToLower(strings.TrimSpace(string(mealType))))
+		if mealType != "" && !normalized.Contains(mealType) {
+			normalized = append(normalized, mealType)
+		}
+	}
+	return normalized
+}
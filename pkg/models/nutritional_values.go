@@ -1,10 +1,150 @@
 package models
 
+import "math"
+
+// NutritionalValues are amounts of nutrients, in the units given by
+// Nutrients: energy in kcal, macronutrients in g and micronutrients in mg
+// or µg.
 type NutritionalValues struct {
-	Calories float64 `json:"calories"`
-	Protein  float64 `json:"protein"`
-	Fat      float64 `json:"fat"`
-	Carbs    float64 `json:"carbs"`
-	Fiber    float64 `json:"fiber"`
-	Sugar    float64 `json:"sugar"`
+	Calories     float64 `json:"calories"`
+	Protein      float64 `json:"protein"`
+	Fat          float64 `json:"fat"`
+	Carbs        float64 `json:"carbs"`
+	Fiber        float64 `json:"fiber"`
+	Sugar        float64 `json:"sugar"`
+	SaturatedFat float64 `json:"saturated_fat"`
+	Cholesterol  float64 `json:"cholesterol"`
+	Sodium       float64 `json:"sodium"`
+	Potassium    float64 `json:"potassium"`
+	Calcium      float64 `json:"calcium"`
+	Iron         float64 `json:"iron"`
+	VitaminA     float64 `json:"vitamin_a"`
+	VitaminC     float64 `json:"vitamin_c"`
+	VitaminD     float64 `json:"vitamin_d"`
+}
+
+// NutrientInfo describes a field of NutritionalValues. DailyValue is the
+// reference daily intake used for percentages on nutrition labels.
+type NutrientInfo struct {
+	Name       string  `json:"name"`
+	Label      string  `json:"label"`
+	Unit       string  `json:"unit"`
+	DailyValue float64 `json:"daily_value"`
+}
+
+// Nutrients describes the fields of NutritionalValues in the order returned
+// by Fields. Daily values are the FDA's for adults.
+var Nutrients = []NutrientInfo{
+	{Name: "calories", Label: "Calories", Unit: "kcal", DailyValue: 2000},
+	{Name: "protein", Label: "Protein", Unit: "g", DailyValue: 50},
+	{Name: "fat", Label: "Total Fat", Unit: "g", DailyValue: 78},
+	{Name: "carbs", Label: "Total Carbohydrate", Unit: "g", DailyValue: 275},
+	{Name: "fiber", Label: "Dietary Fiber", Unit: "g", DailyValue: 28},
+	{Name: "sugar", Label: "Total Sugars", Unit: "g", DailyValue: 50},
+	{Name: "saturated_fat", Label: "Saturated Fat", Unit: "g", DailyValue: 20},
+	{Name: "cholesterol", Label: "Cholesterol", Unit: "mg", DailyValue: 300},
+	{Name: "sodium", Label: "Sodium", Unit: "mg", DailyValue: 2300},
+	{Name: "potassium", Label: "Potassium", Unit: "mg", DailyValue: 4700},
+	{Name: "calcium", Label: "Calcium", Unit: "mg", DailyValue: 1300},
+	{Name: "iron", Label: "Iron", Unit: "mg", DailyValue: 18},
+	{Name: "vitamin_a", Label: "Vitamin A", Unit: "µg", DailyValue: 900},
+	{Name: "vitamin_c", Label: "Vitamin C", Unit: "mg", DailyValue: 90},
+	{Name: "vitamin_d", Label: "Vitamin D", Unit: "µg", DailyValue: 20},
+}
+
+// NutrientFieldNames lists the JSON names of NutritionalValues in the order
+// returned by Fields.
+var NutrientFieldNames = nutrientNames()
+
+func nutrientNames() []string {
+	names := make([]string, len(Nutrients))
+	for i, nutrient := range Nutrients {
+		names[i] = nutrient.Name
+	}
+	return names
+}
+
+// NutrientByName returns the metadata of the nutrient with the JSON name.
+func NutrientByName(name string) (NutrientInfo, bool) {
+	for _, nutrient := range Nutrients {
+		if nutrient.Name == name {
+			return nutrient, true
+		}
+	}
+	return NutrientInfo{}, false
+}
+
+func (n NutritionalValues) Fields() []float64 {
+	fields := n.pointers()
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		values[i] = *field
+	}
+	return values
+}
+
+// pointers returns pointers to n's fields in the order of Nutrients.
+func (n *NutritionalValues) pointers() []*float64 {
+	return []*float64{
+		&n.Calories, &n.Protein, &n.Fat, &n.Carbs, &n.Fiber, &n.Sugar,
+		&n.SaturatedFat, &n.Cholesterol, &n.Sodium, &n.Potassium, &n.Calcium, &n.Iron,
+		&n.VitaminA, &n.VitaminC, &n.VitaminD,
+	}
+}
+
+// combine returns the result of fn applied to every field of n and other.
+func (n NutritionalValues) combine(other NutritionalValues, fn func(a float64, b float64) float64) NutritionalValues {
+	result := n
+	fields, others := result.pointers(), other.pointers()
+	for i, field := range fields {
+		*field = fn(*field, *others[i])
+	}
+	return result
+}
+
+func (n NutritionalValues) Add(other NutritionalValues) NutritionalValues {
+	return n.combine(other, func(a float64, b float64) float64 { return a + b })
+}
+
+// WithDefaults returns n with every zero value taken from defaults.
+func (n NutritionalValues) WithDefaults(defaults NutritionalValues) NutritionalValues {
+	return n.combine(defaults, func(value float64, fallback float64) float64 {
+		if value == 0 {
+			return fallback
+		}
+		return value
+	})
+}
+
+func (n NutritionalValues) Sub(other NutritionalValues) NutritionalValues {
+	return n.Add(other.Scale(-1))
+}
+
+func (n NutritionalValues) Scale(factor float64) NutritionalValues {
+	return n.combine(n, func(a float64, _ float64) float64 { return a * factor })
+}
+
+// ClampMin returns n with every value below floor raised to floor.
+func (n NutritionalValues) ClampMin(floor float64) NutritionalValues {
+	return n.combine(n, func(a float64, _ float64) float64 { return math.Max(a, floor) })
+}
+
+// Min returns the lower of n's and other's value for every nutrient.
+func (n NutritionalValues) Min(other NutritionalValues) NutritionalValues {
+	return n.combine(other, math.Min)
+}
+
+// Max returns the higher of n's and other's value for every nutrient.
+func (n NutritionalValues) Max(other NutritionalValues) NutritionalValues {
+	return n.combine(other, math.Max)
+}
+
+// PercentDailyValues returns every value as a percentage of its nutrient's
+// daily value.
+func (n NutritionalValues) PercentDailyValues() NutritionalValues {
+	result := n
+	for i, field := range result.pointers() {
+		*field = *field / Nutrients[i].DailyValue * 100
+	}
+	return result
 }
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type PlanningJobStatus string
+
+const (
+	PlanningJobQueued      PlanningJobStatus = "queued"
+	PlanningJobRunning     PlanningJobStatus = "running"
+	PlanningJobSucceeded   PlanningJobStatus = "succeeded"
+	PlanningJobFailed      PlanningJobStatus = "failed"
+	PlanningJobCancelled   PlanningJobStatus = "cancelled"
+	PlanningJobInterrupted PlanningJobStatus = "interrupted" // the server stopped while the job was running
+)
+
+// IsFinished reports whether a job in this status will not change again.
+func (s PlanningJobStatus) IsFinished() bool {
+	return s != PlanningJobQueued && s != PlanningJobRunning
+}
+
+// PlanningJob tracks a planning request that runs in the background.
+type PlanningJob struct {
+	gorm.Model
+	UserID     uint              `json:"user_id"`
+	Status     PlanningJobStatus `json:"status" gorm:"type:varchar(16);not null;index"`
+	Progress   float64           `json:"progress"`               // fraction of the plan completed, between 0 and 1
+	Request    string            `json:"-" gorm:"size:65535"`    // JSON encoded planner request
+	Result     string            `json:"-" gorm:"size:16777215"` // JSON encoded meal plans once the job succeeded
+	Error      string            `json:"error,omitempty" gorm:"size:65535"`
+	StartedAt  *time.Time        `json:"started_at"`
+	FinishedAt *time.Time        `json:"finished_at"`
+}
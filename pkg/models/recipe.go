@@ -1,11 +1,19 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jinzhu/gorm"
 )
 
+// Recipe is one version of a recipe; every update adds a version. Steps,
+// Equipment and Tags are stored with the version. Times are in minutes: the
+// preparation time, the cooking time and the total time from start to
+// table, which may include waiting. Tags are free-form and kept lower case.
+// MealSlots are the names of the meal slots the recipe can be planned for;
+// they are stored in a join table.
 type Recipe struct {
 	gorm.Model
 	ID                uint                `gorm:"primary_key"`
@@ -13,12 +21,135 @@ type Recipe struct {
 	Description       string              `json:"description" gorm:"type:text;not null"`
 	Servings          int                 `json:"servings" gorm:"not null"`
 	PreparationTime   int                 `json:"preparation_time" gorm:"not null"`
+	CookTime          int                 `json:"cook_time"`
+	TotalTime         int                 `json:"total_time"`
 	RecipeIngredients *[]RecipeIngredient `json:"ingredients" gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	IsBreakfast       bool                `json:"is_breakfast" gorm:"type:bool"`
-	IsLunch           bool                `json:"is_lunch" gorm:"type:bool"`
-	IsDinner          bool                `json:"is_dinner" gorm:"type:bool"`
-	IsSnack           bool                `json:"is_snack" gorm:"type:bool"`
-	Version           int                 `gorm:"not null"`
+	Steps             RecipeSteps         `json:"steps" gorm:"type:text"`
+	Equipment         StringList          `json:"equipment" gorm:"type:text"`
+	Cuisine           string              `json:"cuisine" gorm:"type:varchar(50);index"`
+	Tags              StringList          `json:"tags" gorm:"type:text"`
+	MealSlots         MealTypes           `json:"meal_slots" gorm:"-"`
+	Version           int                 `json:"version" gorm:"not null"`
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
 }
+
+// Complete fills what can be derived from the recipe's steps: the total
+// time, when it isn't set, and the equipment the steps use but the recipe
+// doesn't list. It also normalizes the cuisine and tags.
+func (r *Recipe) Complete() {
+	r.Cuisine = strings.TrimSpace(r.Cuisine)
+	r.Tags = NormalizeTags(r.Tags)
+	r.MealSlots = normalizeMealTypes(r.MealSlots)
+
+	if r.TotalTime == 0 {
+		active, passive := r.Steps.Times()
+		r.TotalTime = r.PreparationTime + r.CookTime
+		if steps := active + passive; steps > r.TotalTime {
+			r.TotalTime = steps
+		}
+	}
+
+	listed := make(map[string]bool)
+	for _, equipment := range r.Equipment {
+		listed[strings.ToLower(strings.TrimSpace(equipment))] = true
+	}
+	for _, step := range r.Steps {
+		for _, equipment := range step.Equipment {
+			key := strings.ToLower(strings.TrimSpace(equipment))
+			if key != "" && !listed[key] {
+				listed[key] = true
+				r.Equipment = append(r.Equipment, equipment)
+			}
+		}
+	}
+}
+
+// Validate checks the recipe's times, equipment and steps.
+func (r Recipe) Validate() error {
+	var errs ValidationErrors
+
+	if r.Servings < 0 {
+		errs.Add("servings", "must not be negative")
+	}
+	if r.PreparationTime < 0 {
+		errs.Add("preparation_time", "must not be negative")
+	}
+	if r.CookTime < 0 {
+		errs.Add("cook_time", "must not be negative")
+	}
+	if r.TotalTime < 0 {
+		errs.Add("total_time", "must not be negative")
+	} else if r.TotalTime > 0 && r.TotalTime < r.PreparationTime+r.CookTime {
+		errs.Add("total_time", "must not be less than the preparation and cooking time")
+	}
+	for i, equipment := range r.Equipment {
+		if strings.TrimSpace(equipment) == "" {
+			errs.Add(fmt.Sprintf("equipment[%d]", i), "must not be empty")
+		}
+	}
+	if len(strings.TrimSpace(r.Cuisine)) > maxLabelLength {
+		errs.Add("cuisine", fmt.Sprintf("must be at most %d characters", maxLabelLength))
+	}
+	for i, tag := range r.Tags {
+		field := fmt.Sprintf("tags[%d]", i)
+		switch tag = strings.TrimSpace(tag); {
+		case tag == "":
+			errs.Add(field, "must not be empty")
+		case len(tag) > maxLabelLength:
+			errs.Add(field, fmt.Sprintf("must be at most %d characters", maxLabelLength))
+		}
+	}
+	for i, mealType := range r.MealSlots {
+		if !mealSlotName.MatchString(strings.ToLower(strings.TrimSpace(string(mealType)))) {
+			errs.Add(fmt.Sprintf("meal_slots[%d]", i), "must be the name of a meal slot")
+		}
+	}
+
+	ingredientIDs := make(map[uint]bool)
+	if r.RecipeIngredients != nil {
+		for _, recipeIngredient := range *r.RecipeIngredients {
+			ingredientIDs[recipeIngredient.IngredientID] = true
+		}
+	}
+	r.Steps.validate(&errs, ingredientIDs)
+
+	return errs.Err()
+}
+
+// maxLabelLength is the longest cuisine or tag.
+const maxLabelLength = 50
+
+// NormalizeTags returns the tags trimmed and in lower case, without empty
+// tags and duplicates, in their original order.
+func NormalizeTags(tags []string) StringList {
+	if tags == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	normalized := StringList{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// HasMealSlot reports whether the recipe can be planned for the meal slot.
+func (r Recipe) HasMealSlot(mealType MealType) bool {
+	return r.MealSlots.Contains(mealType)
+}
+
+// HasTag reports whether the recipe has the tag, ignoring case.
+func (r Recipe) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, own := range r.Tags {
+		if strings.ToLower(own) == tag {
+			return true
+		}
+	}
+	return false
+}
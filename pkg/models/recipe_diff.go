@@ -0,0 +1,124 @@
+package models
+
+import "reflect"
+
+// Ways an ingredient can differ between two recipe versions.
+const (
+	IngredientAdded   = "added"
+	IngredientRemoved = "removed"
+	IngredientChanged = "changed"
+)
+
+// FieldChange is a recipe field that differs between two versions, by its
+// JSON name.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// IngredientAmount is how much of an ingredient a recipe version uses.
+type IngredientAmount struct {
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+}
+
+// IngredientChange is an ingredient added, removed or used in a different
+// amount between two versions. From is nil for added ingredients and To for
+// removed ones.
+type IngredientChange struct {
+	IngredientID uint              `json:"ingredient_id"`
+	Name         string            `json:"name"`
+	Change       string            `json:"change"`
+	From         *IngredientAmount `json:"from,omitempty"`
+	To           *IngredientAmount `json:"to,omitempty"`
+}
+
+// RecipeDiff is what changed from one recipe version to another.
+type RecipeDiff struct {
+	RecipeID    uint               `json:"recipe_id"`
+	From        int                `json:"from"`
+	To          int                `json:"to"`
+	Fields      []FieldChange      `json:"fields"`
+	Ingredients []IngredientChange `json:"ingredients"`
+}
+
+// DiffRecipes compares two versions of a recipe. Ingredients are matched by
+// ingredient ID.
+func DiffRecipes(from Recipe, to Recipe) RecipeDiff {
+	diff := RecipeDiff{
+		RecipeID:    to.ID,
+		From:        from.Version,
+		To:          to.Version,
+		Fields:      []FieldChange{},
+		Ingredients: []IngredientChange{},
+	}
+
+	fields := []FieldChange{
+		{Field: "title", From: from.Title, To: to.Title},
+		{Field: "description", From: from.Description, To: to.Description},
+		{Field: "servings", From: from.Servings, To: to.Servings},
+		{Field: "preparation_time", From: from.PreparationTime, To: to.PreparationTime},
+		{Field: "cook_time", From: from.CookTime, To: to.CookTime},
+		{Field: "total_time", From: from.TotalTime, To: to.TotalTime},
+		{Field: "meal_slots", From: from.MealSlots, To: to.MealSlots},
+		{Field: "steps", From: from.Steps, To: to.Steps},
+		{Field: "equipment", From: from.Equipment, To: to.Equipment},
+		{Field: "cuisine", From: from.Cuisine, To: to.Cuisine},
+		{Field: "tags", From: from.Tags, To: to.Tags},
+	}
+	for _, field := range fields {
+		if !sameValue(field.From, field.To) {
+			diff.Fields = append(diff.Fields, field)
+		}
+	}
+
+	toAmounts := make(map[uint]RecipeIngredient)
+	for _, recipeIngredient := range recipeIngredients(to) {
+		toAmounts[recipeIngredient.IngredientID] = recipeIngredient
+	}
+	fromIDs := make(map[uint]bool)
+	for _, old := range recipeIngredients(from) {
+		fromIDs[old.IngredientID] = true
+		change := IngredientChange{IngredientID: old.IngredientID, Name: old.Ingredient.Name, From: amount(old)}
+		current, ok := toAmounts[old.IngredientID]
+		switch {
+		case !ok:
+			change.Change = IngredientRemoved
+		case current.Quantity != old.Quantity || current.Unit != old.Unit:
+			change.Change = IngredientChanged
+			change.To = amount(current)
+		default:
+			continue
+		}
+		diff.Ingredients = append(diff.Ingredients, change)
+	}
+	for _, added := range recipeIngredients(to) {
+		if !fromIDs[added.IngredientID] {
+			diff.Ingredients = append(diff.Ingredients, IngredientChange{IngredientID: added.IngredientID, Name: added.Ingredient.Name, Change: IngredientAdded, To: amount(added)})
+		}
+	}
+
+	return diff
+}
+
+func recipeIngredients(recipe Recipe) []RecipeIngredient {
+	if recipe.RecipeIngredients == nil {
+		return nil
+	}
+	return *recipe.RecipeIngredients
+}
+
+func amount(recipeIngredient RecipeIngredient) *IngredientAmount {
+	return &IngredientAmount{Quantity: recipeIngredient.Quantity, Unit: recipeIngredient.Unit}
+}
+
+// sameValue reports whether a and b are deeply equal, counting nil and
+// empty lists as equal.
+func sameValue(a interface{}, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() == reflect.Slice && vb.Kind() == reflect.Slice && va.Len() == 0 && vb.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
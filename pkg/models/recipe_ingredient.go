@@ -9,7 +9,7 @@ import (
 type RecipeIngredient struct {
 	gorm.Model
 	RecipeID     uint       `json:"recipe_id" gorm:"not null"`
-	Ingredient   Ingredient `gorm:"foreignKey:IngredientID"`
+	Ingredient   Ingredient `gorm:"foreignKey:IngredientID;association_autoupdate:false;association_autocreate:false"` // saved through the ingredient catalog
 	IngredientID uint       `json:"ingredient_id" gorm:"not null"`
 	Quantity     float64    `json:"quantity" gorm:"not null"`
 	Unit         string     `json:"unit" gorm:"not null"`
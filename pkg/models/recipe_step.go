@@ -0,0 +1,89 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// TemperatureUnits are the units a step's temperature can be given in.
+var TemperatureUnits = []string{"c", "f", "gas-mark"}
+
+// Temperature is an oven or cooking temperature.
+type Temperature struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"` // c, f or gas-mark
+}
+
+// RecipeStep is one instruction of a recipe. Times are in minutes: active
+// time needs the cook's attention, passive time is waiting, for example for
+// dough to rise. IngredientIDs are the recipe's ingredients the step uses.
+type RecipeStep struct {
+	Text          string       `json:"text"`
+	ActiveTime    int          `json:"active_time"`
+	PassiveTime   int          `json:"passive_time"`
+	Temperature   *Temperature `json:"temperature,omitempty"`
+	Equipment     []string     `json:"equipment,omitempty"`
+	IngredientIDs []uint       `json:"ingredient_ids,omitempty"`
+}
+
+// RecipeSteps are a recipe's steps in order, stored as JSON with every
+// version of the recipe.
+type RecipeSteps []RecipeStep
+
+func (s RecipeSteps) Value() (driver.Value, error) {
+	return jsonValue(s)
+}
+
+func (s *RecipeSteps) Scan(value interface{}) error {
+	return scanJSON(value, s)
+}
+
+// Times returns the active and passive time of all steps.
+func (s RecipeSteps) Times() (int, int) {
+	active, passive := 0, 0
+	for _, step := range s {
+		active += step.ActiveTime
+		passive += step.PassiveTime
+	}
+	return active, passive
+}
+
+// validate adds the steps' invalid fields to errs. ingredientIDs are the
+// recipe's ingredients steps may use.
+func (s RecipeSteps) validate(errs *ValidationErrors, ingredientIDs map[uint]bool) {
+	for i, step := range s {
+		field := fmt.Sprintf("steps[%d]", i)
+		if strings.TrimSpace(step.Text) == "" {
+			errs.Add(field+".text", "is required")
+		}
+		if step.ActiveTime < 0 {
+			errs.Add(field+".active_time", "must not be negative")
+		}
+		if step.PassiveTime < 0 {
+			errs.Add(field+".passive_time", "must not be negative")
+		}
+		if step.Temperature != nil && !isTemperatureUnit(step.Temperature.Unit) {
+			errs.Add(field+".temperature.unit", "must be one of c, f or gas-mark")
+		}
+		for j, equipment := range step.Equipment {
+			if strings.TrimSpace(equipment) == "" {
+				errs.Add(fmt.Sprintf("%s.equipment[%d]", field, j), "must not be empty")
+			}
+		}
+		for j, id := range step.IngredientIDs {
+			if !ingredientIDs[id] {
+				errs.Add(fmt.Sprintf("%s.ingredient_ids[%d]", field, j), "is not an ingredient of the recipe")
+			}
+		}
+	}
+}
+
+func isTemperatureUnit(unit string) bool {
+	for _, u := range TemperatureUnits {
+		if u == unit {
+			return true
+		}
+	}
+	return false
+}
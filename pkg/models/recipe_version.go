@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+
+	"github.com/jinzhu/gorm"
+)
+
+// RecipeVersion is a recipe as it was saved at one version. The recipes
+// table only holds the latest version of each recipe; every version,
+// including the latest, is kept here so that meal plans resolve to the
+// version they were planned with.
+type RecipeVersion struct {
+	gorm.Model
+	RecipeID uint           `json:"recipe_id" gorm:"not null;unique_index:idx_recipe_versions_recipe_version"`
+	Version  int            `json:"version" gorm:"not null;unique_index:idx_recipe_versions_recipe_version"`
+	Title    string         `json:"title" gorm:"type:varchar(100);not null"`
+	Recipe   RecipeSnapshot `json:"-" gorm:"type:text"`
+}
+
+// NewRecipeVersion returns the version of the recipe as it is. Ingredients
+// are part of the catalog rather than the recipe, so only their ID and name
+// are kept with the version.
+func NewRecipeVersion(recipe Recipe) RecipeVersion {
+	if recipe.RecipeIngredients != nil {
+		ingredients := make([]RecipeIngredient, len(*recipe.RecipeIngredients))
+		for i, recipeIngredient := range *recipe.RecipeIngredients {
+			ingredients[i] = RecipeIngredient{
+				IngredientID: recipeIngredient.IngredientID,
+				Ingredient:   Ingredient{ID: recipeIngredient.IngredientID, Name: recipeIngredient.Ingredient.Name},
+				Quantity:     recipeIngredient.Quantity,
+				Unit:         recipeIngredient.Unit,
+			}
+		}
+		recipe.RecipeIngredients = &ingredients
+	}
+
+	return RecipeVersion{
+		RecipeID: recipe.ID,
+		Version:  recipe.Version,
+		Title:    recipe.Title,
+		Recipe:   RecipeSnapshot(recipe),
+	}
+}
+
+// RecipeSnapshot is a recipe stored as JSON.
+type RecipeSnapshot Recipe
+
+func (s RecipeSnapshot) Value() (driver.Value, error) {
+	return jsonValue(Recipe(s))
+}
+
+func (s *RecipeSnapshot) Scan(value interface{}) error {
+	return scanJSON(value, (*Recipe)(s))
+}
@@ -0,0 +1,34 @@
+package models
+
+import "strings"
+
+// ValidationError describes a single invalid field in a request.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors collects every invalid field so clients can report them
+// all at once.
+type ValidationErrors []ValidationError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, e := range v {
+		messages[i] = e.Field + ": " + e.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (v *ValidationErrors) Add(field string, message string) {
+	*v = append(*v, ValidationError{Field: field, Message: message})
+}
+
+// Err returns nil when no errors were collected, so callers can return it
+// directly as an error.
+func (v ValidationErrors) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
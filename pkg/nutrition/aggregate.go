@@ -0,0 +1,180 @@
+package nutrition
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/units"
+)
+
+// IngredientQuantity returns the recipe ingredient's quantity in the
+// converter's default unit for its unit type, which is the basis of the
+// ingredient's price. Quantities that can't be converted are
+// returned as they are.
+func IngredientQuantity(recipeIngredient models.RecipeIngredient, converter units.UnitConverterInterface) float64 {
+	ingredient := recipeIngredient.Ingredient
+	quantity := recipeIngredient.Quantity
+	if converter == nil || (ingredient.UnitType != "mass" && ingredient.UnitType != "volume") {
+		return quantity
+	}
+
+	defaultUnit := converter.GetDefaultUnit(ingredient.UnitType)
+	converted, err := units.ConvertQuantity(converter, units.QuantityFromFloat(quantity), recipeIngredient.Unit, defaultUnit, ingredient.UnitType)
+	if err != nil {
+		return quantity
+	}
+	return converted.Float64()
+}
+
+// ReferenceQuantities returns how many of the ingredient's reference
+// quantities, the Quantity of QuantityUnit its nutrients are given for, the
+// recipe ingredient uses. Ingredients without a reference quantity give
+// their nutrients per the converter's default unit. A quantity that can't be
+// converted is an error, and it is then counted as it is.
+func ReferenceQuantities(recipeIngredient models.RecipeIngredient, converter units.UnitConverterInterface) (float64, error) {
+	ingredient := recipeIngredient.Ingredient
+	quantity := recipeIngredient.Quantity
+	if ingredient.Quantity <= 0 || ingredient.QuantityUnit == "" {
+		if converter == nil || (ingredient.UnitType != "mass" && ingredient.UnitType != "volume") {
+			return quantity, nil
+		}
+		converted, err := units.ConvertQuantity(converter, units.QuantityFromFloat(quantity), recipeIngredient.Unit, converter.GetDefaultUnit(ingredient.UnitType), ingredient.UnitType)
+		if err != nil {
+			return quantity, err
+		}
+		return converted.Float64(), nil
+	}
+
+	if recipeIngredient.Unit == ingredient.QuantityUnit {
+		return quantity / ingredient.Quantity, nil
+	}
+	if converter == nil {
+		return quantity / ingredient.Quantity, fmt.Errorf("can't convert %s to %s", recipeIngredient.Unit, ingredient.QuantityUnit)
+	}
+	converted, err := units.ConvertQuantity(converter, units.QuantityFromFloat(quantity), recipeIngredient.Unit, ingredient.QuantityUnit, ingredient.UnitType)
+	if err != nil {
+		return quantity / ingredient.Quantity, err
+	}
+	return converted.Float64() / ingredient.Quantity, nil
+}
+
+// IngredientNutrients returns the nutrients the recipe ingredient adds to
+// its recipe. A quantity that can't be converted to the ingredient's
+// reference unit adds none, and the error says why.
+func IngredientNutrients(recipeIngredient models.RecipeIngredient, converter units.UnitConverterInterface) (models.NutritionalValues, error) {
+	quantities, err := ReferenceQuantities(recipeIngredient, converter)
+	if err != nil {
+		return models.NutritionalValues{}, err
+	}
+	return recipeIngredient.Ingredient.Nutrients.Scale(quantities), nil
+}
+
+// UnconvertedError is returned with nutrient totals that leave out
+// ingredients whose quantities can't be converted, so the totals are lower
+// than the real ones. Ingredients are named in recipe order.
+type UnconvertedError struct {
+	Ingredients []string
+}
+
+func (e *UnconvertedError) Error() string {
+	return fmt.Sprintf("quantities of %s can't be converted", strings.Join(e.Ingredients, ", "))
+}
+
+// RecipeNutrients returns the nutrients of all servings of the recipe. The
+// total leaves out ingredients whose quantities can't be converted, and an
+// *UnconvertedError names them.
+func RecipeNutrients(recipe models.Recipe, converter units.UnitConverterInterface) (models.NutritionalValues, error) {
+	var total models.NutritionalValues
+	if recipe.RecipeIngredients == nil {
+		return total, nil
+	}
+	var unconverted []string
+	for _, recipeIngredient := range *recipe.RecipeIngredients {
+		nutrients, err := IngredientNutrients(recipeIngredient, converter)
+		if err != nil {
+			unconverted = append(unconverted, recipeIngredient.Ingredient.Name)
+		}
+		total = total.Add(nutrients)
+	}
+	if len(unconverted) > 0 {
+		return total, &UnconvertedError{Ingredients: unconverted}
+	}
+	return total, nil
+}
+
+// ServingNutrients returns the nutrients of one serving of the recipe, with
+// the same error as RecipeNutrients.
+func ServingNutrients(recipe models.Recipe, converter units.UnitConverterInterface) (models.NutritionalValues, error) {
+	total, err := RecipeNutrients(recipe, converter)
+	if recipe.Servings <= 0 {
+		return total, err
+	}
+	return total.Scale(1 / float64(recipe.Servings)), err
+}
+
+// MealNutrients returns the nutrients of a planned meal at its servings,
+// with the same error as RecipeNutrients. Meals without a recipe have none.
+func MealNutrients(mealPlan models.MealPlan, converter units.UnitConverterInterface) (models.NutritionalValues, error) {
+	if mealPlan.Recipe == nil {
+		return models.NutritionalValues{}, nil
+	}
+	serving, err := ServingNutrients(*mealPlan.Recipe, converter)
+	return serving.Scale(float64(mealPlan.Servings)), err
+}
+
+// DayNutrition is what the meals of a day add up to. Complete is false when
+// a meal's ingredient quantities can't be converted, so the values are
+// lower than the meals' real nutrients.
+type DayNutrition struct {
+	Date               string                   `json:"date"` // YYYY-MM-DD in the meals' time zone
+	Meals              int                      `json:"meals"`
+	Nutrients          models.NutritionalValues `json:"nutrients"`
+	PercentDailyValues models.NutritionalValues `json:"percent_daily_values"`
+	Complete           bool                     `json:"complete"`
+}
+
+// PlanNutrition is what a plan's meals add up to, per day and in total.
+// DailyAverage is averaged over the days with meals. Complete is false
+// when any day is incomplete.
+type PlanNutrition struct {
+	Days         []DayNutrition           `json:"days"`
+	Total        models.NutritionalValues `json:"total"`
+	DailyAverage models.NutritionalValues `json:"daily_average"`
+	Complete     bool                     `json:"complete"`
+}
+
+// Summarize adds up the meals' nutrients per day and for the whole plan.
+func Summarize(mealPlans []models.MealPlan, converter units.UnitConverterInterface) PlanNutrition {
+	byDate := make(map[string]*DayNutrition)
+	for _, mealPlan := range mealPlans {
+		date := mealPlan.MealTime.Format("2006-01-02")
+		day, ok := byDate[date]
+		if !ok {
+			day = &DayNutrition{Date: date, Complete: true}
+			byDate[date] = day
+		}
+		day.Meals++
+		nutrients, err := MealNutrients(mealPlan, converter)
+		if err != nil {
+			day.Complete = false
+		}
+		day.Nutrients = day.Nutrients.Add(nutrients)
+	}
+
+	summary := PlanNutrition{Days: []DayNutrition{}, Complete: true}
+	for _, day := range byDate {
+		day.PercentDailyValues = day.Nutrients.PercentDailyValues()
+		summary.Days = append(summary.Days, *day)
+		summary.Total = summary.Total.Add(day.Nutrients)
+		summary.Complete = summary.Complete && day.Complete
+	}
+	sort.Slice(summary.Days, func(i, j int) bool {
+		return summary.Days[i].Date < summary.Days[j].Date
+	})
+	if len(summary.Days) > 0 {
+		summary.DailyAverage = summary.Total.Scale(1 / float64(len(summary.Days)))
+	}
+	return summary
+}
@@ -0,0 +1,122 @@
+package nutrition_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/nutrition"
+	"github.com/cvele/recipe/pkg/units"
+	"github.com/stretchr/testify/assert"
+)
+
+func soup() *models.Recipe {
+	return &models.Recipe{
+		ID:       1,
+		Servings: 4,
+		RecipeIngredients: &[]models.RecipeIngredient{
+			{
+				Ingredient: models.Ingredient{
+					Name:      "Lentils",
+					UnitType:  "mass",
+					Nutrients: models.NutritionalValues{Calories: 3.5, Protein: 0.25, Iron: 0.075},
+				},
+				Quantity: 0.4,
+				Unit:     "kg",
+			},
+			{
+				Ingredient: models.Ingredient{
+					Name:      "Stock",
+					UnitType:  "volume",
+					Nutrients: models.NutritionalValues{Calories: 0.1, Sodium: 4},
+				},
+				Quantity: 1,
+				Unit:     "l",
+			},
+		},
+	}
+}
+
+func TestRecipeNutrients(t *testing.T) {
+	converter := units.NewUnitConverter("g", "ml")
+
+	total, err := nutrition.RecipeNutrients(*soup(), converter)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1500, total.Calories, 1e-9)
+	assert.InDelta(t, 100, total.Protein, 1e-9)
+	assert.InDelta(t, 30, total.Iron, 1e-9)
+	assert.InDelta(t, 4000, total.Sodium, 1e-9)
+
+	serving, err := nutrition.ServingNutrients(*soup(), converter)
+	assert.NoError(t, err)
+	assert.InDelta(t, 375, serving.Calories, 1e-9)
+	assert.InDelta(t, 1000, serving.Sodium, 1e-9)
+}
+
+func TestRecipeNutrients_Unconverted(t *testing.T) {
+	converter := units.NewUnitConverter("g", "ml")
+	recipe := soup()
+	(*recipe.RecipeIngredients)[0].Quantity = 2
+	(*recipe.RecipeIngredients)[0].Unit = "cups"
+
+	// The lentils are left out rather than counted as 2 g
+	total, err := nutrition.RecipeNutrients(*recipe, converter)
+	var unconverted *nutrition.UnconvertedError
+	if assert.ErrorAs(t, err, &unconverted) {
+		assert.Equal(t, []string{"Lentils"}, unconverted.Ingredients)
+	}
+	assert.InDelta(t, 100, total.Calories, 1e-9)
+	assert.InDelta(t, 4000, total.Sodium, 1e-9)
+
+	_, err = nutrition.ServingNutrients(*recipe, converter)
+	assert.ErrorAs(t, err, &unconverted)
+
+	summary := nutrition.Summarize([]models.MealPlan{
+		{Recipe: soup(), Servings: 1, MealTime: time.Date(2023, 6, 5, 12, 0, 0, 0, time.UTC)},
+		{Recipe: recipe, Servings: 1, MealTime: time.Date(2023, 6, 6, 12, 0, 0, 0, time.UTC)},
+	}, converter)
+	assert.True(t, summary.Days[0].Complete)
+	assert.False(t, summary.Days[1].Complete)
+	assert.False(t, summary.Complete)
+}
+
+func TestSummarize(t *testing.T) {
+	converter := units.NewUnitConverter("g", "ml")
+	monday := time.Date(2023, 6, 5, 12, 0, 0, 0, time.UTC)
+	mealPlans := []models.MealPlan{
+		{Recipe: soup(), Servings: 2, MealTime: monday.AddDate(0, 0, 1)},
+		{Recipe: soup(), Servings: 2, MealTime: monday},
+		{Recipe: soup(), Servings: 1, MealTime: monday.Add(6 * time.Hour)},
+	}
+
+	summary := nutrition.Summarize(mealPlans, converter)
+
+	assert.Len(t, summary.Days, 2)
+	assert.True(t, summary.Complete)
+	assert.Equal(t, "2023-06-05", summary.Days[0].Date)
+	assert.Equal(t, 2, summary.Days[0].Meals)
+	assert.InDelta(t, 1125, summary.Days[0].Nutrients.Calories, 1e-9)
+	assert.InDelta(t, 3000, summary.Days[0].Nutrients.Sodium, 1e-9)
+	assert.InDelta(t, 3000.0/2300*100, summary.Days[0].PercentDailyValues.Sodium, 1e-9)
+	assert.InDelta(t, 1875, summary.Total.Calories, 1e-9)
+	assert.InDelta(t, 937.5, summary.DailyAverage.Calories, 1e-9)
+}
+
+func TestReferenceQuantities(t *testing.T) {
+	converter := units.NewUnitConverter("g", "ml")
+	flour := models.Ingredient{Name: "Flour", UnitType: "mass", Quantity: 100, QuantityUnit: "g"}
+
+	quantities, err := nutrition.ReferenceQuantities(models.RecipeIngredient{Ingredient: flour, Quantity: 0.25, Unit: "kg"}, converter)
+	assert.NoError(t, err)
+	assert.InDelta(t, 2.5, quantities, 1e-9)
+
+	// Without a reference quantity nutrients are per default unit
+	flour.Quantity = 0
+	quantities, err = nutrition.ReferenceQuantities(models.RecipeIngredient{Ingredient: flour, Quantity: 0.25, Unit: "kg"}, converter)
+	assert.NoError(t, err)
+	assert.InDelta(t, 250, quantities, 1e-9)
+
+	flour.Quantity = 100
+	_, err = nutrition.ReferenceQuantities(models.RecipeIngredient{Ingredient: flour, Quantity: 2, Unit: "cups"}, converter)
+	assert.Error(t, err)
+}
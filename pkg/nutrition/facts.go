@@ -0,0 +1,95 @@
+package nutrition
+
+import (
+	"fmt"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/units"
+)
+
+// IngredientFacts is what one ingredient adds to a recipe. Missing explains
+// why the ingredient's nutrients are missing or unreliable.
+type IngredientFacts struct {
+	IngredientID uint                     `json:"ingredient_id"`
+	Name         string                   `json:"name"`
+	Quantity     float64                  `json:"quantity"`
+	Unit         string                   `json:"unit"`
+	Nutrients    models.NutritionalValues `json:"nutrients"`
+	Missing      string                   `json:"missing,omitempty"`
+}
+
+// Facts are a recipe's nutrition facts. PercentDailyValues are per
+// serving. Complete is false when an ingredient is missing data, so the
+// values are lower than the recipe's real nutrients.
+type Facts struct {
+	RecipeID           uint                     `json:"recipe_id"`
+	Version            int                      `json:"version"`
+	Title              string                   `json:"title"`
+	Servings           int                      `json:"servings"`
+	Total              models.NutritionalValues `json:"total"`
+	PerServing         models.NutritionalValues `json:"per_serving"`
+	PercentDailyValues models.NutritionalValues `json:"percent_daily_values"`
+	Ingredients        []IngredientFacts        `json:"ingredients"`
+	Complete           bool                     `json:"complete"`
+}
+
+// RecipeFacts calculates the recipe's nutrition facts from its ingredients'
+// reference quantities. Ingredients without nutrients and quantities that
+// can't be converted to their ingredient's reference unit are flagged and
+// not counted.
+func RecipeFacts(recipe models.Recipe, converter units.UnitConverterInterface) Facts {
+	facts := Facts{
+		RecipeID:    recipe.ID,
+		Version:     recipe.Version,
+		Title:       recipe.Title,
+		Servings:    recipe.Servings,
+		Ingredients: []IngredientFacts{},
+		Complete:    true,
+	}
+
+	if recipe.RecipeIngredients != nil {
+		for _, recipeIngredient := range *recipe.RecipeIngredients {
+			ingredient := recipeIngredient.Ingredient
+			ingredientFacts := IngredientFacts{
+				IngredientID: recipeIngredient.IngredientID,
+				Name:         ingredient.Name,
+				Quantity:     recipeIngredient.Quantity,
+				Unit:         recipeIngredient.Unit,
+			}
+
+			quantities, err := ReferenceQuantities(recipeIngredient, converter)
+			switch {
+			case ingredient.Nutrients == (models.NutritionalValues{}):
+				ingredientFacts.Missing = "the ingredient has no nutrient data"
+			case err != nil:
+				ingredientFacts.Missing = fmt.Sprintf("the quantity can't be converted to the ingredient's reference unit: %v", err)
+			default:
+				ingredientFacts.Nutrients = ingredient.Nutrients.Scale(quantities)
+			}
+			if ingredientFacts.Missing != "" {
+				facts.Complete = false
+			}
+
+			facts.Total = facts.Total.Add(ingredientFacts.Nutrients)
+			facts.Ingredients = append(facts.Ingredients, ingredientFacts)
+		}
+	}
+
+	facts.PerServing = facts.Total
+	if recipe.Servings > 0 {
+		facts.PerServing = facts.Total.Scale(1 / float64(recipe.Servings))
+	}
+	facts.PercentDailyValues = facts.PerServing.PercentDailyValues()
+	return facts
+}
+
+// Missing returns the ingredients that are missing data.
+func (f Facts) Missing() []IngredientFacts {
+	var missing []IngredientFacts
+	for _, ingredient := range f.Ingredients {
+		if ingredient.Missing != "" {
+			missing = append(missing, ingredient)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,198 @@
+// Package nutrition recommends daily nutrient intake from a person's profile
+// and adds up the nutrients of recipes and planned meals.
+package nutrition
+
+import (
+	"math"
+
+	"github.com/cvele/recipe/pkg/models"
+)
+
+// Energy per gram of each macronutrient, in kcal.
+const (
+	caloriesPerGramProtein = 4
+	caloriesPerGramFat     = 9
+	caloriesPerGramCarbs   = 4
+)
+
+// referenceCalories is the energy intake daily values are based on.
+var referenceCalories = dailyValue("calories")
+
+func dailyValue(name string) float64 {
+	nutrient, _ := models.NutrientByName(name)
+	return nutrient.DailyValue
+}
+
+// Intake is a daily or per-meal nutrient target with the limits around it.
+type Intake struct {
+	TargetNutrients models.NutritionalValues `json:"target_nutrients"`
+	MinNutrients    models.NutritionalValues `json:"min_nutrients"`
+	MaxNutrients    models.NutritionalValues `json:"max_nutrients"`
+}
+
+// Scale returns the intake multiplied by factor, for example a meal's share
+// of the day.
+func (i Intake) Scale(factor float64) Intake {
+	return Intake{
+		TargetNutrients: i.TargetNutrients.Scale(factor),
+		MinNutrients:    i.MinNutrients.Scale(factor),
+		MaxNutrients:    i.MaxNutrients.Scale(factor),
+	}
+}
+
+// activityFactors multiply the basal metabolic rate into the total daily
+// energy expenditure.
+var activityFactors = map[models.ActivityLevel]float64{
+	models.ActivitySedentary:  1.2,
+	models.ActivityLight:      1.375,
+	models.ActivityModerate:   1.55,
+	models.ActivityActive:     1.725,
+	models.ActivityVeryActive: 1.9,
+}
+
+// goalFactors adjust the energy expenditure to the energy target.
+var goalFactors = map[models.Goal]float64{
+	models.GoalLose:     0.8,
+	models.GoalMaintain: 1,
+	models.GoalGain:     1.1,
+}
+
+// BasalMetabolicRate returns the energy the person uses at rest, in kcal per
+// day. Adults use the Mifflin-St Jeor equation and people under 18 the
+// Schofield equations, which only need the weight. Without a sex, the
+// average of both is used. The profile must have what recommendations
+// need; see models.Profile.CanRecommend.
+func BasalMetabolicRate(p models.Profile) float64 {
+	if p.Age < models.AdultAge {
+		female, male := schofield(p.Age, p.Weight)
+		return bySex(p.Sex, female, male)
+	}
+
+	base := 10*p.Weight + 6.25*p.Height - 5*float64(p.Age)
+	return bySex(p.Sex, base-161, base+5)
+}
+
+// schofield returns the basal metabolic rate of girls and boys of the age
+// and weight.
+func schofield(age int, weight float64) (float64, float64) {
+	switch {
+	case age < 3:
+		return 58.317*weight - 31.1, 59.512*weight - 30.4
+	case age < 10:
+		return 20.315*weight + 485.9, 22.706*weight + 504.3
+	default:
+		return 13.384*weight + 692.6, 17.686*weight + 658.2
+	}
+}
+
+func bySex(sex models.Sex, female float64, male float64) float64 {
+	switch sex {
+	case models.SexFemale:
+		return female
+	case models.SexMale:
+		return male
+	default:
+		return (female + male) / 2
+	}
+}
+
+// EnergyExpenditure returns the total energy the person uses in a day, in
+// kcal. An unset activity level counts as sedentary.
+func EnergyExpenditure(p models.Profile) float64 {
+	factor, ok := activityFactors[p.ActivityLevel]
+	if !ok {
+		factor = activityFactors[models.ActivitySedentary]
+	}
+	return BasalMetabolicRate(p) * factor
+}
+
+// DailyTarget returns the person's daily nutrient targets: the energy
+// expenditure adjusted for the goal, split into 20% protein, 30% fat and
+// 50% carbohydrates, with 14 g of fiber per 1000 kcal and 5% of the energy
+// from sugar.
+func DailyTarget(p models.Profile) models.NutritionalValues {
+	goal, ok := goalFactors[p.Goal]
+	if !ok {
+		goal = goalFactors[models.GoalMaintain]
+	}
+	calories := math.Max(EnergyExpenditure(p)*goal, 0)
+
+	return models.NutritionalValues{
+		Calories: calories,
+		Protein:  calories * 0.2 / caloriesPerGramProtein,
+		Fat:      calories * 0.3 / caloriesPerGramFat,
+		Carbs:    calories * 0.5 / caloriesPerGramCarbs,
+		Fiber:    calories * 14 / 1000,
+		Sugar:    calories * 0.05 / caloriesPerGramCarbs,
+	}
+}
+
+// Limits returns the intake around target. Energy may vary by 10%, the
+// macronutrients within their acceptable ranges of the target energy
+// (protein 10-35%, fat 20-35%, carbohydrates 45-65%), fiber down to 75% of
+// its target and sugar up to 10% of the energy. Saturated fat is capped at
+// 10% of the energy, and sodium and cholesterol at their daily values per
+// 2000 kcal. The limits scale with target, so they hold for a whole day as
+// well as for a meal.
+func Limits(target models.NutritionalValues) Intake {
+	energy := target.Calories
+	min := models.NutritionalValues{
+		Calories: energy * 0.9,
+		Protein:  energy * 0.10 / caloriesPerGramProtein,
+		Fat:      energy * 0.20 / caloriesPerGramFat,
+		Carbs:    energy * 0.45 / caloriesPerGramCarbs,
+		Fiber:    target.Fiber * 0.75,
+	}
+	max := models.NutritionalValues{
+		Calories: energy * 1.1,
+		Protein:  energy * 0.35 / caloriesPerGramProtein,
+		Fat:      energy * 0.35 / caloriesPerGramFat,
+		Carbs:    energy * 0.65 / caloriesPerGramCarbs,
+		Fiber:    target.Fiber * 2,
+		Sugar:    energy * 0.10 / caloriesPerGramCarbs,
+
+		SaturatedFat: energy * 0.10 / caloriesPerGramFat,
+		Sodium:       energy * dailyValue("sodium") / referenceCalories,
+		Cholesterol:  energy * dailyValue("cholesterol") / referenceCalories,
+	}
+
+	// A target outside its range moves the range, not the target
+	return Intake{
+		TargetNutrients: target,
+		MinNutrients:    min.Min(target),
+		MaxNutrients:    max.Max(target),
+	}
+}
+
+// Recommend returns the person's recommended daily intake.
+func Recommend(p models.Profile) Intake {
+	return Limits(DailyTarget(p))
+}
+
+// Complete fills the zero values of target, min and max from recommended:
+// missing targets are taken from it and missing limits are derived from the
+// resulting target with Limits. Values that are set are kept, so they
+// override the recommendation.
+func Complete(target models.NutritionalValues, min models.NutritionalValues, max models.NutritionalValues, recommended models.NutritionalValues) Intake {
+	limits := Limits(target.WithDefaults(recommended))
+	return Intake{
+		TargetNutrients: limits.TargetNutrients,
+		MinNutrients:    min.WithDefaults(limits.MinNutrients),
+		MaxNutrients:    max.WithDefaults(limits.MaxNutrients),
+	}
+}
+
+// CompleteMember returns the member with their daily targets completed from
+// their profile. Members whose profile lacks what recommendations need are
+// returned as they are.
+func CompleteMember(member models.HouseholdMember) models.HouseholdMember {
+	if !member.CanRecommend() {
+		return member
+	}
+
+	intake := Complete(member.TargetNutrients, member.MinNutrients, member.MaxNutrients, DailyTarget(member.Profile))
+	member.TargetNutrients = intake.TargetNutrients
+	member.MinNutrients = intake.MinNutrients
+	member.MaxNutrients = intake.MaxNutrients
+	return member
+}
@@ -0,0 +1,106 @@
+package nutrition_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/nutrition"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasalMetabolicRate(t *testing.T) {
+	adult := models.Profile{Age: 30, Sex: models.SexMale, Weight: 80, Height: 180}
+	assert.InDelta(t, 1780, nutrition.BasalMetabolicRate(adult), 1e-9)
+
+	adult.Sex = models.SexFemale
+	assert.InDelta(t, 1614, nutrition.BasalMetabolicRate(adult), 1e-9)
+
+	adult.Sex = ""
+	assert.InDelta(t, 1697, nutrition.BasalMetabolicRate(adult), 1e-9)
+
+	// Children only need their weight
+	toddler := models.Profile{Age: 2, Sex: models.SexMale, Weight: 12}
+	assert.InDelta(t, 59.512*12-30.4, nutrition.BasalMetabolicRate(toddler), 1e-9)
+}
+
+func TestDailyTarget(t *testing.T) {
+	profile := models.Profile{Age: 30, Sex: models.SexMale, Weight: 80, Height: 180, ActivityLevel: models.ActivityModerate}
+	target := nutrition.DailyTarget(profile)
+
+	assert.InDelta(t, 1780*1.55, target.Calories, 1e-9)
+	assert.InDelta(t, target.Calories*0.2/4, target.Protein, 1e-9)
+	assert.InDelta(t, target.Calories*0.3/9, target.Fat, 1e-9)
+	assert.InDelta(t, target.Calories*0.5/4, target.Carbs, 1e-9)
+
+	profile.Goal = models.GoalLose
+	assert.InDelta(t, 1780*1.55*0.8, nutrition.DailyTarget(profile).Calories, 1e-9)
+
+	athlete := models.Profile{Age: 25, Sex: models.SexMale, Weight: 90, Height: 190, ActivityLevel: models.ActivityVeryActive}
+	toddler := models.Profile{Age: 2, Weight: 12}
+	assert.Greater(t, nutrition.DailyTarget(athlete).Calories, 3*nutrition.DailyTarget(toddler).Calories)
+}
+
+func TestRecommend_LimitsAreConsistent(t *testing.T) {
+	intake := nutrition.Recommend(models.Profile{Age: 40, Weight: 70, Height: 165})
+
+	mins, targets, maxes := intake.MinNutrients.Fields(), intake.TargetNutrients.Fields(), intake.MaxNutrients.Fields()
+	for i, name := range models.NutrientFieldNames {
+		assert.LessOrEqual(t, mins[i], targets[i], name)
+		assert.LessOrEqual(t, targets[i], maxes[i], name)
+	}
+	assert.NoError(t, models.MealPlanParams{
+		StartDate:       startDate(),
+		EndDate:         startDate().AddDate(0, 0, 1),
+		Servings:        1,
+		TargetNutrients: intake.TargetNutrients,
+		MinNutrients:    intake.MinNutrients,
+		MaxNutrients:    intake.MaxNutrients,
+	}.Validate())
+}
+
+func TestComplete_KeepsOverrides(t *testing.T) {
+	recommended := models.NutritionalValues{Calories: 2000, Protein: 100, Fat: 60, Carbs: 250, Fiber: 28, Sugar: 25}
+
+	intake := nutrition.Complete(
+		models.NutritionalValues{Calories: 2500, Protein: 200},
+		models.NutritionalValues{Calories: 2400},
+		models.NutritionalValues{Sugar: 10},
+		recommended,
+	)
+
+	assert.Equal(t, 2500.0, intake.TargetNutrients.Calories)
+	assert.Equal(t, 200.0, intake.TargetNutrients.Protein)
+	assert.Equal(t, 60.0, intake.TargetNutrients.Fat)
+	assert.Equal(t, 2400.0, intake.MinNutrients.Calories)
+	assert.Equal(t, 10.0, intake.MaxNutrients.Sugar)
+	// Derived limits follow the overridden target
+	assert.InDelta(t, 2750, intake.MaxNutrients.Calories, 1e-9)
+	assert.GreaterOrEqual(t, intake.MaxNutrients.Protein, 200.0)
+}
+
+func TestCompleteMember(t *testing.T) {
+	unknown := models.HouseholdMember{Name: "Guest"}
+	assert.Equal(t, unknown, nutrition.CompleteMember(unknown))
+
+	member := nutrition.CompleteMember(models.HouseholdMember{
+		Name:            "Ana",
+		Profile:         models.Profile{Age: 34, Sex: models.SexFemale, Weight: 60, Height: 168},
+		TargetNutrients: models.NutritionalValues{Calories: 1800},
+	})
+	assert.Equal(t, 1800.0, member.TargetNutrients.Calories)
+	assert.Greater(t, member.TargetNutrients.Protein, 0.0)
+	assert.InDelta(t, 1620, member.MinNutrients.Calories, 1e-9)
+
+	// Without an age, or the height of an adult, nothing is recommended
+	noAge := models.HouseholdMember{Name: "Guest", Profile: models.Profile{Weight: 70, Height: 175}}
+	assert.Equal(t, noAge, nutrition.CompleteMember(noAge))
+	noHeight := models.HouseholdMember{Name: "Guest", Profile: models.Profile{Age: 30, Weight: 70}}
+	assert.Equal(t, noHeight, nutrition.CompleteMember(noHeight))
+	child := nutrition.CompleteMember(models.HouseholdMember{Name: "Mia", Profile: models.Profile{Age: 8, Weight: 25}})
+	assert.Greater(t, child.TargetNutrients.Calories, 0.0)
+}
+
+func startDate() time.Time {
+	return time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,278 @@
+package nutrition
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/cvele/recipe/pkg/models"
+)
+
+// labelRow is a line of a nutrition label.
+type labelRow struct {
+	Label   string
+	Amount  string
+	Percent string
+	Bold    bool
+	Indent  bool
+	Y       int // baseline in the SVG label
+}
+
+// labelData is what the label templates render.
+type labelData struct {
+	Title     string
+	Servings  int
+	Calories  string
+	Rows      []labelRow
+	Vitamins  []labelRow
+	Missing   []labelRow
+	Height    int
+	MissingY  int
+	FootnoteY int
+}
+
+// labelLines are the nutrients on the label in FDA order. Sugars and
+// protein have no percent daily value on a label.
+var labelLines = []struct {
+	name      string
+	bold      bool
+	indent    bool
+	noPercent bool
+}{
+	{name: "fat", bold: true},
+	{name: "saturated_fat", indent: true},
+	{name: "cholesterol", bold: true},
+	{name: "sodium", bold: true},
+	{name: "carbs", bold: true},
+	{name: "fiber", indent: true},
+	{name: "sugar", indent: true, noPercent: true},
+	{name: "protein", bold: true, noPercent: true},
+}
+
+var labelVitamins = []string{"vitamin_d", "calcium", "iron", "potassium", "vitamin_a", "vitamin_c"}
+
+func newLabelData(facts Facts) labelData {
+	values := nutrientValues(facts.PerServing)
+	percents := nutrientValues(facts.PercentDailyValues)
+
+	data := labelData{
+		Title:    facts.Title,
+		Servings: facts.Servings,
+		Calories: formatNumber(roundCalories(facts.PerServing.Calories)),
+	}
+
+	y := 170
+	for _, line := range labelLines {
+		nutrient, _ := models.NutrientByName(line.name)
+		row := labelRow{
+			Label:  nutrient.Label,
+			Amount: formatAmount(line.name, values[line.name], nutrient.Unit),
+			Bold:   line.bold,
+			Indent: line.indent,
+			Y:      y,
+		}
+		if !line.noPercent {
+			row.Percent = formatPercent(percents[line.name])
+		}
+		data.Rows = append(data.Rows, row)
+		y += 22
+	}
+
+	y += 14
+	for _, name := range labelVitamins {
+		nutrient, _ := models.NutrientByName(name)
+		data.Vitamins = append(data.Vitamins, labelRow{
+			Label:   nutrient.Label,
+			Amount:  formatNumber(roundTo(values[name], vitaminStep(values[name]))) + nutrient.Unit,
+			Percent: formatPercent(percents[name]),
+			Y:       y,
+		})
+		y += 22
+	}
+
+	data.FootnoteY = y + 10
+	data.MissingY = data.FootnoteY + 44
+	y = data.MissingY
+	for _, ingredient := range facts.Missing() {
+		y += 18
+		data.Missing = append(data.Missing, labelRow{Label: ingredient.Name, Amount: ingredient.Missing, Y: y})
+	}
+	data.Height = y + 14
+	return data
+}
+
+// nutrientValues returns the values by their nutrient's name.
+func nutrientValues(values models.NutritionalValues) map[string]float64 {
+	byName := make(map[string]float64, len(models.Nutrients))
+	for i, value := range values.Fields() {
+		byName[models.Nutrients[i].Name] = value
+	}
+	return byName
+}
+
+// roundCalories rounds energy like a label: to 5 kcal up to 50 kcal and to
+// 10 kcal above, with less than 5 kcal shown as zero.
+func roundCalories(calories float64) float64 {
+	switch {
+	case calories < 5:
+		return 0
+	case calories <= 50:
+		return roundTo(calories, 5)
+	default:
+		return roundTo(calories, 10)
+	}
+}
+
+// formatAmount formats a nutrient's amount with the label rounding rules.
+func formatAmount(name string, value float64, unit string) string {
+	switch name {
+	case "fat", "saturated_fat":
+		switch {
+		case value < 0.5:
+			value = 0
+		case value < 5:
+			value = roundTo(value, 0.5)
+		default:
+			value = roundTo(value, 1)
+		}
+	case "cholesterol":
+		switch {
+		case value < 2:
+			value = 0
+		case value <= 5:
+			return "less than 5" + unit
+		default:
+			value = roundTo(value, 5)
+		}
+	case "sodium":
+		switch {
+		case value < 5:
+			value = 0
+		case value <= 140:
+			value = roundTo(value, 5)
+		default:
+			value = roundTo(value, 10)
+		}
+	default:
+		switch {
+		case value < 0.5:
+			value = 0
+		case value < 1:
+			return "less than 1" + unit
+		default:
+			value = roundTo(value, 1)
+		}
+	}
+	return formatNumber(value) + unit
+}
+
+// formatPercent rounds a percent daily value to 2% up to 10%, 5% up to 50%
+// and 10% above.
+func formatPercent(percent float64) string {
+	switch {
+	case percent < 1:
+		percent = 0
+	case percent <= 10:
+		percent = roundTo(percent, 2)
+	case percent <= 50:
+		percent = roundTo(percent, 5)
+	default:
+		percent = roundTo(percent, 10)
+	}
+	return formatNumber(percent) + "%"
+}
+
+func vitaminStep(value float64) float64 {
+	switch {
+	case value < 1:
+		return 0.1
+	case value < 100:
+		return 1
+	default:
+		return 10
+	}
+}
+
+func roundTo(value float64, step float64) float64 {
+	return math.Round(value/step) * step
+}
+
+func formatNumber(value float64) string {
+	s := fmt.Sprintf("%.1f", value)
+	return strings.TrimSuffix(s, ".0")
+}
+
+// RenderLabelHTML writes the recipe's per-serving nutrition facts as an
+// FDA-style nutrition label in an HTML page.
+func RenderLabelHTML(w io.Writer, facts Facts) error {
+	return htmlLabel.Execute(w, newLabelData(facts))
+}
+
+// RenderLabelSVG writes the recipe's per-serving nutrition facts as an
+// FDA-style nutrition label in an SVG image.
+func RenderLabelSVG(w io.Writer, facts Facts) error {
+	return svgLabel.Execute(w, newLabelData(facts))
+}
+
+var htmlLabel = template.Must(template.New("label.html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Nutrition Facts: {{.Title}}</title>
+<style>
+.label { width: 300px; border: 1px solid #000; padding: 4px 8px; font-family: Helvetica, Arial, sans-serif; font-size: 14px; }
+.label h1 { margin: 0; font-size: 36px; font-weight: 900; }
+.label table { width: 100%; border-collapse: collapse; }
+.label td { border-top: 1px solid #000; padding: 2px 0; }
+.label td.percent { text-align: right; font-weight: bold; }
+.label .indent { padding-left: 16px; }
+.label .calories { font-size: 28px; font-weight: 900; border-top: 8px solid #000; }
+.label .thick td { border-top: 8px solid #000; }
+.label .footnote { border-top: 4px solid #000; font-size: 11px; padding-top: 4px; }
+.label .missing { color: #b00; font-size: 12px; }
+</style>
+</head>
+<body>
+<div class="label">
+<h1>Nutrition Facts</h1>
+<div>{{.Servings}} {{if eq .Servings 1}}serving{{else}}servings{{end}} per recipe</div>
+<div><strong>Serving size</strong> 1 serving</div>
+<table>
+<tr class="calories"><td class="calories">Calories</td><td class="calories percent">{{.Calories}}</td></tr>
+<tr><td></td><td class="percent">% Daily Value*</td></tr>
+{{range .Rows}}<tr><td{{if .Indent}} class="indent"{{end}}>{{if .Bold}}<strong>{{.Label}}</strong>{{else}}{{.Label}}{{end}} {{.Amount}}</td><td class="percent">{{.Percent}}</td></tr>
+{{end}}{{range $i, $row := .Vitamins}}<tr{{if eq $i 0}} class="thick"{{end}}><td>{{$row.Label}} {{$row.Amount}}</td><td class="percent">{{$row.Percent}}</td></tr>
+{{end}}</table>
+<div class="footnote">* The % Daily Value (DV) tells you how much a nutrient in a serving of food contributes to a daily diet. 2,000 calories a day is used for general nutrition advice.</div>
+{{if .Missing}}<div class="missing">Incomplete: nutrient data is missing for
+<ul>{{range .Missing}}<li>{{.Label}}: {{.Amount}}</li>{{end}}</ul></div>
+{{end}}</div>
+</body>
+</html>
+`))
+
+var svgLabel = template.Must(template.New("label.svg").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="320" height="{{.Height}}" viewBox="0 0 320 {{.Height}}" font-family="Helvetica, Arial, sans-serif" font-size="14">
+<rect x="1" y="1" width="318" height="{{.Height}}" fill="#fff" stroke="#000"/>
+<text x="10" y="40" font-size="32" font-weight="900">Nutrition Facts</text>
+<text x="10" y="62">{{.Servings}} {{if eq .Servings 1}}serving{{else}}servings{{end}} per recipe</text>
+<text x="10" y="82"><tspan font-weight="bold">Serving size</tspan> 1 serving</text>
+<rect x="10" y="90" width="300" height="8"/>
+<text x="10" y="126" font-size="26" font-weight="900">Calories</text>
+<text x="310" y="126" font-size="26" font-weight="900" text-anchor="end">{{.Calories}}</text>
+<rect x="10" y="134" width="300" height="4"/>
+<text x="310" y="154" font-weight="bold" text-anchor="end">% Daily Value*</text>
+{{range .Rows}}<line x1="10" x2="310" y1="{{.Y}}" y2="{{.Y}}" stroke="#000" transform="translate(0 -16)"/>
+<text x="{{if .Indent}}26{{else}}10{{end}}" y="{{.Y}}">{{if .Bold}}<tspan font-weight="bold">{{.Label}}</tspan>{{else}}{{.Label}}{{end}} {{.Amount}}</text>
+<text x="310" y="{{.Y}}" font-weight="bold" text-anchor="end">{{.Percent}}</text>
+{{end}}{{range .Vitamins}}<line x1="10" x2="310" y1="{{.Y}}" y2="{{.Y}}" stroke="#000" transform="translate(0 -16)"/>
+<text x="10" y="{{.Y}}">{{.Label}} {{.Amount}}</text>
+<text x="310" y="{{.Y}}" text-anchor="end">{{.Percent}}</text>
+{{end}}<text x="10" y="{{.FootnoteY}}" font-size="10">* The % Daily Value (DV) tells you how much a nutrient in a serving</text>
+<text x="10" y="{{.FootnoteY}}" dy="12" font-size="10">of food contributes to a daily diet. 2,000 calories a day is used</text>
+<text x="10" y="{{.FootnoteY}}" dy="24" font-size="10">for general nutrition advice.</text>
+{{if .Missing}}<text x="10" y="{{.MissingY}}" font-size="12" fill="#b00">Incomplete, nutrient data is missing for:</text>
+{{range .Missing}}<text x="18" y="{{.Y}}" font-size="12" fill="#b00">{{.Label}}</text>
+{{end}}{{end}}</svg>
+`))
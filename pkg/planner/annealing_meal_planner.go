@@ -0,0 +1,192 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
+)
+
+var _ ContextMealPlanner = (*SimulatedAnnealingPlanner)(nil)
+
+// SimulatedAnnealingPlanner plans all free days at once by simulated
+// annealing: every step moves one day to a random recipe, always keeping
+// better plans and worse ones with a probability that falls as the
+// temperature cools. It is fast but not guaranteed to find the best plan.
+type SimulatedAnnealingPlanner struct {
+	plannerBase
+	steps              int
+	initialTemperature float64
+	coolingRate        float64
+}
+
+// NewSimulatedAnnealingPlanner returns a planner that runs steps moves,
+// multiplying the temperature by coolingRate after each. A zero initial
+// temperature is derived from the spread of the recipes' fitness.
+func NewSimulatedAnnealingPlanner(
+	steps int,
+	initialTemperature float64,
+	coolingRate float64,
+	recipeRepo repositories.RecipeRepositoryInterface,
+	params models.MealPlanParams,
+	unitConverter units.UnitConverterInterface,
+) *SimulatedAnnealingPlanner {
+	return &SimulatedAnnealingPlanner{
+		plannerBase: plannerBase{
+			recipeRepo:    recipeRepo,
+			params:        params,
+			unitConverter: unitConverter,
+		},
+		steps:              steps,
+		initialTemperature: initialTemperature,
+		coolingRate:        coolingRate,
+	}
+}
+
+func (a *SimulatedAnnealingPlanner) CreateMealPlans(
+	startDate time.Time,
+	endDate time.Time,
+	mealTime time.Time,
+	mealType models.MealType,
+) ([]models.MealPlan, error) {
+	return a.CreateMealPlansContext(context.Background(), startDate, endDate, mealTime, mealType)
+}
+
+// CreateMealPlansContext is CreateMealPlans with cancellation. It returns
+// ctx.Err() if ctx is done before planning finishes.
+func (a *SimulatedAnnealingPlanner) CreateMealPlansContext(
+	ctx context.Context,
+	startDate time.Time,
+	endDate time.Time,
+	mealTime time.Time,
+	mealType models.MealType,
+) ([]models.MealPlan, error) {
+	days, pinned, err := a.begin(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	free := freeDays(days, pinned)
+	var indexes []int
+	if len(free) > 0 {
+		if err := a.loadPool(mealType); err != nil {
+			return nil, fmt.Errorf("unable to load recipes: %w", err)
+		}
+		indexes, err = a.anneal(ctx, len(free))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mealPlans, err := a.assemble(startDate, days, pinned, indexes, mealTime, mealType)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mealPlans {
+		if !mealPlans[i].Fitness.Pinned {
+			mealPlans[i].Fitness.Generations = a.steps
+			mealPlans[i].Fitness.Termination = string(TerminationMaxGenerations)
+		}
+	}
+	return mealPlans, nil
+}
+
+// anneal returns the pool indexes of the best plan found for n free days.
+func (a *SimulatedAnnealingPlanner) anneal(ctx context.Context, n int) ([]int, error) {
+	rng := rand.New(rand.NewSource(a.newSeed()))
+
+	uses := a.uses.clone()
+
+	current := make([]int, n)
+	cost := 0.0
+	for i := range current {
+		current[i] = rng.Intn(len(a.pool))
+		recipe := a.pool[current[i]]
+		cost += a.poolFitness[current[i]] + uses.repeats(recipe).penalty()
+		uses.add(recipe, 1)
+	}
+
+	best := make([]int, n)
+	copy(best, current)
+	bestCost := cost
+
+	temperature := a.initialTemperature
+	if temperature <= 0 {
+		temperature = a.autoTemperature()
+	}
+
+	reportEvery := a.steps / 100
+	if reportEvery < 1 {
+		reportEvery = 1
+	}
+
+	for step := 0; step < a.steps; step++ {
+		if step%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if step%reportEvery == 0 {
+			a.reportProgress(step, bestCost, "")
+		}
+
+		day := rng.Intn(n)
+		previous, next := current[day], rng.Intn(len(a.pool))
+
+		// Moving a meal from one recipe to another changes its pool fitness
+		// and how many other meals it repeats
+		uses.add(a.pool[previous], -1)
+		delta := a.poolFitness[next] - a.poolFitness[previous] +
+			uses.repeats(a.pool[next]).penalty() - uses.repeats(a.pool[previous]).penalty()
+
+		if delta <= 0 || rng.Float64() < math.Exp(-delta/temperature) {
+			current[day] = next
+			uses.add(a.pool[next], 1)
+			cost += delta
+			if cost < bestCost {
+				bestCost = cost
+				copy(best, current)
+			}
+		} else {
+			uses.add(a.pool[previous], 1)
+		}
+
+		temperature *= a.coolingRate
+	}
+
+	a.reportProgress(a.steps, bestCost, TerminationMaxGenerations)
+	return best, nil
+}
+
+// autoTemperature starts hot enough to accept moving between the best and
+// the worst recipe about a third of the time.
+func (a *SimulatedAnnealingPlanner) autoTemperature() float64 {
+	lowest, highest := a.poolFitness[0], a.poolFitness[0]
+	for _, fitness := range a.poolFitness {
+		lowest = math.Min(lowest, fitness)
+		highest = math.Max(highest, fitness)
+	}
+	if spread := highest - lowest + repeatPenalty; spread > 0 {
+		return spread
+	}
+	return 1
+}
+
+func (a *SimulatedAnnealingPlanner) reportProgress(step int, bestFitness float64, reason TerminationReason) {
+	if a.onProgress == nil {
+		return
+	}
+	a.onProgress(Progress{
+		Day:               0,
+		Days:              1,
+		Generation:        step,
+		MaxGenerations:    a.steps,
+		BestFitness:       bestFitness,
+		TerminationReason: reason,
+	})
+}
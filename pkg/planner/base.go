@@ -0,0 +1,270 @@
+package planner
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
+)
+
+// plannerBase is what all planners share: the request, pinned meals, the
+// recipe pool and how meals are scored. Every free day has the same
+// targets, so a plan's fitness is the sum of its recipes' pool fitness plus
+// repeatPenalty for every pair of meals sharing a recipe and
+// cuisineRepeatPenalty for every pair sharing a cuisine; planners differ
+// only in how they search for the recipes.
+type plannerBase struct {
+	recipeRepo    repositories.RecipeRepositoryInterface
+	params        models.MealPlanParams
+	unitConverter units.UnitConverterInterface
+	onProgress    ProgressFunc
+	pinnedMeals   []models.MealPlan
+	recipeCache   *RecipeCache
+	slotTargets   slotTargets
+	limited       [][]bool        // per diner, which nutrients have limits
+	uses          mealUses        // meals per recipe and cuisine planned so far
+	pool          []models.Recipe // recipes free days are planned from
+	poolFitness   []float64       // fitness of each pool recipe before variety
+	seeds         *rand.Rand      // nil for unseeded runs
+}
+
+// SetProgressFunc registers fn to receive progress updates.
+func (b *plannerBase) SetProgressFunc(fn ProgressFunc) {
+	b.onProgress = fn
+}
+
+// SetRecipeCache shares cache between runs. Without one, every run
+// profiles its recipes from scratch.
+func (b *plannerBase) SetRecipeCache(cache *RecipeCache) {
+	b.recipeCache = cache
+}
+
+// SetSeed makes runs repeatable: the same seed and inputs give the same
+// plan.
+func (b *plannerBase) SetSeed(seed int64) {
+	b.seeds = rand.New(rand.NewSource(seed))
+}
+
+// newSeed returns a seed for a random source used during the run.
+func (b *plannerBase) newSeed() int64 {
+	if b.seeds == nil {
+		return rand.Int63()
+	}
+	return b.seeds.Int63()
+}
+
+// begin prepares a run over [startDate, endDate), returning the number of
+// days and the pinned meals by day.
+func (b *plannerBase) begin(startDate time.Time, endDate time.Time) (int, map[int]models.MealPlan, error) {
+	duration := endDate.Sub(startDate)
+	days := int(duration.Round(time.Hour*24).Hours() / 24)
+
+	if b.recipeCache == nil {
+		b.recipeCache = NewRecipeCache(b.unitConverter)
+	}
+
+	pinned, err := b.pinnedByDay(startDate, days)
+	if err != nil {
+		return 0, nil, err
+	}
+	b.slotTargets = b.calculateSlotTargets(days, pinned)
+	b.limited = limitedNutrients(b.params.PlannedDiners())
+
+	b.uses = newMealUses()
+	for _, mealPlan := range pinned {
+		b.uses.add(*mealPlan.Recipe, 1)
+	}
+	b.pool = nil
+
+	return days, pinned, nil
+}
+
+// loadPool loads the recipes free days are planned from and scores each of
+// them once.
+func (b *plannerBase) loadPool(mealType models.MealType) error {
+	recipes, err := b.recipeRepo.GetRecipesByType(mealType)
+	if err != nil {
+		return err
+	}
+	if len(recipes) == 0 {
+		return ErrNoRecipes
+	}
+
+	recipes = b.allowedRecipes(recipes)
+	if len(recipes) == 0 {
+		return ErrNoRecipes
+	}
+
+	b.pool = recipes
+	b.poolFitness = make([]float64, len(recipes))
+	for i, recipe := range recipes {
+		b.poolFitness[i] = b.evaluateProfile(b.recipeCache.profile(recipe), b.params.Servings, b.slotTargets, repeats{}).Total
+	}
+	return nil
+}
+
+// allowedRecipes returns the recipes without excluded ingredients that
+// match the plan's tags and cuisines. An ingredient is excluded when its
+// name contains an excluded name, ignoring case, so that excluding "peanut"
+// also excludes peanut butter.
+func (b *plannerBase) allowedRecipes(recipes []models.Recipe) []models.Recipe {
+	excluded := normalizedNames(b.params.ExcludedIngredients)
+	tags, excludedTags := normalizedNames(b.params.Tags), normalizedNames(b.params.ExcludedTags)
+	cuisines, excludedCuisines := normalizedNames(b.params.Cuisines), normalizedNames(b.params.ExcludedCuisines)
+	if len(excluded)+len(tags)+len(excludedTags)+len(cuisines)+len(excludedCuisines) == 0 {
+		return recipes
+	}
+
+	var allowed []models.Recipe
+	for _, recipe := range recipes {
+		cuisine := cuisineKey(recipe)
+		switch {
+		case containsIngredient(recipe, excluded):
+		case !hasTags(recipe, tags, true) || hasTags(recipe, excludedTags, false):
+		case len(cuisines) > 0 && !contains(cuisines, cuisine):
+		case cuisine != "" && contains(excludedCuisines, cuisine):
+		default:
+			allowed = append(allowed, recipe)
+		}
+	}
+	return allowed
+}
+
+// normalizedNames returns the names trimmed and in lower case, without
+// empty ones.
+func normalizedNames(names []string) []string {
+	var normalized []string
+	for _, name := range names {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized
+}
+
+// hasTags reports whether the recipe has all of the tags, or any of them.
+func hasTags(recipe models.Recipe, tags []string, all bool) bool {
+	for _, tag := range tags {
+		if recipe.HasTag(tag) != all {
+			return !all
+		}
+	}
+	return all
+}
+
+func contains(names []string, name string) bool {
+	for _, candidate := range names {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIngredient(recipe models.Recipe, names []string) bool {
+	if recipe.RecipeIngredients == nil {
+		return false
+	}
+	for _, recipeIngredient := range *recipe.RecipeIngredients {
+		ingredientName := strings.ToLower(recipeIngredient.Ingredient.Name)
+		for _, name := range names {
+			if strings.Contains(ingredientName, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fitness returns the fitness of the pool recipe at index given the meals
+// planned so far.
+func (b *plannerBase) fitness(index int) float64 {
+	return b.poolFitness[index] + b.uses.repeats(b.pool[index]).penalty()
+}
+
+// mealPlan returns a meal of the pool recipe at index, with its own copy of
+// the recipe's ingredients.
+func (b *plannerBase) mealPlan(index int) models.MealPlan {
+	recipe := copyRecipe(b.pool[index])
+	return models.MealPlan{
+		Recipe:   &recipe,
+		RecipeID: recipe.ID,
+		Servings: b.params.Servings,
+	}
+}
+
+// mealProfile returns the per-serving profile of a meal's recipe.
+func (b *plannerBase) mealProfile(mealPlan models.MealPlan) recipeProfile {
+	return b.recipeCache.profile(*mealPlan.Recipe)
+}
+
+// pinnedMeal returns a pinned meal with its fitness breakdown.
+func (b *plannerBase) pinnedMeal(mealPlan models.MealPlan, days int) models.MealPlan {
+	// The pinned meal is counted in the uses already
+	b.uses.add(*mealPlan.Recipe, -1)
+	breakdown := b.evaluate(mealPlan, b.mealTargets(days), b.uses.repeats(*mealPlan.Recipe))
+	b.uses.add(*mealPlan.Recipe, 1)
+	breakdown.Pinned = true
+	mealPlan.Fitness = &breakdown
+	return mealPlan
+}
+
+// plannedMeal returns the meal of the pool recipe at index for day, scaled
+// to the planned servings and with its fitness breakdown, and counts it
+// towards the variety of the following meals.
+func (b *plannerBase) plannedMeal(index int, day time.Time, mealTime time.Time, mealType models.MealType) (models.MealPlan, error) {
+	mealPlan := b.mealPlan(index)
+	// Only the chosen meal is scaled to the planned servings
+	if err := mealPlan.AdjustServings(b.unitConverter); err != nil {
+		return models.MealPlan{}, fmt.Errorf("meal for recipe %d: %w", mealPlan.RecipeID, err)
+	}
+	mealPlan.MealTime = time.Date(day.Year(), day.Month(), day.Day(), mealTime.Hour(), mealTime.Minute(), 0, 0, day.Location())
+	mealPlan.MealType = mealType
+	mealPlan.RecipeVersion = mealPlan.Recipe.Version
+
+	breakdown := b.evaluate(mealPlan, b.slotTargets, b.uses.repeats(*mealPlan.Recipe))
+	mealPlan.Fitness = &breakdown
+	b.uses.add(*mealPlan.Recipe, 1)
+	return mealPlan, nil
+}
+
+// assemble returns the plan for days: pinned meals on their days and the
+// pool recipes at indexes, in order, on the free days.
+func (b *plannerBase) assemble(
+	startDate time.Time,
+	days int,
+	pinned map[int]models.MealPlan,
+	indexes []int,
+	mealTime time.Time,
+	mealType models.MealType,
+) ([]models.MealPlan, error) {
+	var mealPlans []models.MealPlan
+	for i := 0; i < days; i++ {
+		if mealPlan, ok := pinned[i]; ok {
+			mealPlans = append(mealPlans, b.pinnedMeal(mealPlan, days))
+			continue
+		}
+		mealPlan, err := b.plannedMeal(indexes[0], startDate.AddDate(0, 0, i), mealTime, mealType)
+		if err != nil {
+			return nil, err
+		}
+		mealPlans = append(mealPlans, mealPlan)
+		indexes = indexes[1:]
+	}
+	return mealPlans, nil
+}
+
+// freeDays returns the indexes of the days without a pinned meal.
+func freeDays(days int, pinned map[int]models.MealPlan) []int {
+	var free []int
+	for i := 0; i < days; i++ {
+		if _, ok := pinned[i]; !ok {
+			free = append(free, i)
+		}
+	}
+	return free
+}
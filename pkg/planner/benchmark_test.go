@@ -0,0 +1,96 @@
+package planner_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cvele/recipe/pkg/fixtures"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/planner"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
+)
+
+// catalogSizes are the synthetic catalogs the planners are measured on.
+var catalogSizes = []int{20, 100, 500}
+
+// comparisonParams plans a week of lunches for two.
+func comparisonParams() models.MealPlanParams {
+	return fixtures.WeekOfLunches(time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC))
+}
+
+var comparedPlanners = []string{"genetic", "annealing", "exact"}
+
+// newComparisonPlanner returns the named planner with the service's default
+// tuning, seeded so that its plans are repeatable.
+func newComparisonPlanner(name string, recipeRepo repositories.RecipeRepositoryInterface, params models.MealPlanParams) planner.MealPlanner {
+	unitConverter := units.NewUnitConverter("g", "ml")
+	switch name {
+	case "annealing":
+		sap := planner.NewSimulatedAnnealingPlanner(20000, 0, 0.9995, recipeRepo, params, unitConverter)
+		sap.SetSeed(1)
+		return sap
+	case "exact":
+		return planner.NewExactMealPlanner(recipeRepo, params, unitConverter)
+	default:
+		gmp := planner.NewGeneticMealPlanner(100, 50, 0.7, 0.1, recipeRepo, params, unitConverter)
+		gmp.SetIslands(4, 5, 1)
+		gmp.SetSeed(1)
+		return gmp
+	}
+}
+
+func totalFitness(mealPlans []models.MealPlan) float64 {
+	total := 0.0
+	for _, mealPlan := range mealPlans {
+		total += mealPlan.Fitness.Total
+	}
+	return total
+}
+
+// satisfiedMeals returns the share of meals that break no limit.
+func satisfiedMeals(mealPlans []models.MealPlan) float64 {
+	if len(mealPlans) == 0 {
+		return 0
+	}
+
+	satisfied := 0
+	for _, mealPlan := range mealPlans {
+		if len(mealPlan.Fitness.Violations()) == 0 {
+			satisfied++
+		}
+	}
+	return float64(satisfied) / float64(len(mealPlans))
+}
+
+// BenchmarkPlanners compares the planners on the same catalogs. Besides time
+// and allocations per plan it reports meals planned per second, the plan's
+// total fitness as penalty/op (lower is better) and the share of meals
+// within all limits as satisfied/op.
+func BenchmarkPlanners(b *testing.B) {
+	params := comparisonParams()
+	for _, size := range catalogSizes {
+		recipeRepo := fixtures.NewRecipeRepository(fixtures.Recipes(size, 1))
+
+		for _, name := range comparedPlanners {
+			b.Run(name+"/"+strconv.Itoa(size), func(b *testing.B) {
+				b.ReportAllocs()
+				mealPlanner := newComparisonPlanner(name, recipeRepo, params)
+				meals, penalty, satisfied := 0, 0.0, 0.0
+				for i := 0; i < b.N; i++ {
+					mealPlans, err := mealPlanner.CreateMealPlans(params.StartDate, params.EndDate, params.StartDate, models.Lunch)
+					if err != nil {
+						b.Fatal(err)
+					}
+					meals += len(mealPlans)
+					penalty += totalFitness(mealPlans)
+					satisfied += satisfiedMeals(mealPlans)
+				}
+				b.ReportMetric(float64(meals)/b.Elapsed().Seconds(), "meals/s")
+				b.ReportMetric(penalty/float64(b.N), "penalty/op")
+				b.ReportMetric(satisfied/float64(b.N), "satisfied/op")
+			})
+		}
+	}
+}
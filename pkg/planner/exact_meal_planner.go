@@ -0,0 +1,125 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
+)
+
+var _ ContextMealPlanner = (*ExactMealPlanner)(nil)
+
+// ExactMealPlanner finds a plan with the lowest possible total fitness.
+//
+// The k-th meal of a recipe costs its pool fitness plus repeatPenalty for
+// each of the k-1 earlier ones and the recipe's pinned meals, plus
+// cuisineRepeatPenalty for each earlier meal of its cuisine. These costs
+// never decrease with k, and every recipe has at most one cuisine, so the
+// plan's fitness is a convex function of how often each recipe and cuisine
+// are used over nested groups, and repeatedly adding the cheapest next meal
+// is optimal. It takes days × recipes steps.
+type ExactMealPlanner struct {
+	plannerBase
+}
+
+func NewExactMealPlanner(
+	recipeRepo repositories.RecipeRepositoryInterface,
+	params models.MealPlanParams,
+	unitConverter units.UnitConverterInterface,
+) *ExactMealPlanner {
+	return &ExactMealPlanner{
+		plannerBase: plannerBase{
+			recipeRepo:    recipeRepo,
+			params:        params,
+			unitConverter: unitConverter,
+		},
+	}
+}
+
+func (e *ExactMealPlanner) CreateMealPlans(
+	startDate time.Time,
+	endDate time.Time,
+	mealTime time.Time,
+	mealType models.MealType,
+) ([]models.MealPlan, error) {
+	return e.CreateMealPlansContext(context.Background(), startDate, endDate, mealTime, mealType)
+}
+
+// CreateMealPlansContext is CreateMealPlans with cancellation. It returns
+// ctx.Err() if ctx is done before planning finishes.
+func (e *ExactMealPlanner) CreateMealPlansContext(
+	ctx context.Context,
+	startDate time.Time,
+	endDate time.Time,
+	mealTime time.Time,
+	mealType models.MealType,
+) ([]models.MealPlan, error) {
+	days, pinned, err := e.begin(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	free := freeDays(days, pinned)
+	var indexes []int
+	if len(free) > 0 {
+		if err := e.loadPool(mealType); err != nil {
+			return nil, fmt.Errorf("unable to load recipes: %w", err)
+		}
+		indexes, err = e.solve(ctx, len(free))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mealPlans, err := e.assemble(startDate, days, pinned, indexes, mealTime, mealType)
+	if err != nil {
+		return nil, err
+	}
+	total := 0.0
+	for i := range mealPlans {
+		total += mealPlans[i].Fitness.Total
+		if !mealPlans[i].Fitness.Pinned {
+			mealPlans[i].Fitness.Termination = string(TerminationOptimal)
+		}
+	}
+
+	if e.onProgress != nil {
+		e.onProgress(Progress{
+			Day:               0,
+			Days:              1,
+			Generation:        0,
+			MaxGenerations:    1,
+			BestFitness:       total,
+			TerminationReason: TerminationOptimal,
+		})
+	}
+
+	return mealPlans, nil
+}
+
+// solve returns the pool indexes of an optimal plan for n free days.
+func (e *ExactMealPlanner) solve(ctx context.Context, n int) ([]int, error) {
+	uses := e.uses.clone()
+
+	indexes := make([]int, 0, n)
+	for len(indexes) < n {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		best, bestCost := -1, 0.0
+		for i, recipe := range e.pool {
+			cost := e.poolFitness[i] + uses.repeats(recipe).penalty()
+			if best < 0 || cost < bestCost {
+				best, bestCost = i, cost
+			}
+		}
+
+		indexes = append(indexes, best)
+		uses.add(e.pool[best], 1)
+	}
+	return indexes, nil
+}
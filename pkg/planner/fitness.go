@@ -0,0 +1,217 @@
+package planner
+
+import (
+	"math"
+	"strings"
+
+	"github.com/cvele/recipe/pkg/models"
+)
+
+// costWeight is the penalty per cent a meal costs. Nutrient penalties count
+// a kcal or gram off target as 1, so a euro more for the meal weighs as
+// much as 10 kcal or grams off target: among meals that fit about equally
+// the cheapest wins, but saving money doesn't outweigh the nutrients.
+const costWeight = 0.1
+
+// repeatPenalty is added to a meal's fitness for every other meal in the
+// plan that uses the same recipe. Nutrient penalties count a kcal or gram
+// off target as 1, so a recipe is repeated only when the next best one is
+// more than 50 kcal or grams further off, about a small side dish.
+const repeatPenalty = 50.0
+
+// cuisineRepeatPenalty is added to a meal's fitness for every other meal in
+// the plan of the same cuisine. Recipes without a cuisine are not counted.
+const cuisineRepeatPenalty = 10.0
+
+// repeats is how many other meals in the plan a meal repeats, by recipe and
+// by cuisine.
+type repeats struct {
+	recipe  int
+	cuisine int
+}
+
+func (r repeats) penalty() float64 {
+	return float64(r.recipe)*repeatPenalty + float64(r.cuisine)*cuisineRepeatPenalty
+}
+
+// mealUses counts the meals of a plan by recipe and by cuisine.
+type mealUses struct {
+	recipes  map[uint]int
+	cuisines map[string]int
+}
+
+func newMealUses() mealUses {
+	return mealUses{recipes: make(map[uint]int), cuisines: make(map[string]int)}
+}
+
+func (u mealUses) clone() mealUses {
+	clone := newMealUses()
+	for id, count := range u.recipes {
+		clone.recipes[id] = count
+	}
+	for cuisine, count := range u.cuisines {
+		clone.cuisines[cuisine] = count
+	}
+	return clone
+}
+
+// add counts n more meals of the recipe; n may be negative.
+func (u mealUses) add(recipe models.Recipe, n int) {
+	u.recipes[recipe.ID] += n
+	if cuisine := cuisineKey(recipe); cuisine != "" {
+		u.cuisines[cuisine] += n
+	}
+}
+
+// repeats returns how many of the counted meals another meal of the recipe
+// would repeat.
+func (u mealUses) repeats(recipe models.Recipe) repeats {
+	r := repeats{recipe: u.recipes[recipe.ID]}
+	if cuisine := cuisineKey(recipe); cuisine != "" {
+		r.cuisine = u.cuisines[cuisine]
+	}
+	return r
+}
+
+// cuisineKey returns the cuisine meals of the recipe are counted by, empty
+// when the recipe has none.
+func cuisineKey(recipe models.Recipe) string {
+	return strings.ToLower(strings.TrimSpace(recipe.Cuisine))
+}
+
+// evaluate scores a meal at its servings against targets. The returned
+// breakdown's Total is the meal's fitness; lower is better.
+func (b *plannerBase) evaluate(mealPlan models.MealPlan, targets slotTargets, repeats repeats) models.FitnessBreakdown {
+	return b.evaluateProfile(b.mealProfile(mealPlan), mealPlan.Servings, targets, repeats)
+}
+
+// evaluateProfile scores a recipe served at servings against targets. Every
+// diner's portion is scored against their own nutrient limits, leaving out
+// nutrients the diner has no limits for; the cost is that of all servings.
+func (b *plannerBase) evaluateProfile(profile recipeProfile, servings int, targets slotTargets, repeats repeats) models.FitnessBreakdown {
+	breakdown := models.FitnessBreakdown{Unconverted: profile.unconverted}
+
+	for d, diner := range targets.diners {
+		values := profile.nutrients.Scale(diner.Portion).Fields()
+		mins, goals, maxes := diner.MinNutrients.Fields(), diner.TargetNutrients.Fields(), diner.MaxNutrients.Fields()
+		for i, name := range models.NutrientFieldNames {
+			if !b.limited[d][i] {
+				continue
+			}
+			score := scoreObjective(name, values[i], goals[i], mins[i], maxes[i])
+			score.Member = diner.Name
+			breakdown.Add(score)
+		}
+	}
+
+	_, totalCost := profile.scaled(servings)
+
+	// Budgets are optional, and without a target only the maximum counts
+	if b.params.MaxBudget > 0 {
+		targetBudget := targets.targetBudget
+		if b.params.TargetBudget == 0 {
+			targetBudget = targets.maxBudget
+		}
+		breakdown.Add(scoreBudget(totalCost, targetBudget, targets.maxBudget))
+	}
+
+	// Cheaper meals are better, with or without a budget
+	breakdown.Add(models.ObjectiveScore{
+		Objective: models.ObjectiveCost,
+		Value:     totalCost,
+		Penalty:   totalCost * costWeight,
+	})
+
+	breakdown.Add(models.ObjectiveScore{
+		Objective: models.ObjectiveVariety,
+		Value:     float64(repeats.recipe),
+		Penalty:   float64(repeats.recipe) * repeatPenalty,
+	})
+	if profile.cuisine != "" {
+		breakdown.Add(models.ObjectiveScore{
+			Objective: models.ObjectiveCuisineVariety,
+			Value:     float64(repeats.cuisine),
+			Penalty:   float64(repeats.cuisine) * cuisineRepeatPenalty,
+		})
+	}
+
+	return breakdown
+}
+
+func scoreObjective(objective string, value float64, target float64, minTarget float64, maxTarget float64) models.ObjectiveScore {
+	score := models.ObjectiveScore{
+		Objective: objective,
+		Value:     value,
+		Target:    target,
+		Min:       minTarget,
+		Max:       maxTarget,
+		Penalty:   calculateNutrientFitness(value, target, minTarget, maxTarget),
+	}
+	if value < minTarget {
+		score.Violation = models.ViolationBelowMin
+	} else if value > maxTarget {
+		score.Violation = models.ViolationAboveMax
+	}
+	return score
+}
+
+// scoreBudget scores a meal's cost against its share of the budget. Only
+// spending above the target is penalized, cheaper meals being rewarded by
+// the cost objective already, and spending above the maximum twice as much.
+// Unlike the cost, overspending is penalized a point per cent: the budget
+// is a limit the user set, like a nutrient's.
+func scoreBudget(cost float64, target float64, maxBudget float64) models.ObjectiveScore {
+	score := models.ObjectiveScore{
+		Objective: models.ObjectiveBudget,
+		Value:     cost,
+		Target:    target,
+		Max:       maxBudget,
+	}
+	switch {
+	case cost > maxBudget:
+		score.Penalty = math.Max(maxBudget-target, 0) + (cost-maxBudget)*2
+		score.Violation = models.ViolationAboveMax
+	case cost > target:
+		score.Penalty = cost - target
+	}
+	return score
+}
+
+// calculateNutrientFitness penalizes value by its distance from target
+// inside [minTarget, maxTarget], and twice as much for every unit beyond
+// them. The penalty at a limit carries on past it, so a value just outside
+// a limit never scores better than one inside it. Without a target the
+// limits alone count, so a cap such as a sodium maximum doesn't push the
+// value towards zero.
+func calculateNutrientFitness(value float64, target float64, minTarget float64, maxTarget float64) float64 {
+	atLimit := func(limit float64) float64 {
+		if target == 0 {
+			return 0
+		}
+		return math.Abs(target - limit)
+	}
+
+	if value < minTarget {
+		return atLimit(minTarget) + (minTarget-value)*2 // Penalize more heavily for falling below minimum
+	} else if value > maxTarget {
+		return atLimit(maxTarget) + (value-maxTarget)*2 // Penalize more heavily for exceeding maximum
+	} else if target == 0 {
+		return 0
+	} else {
+		return math.Abs(target - value) // Encourage matching target
+	}
+}
+
+// limitedNutrients reports, per diner, which nutrients have a minimum,
+// target or maximum. The others are not scored.
+func limitedNutrients(diners []models.Diner) [][]bool {
+	limited := make([][]bool, len(diners))
+	for d, diner := range diners {
+		mins, goals, maxes := diner.MinNutrients.Fields(), diner.TargetNutrients.Fields(), diner.MaxNutrients.Fields()
+		limited[d] = make([]bool, len(models.NutrientFieldNames))
+		for i := range models.NutrientFieldNames {
+			limited[d][i] = mins[i] != 0 || goals[i] != 0 || maxes[i] != 0
+		}
+	}
+	return limited
+}
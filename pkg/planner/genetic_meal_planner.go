@@ -1,11 +1,9 @@
 package planner
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"math"
-	"math/rand"
-	"runtime"
 	"sync"
 	"time"
 
@@ -14,21 +12,29 @@ import (
 	"github.com/cvele/recipe/pkg/units"
 )
 
-var _ MealPlanner = (*GeneticMealPlanner)(nil)
+var _ ContextMealPlanner = (*GeneticMealPlanner)(nil)
+
+// ErrNoRecipes is returned when no recipe is available for the requested
+// meal type.
+var ErrNoRecipes = errors.New("no recipes available for this meal type")
 
 type GeneticMealPlanner struct {
-	populationSize    int
-	maxGenerations    int
-	crossoverRate     float64
-	mutationRate      float64
-	recipeRepo        repositories.RecipeRepositoryInterface
-	params            models.MealPlanParams
-	population        []models.MealPlan
-	fitnessValues     []float64
-	bestMealPlan      models.MealPlan
-	bestFitness       float64
-	currentGeneration int
-	unitConverter     units.UnitConverterInterface
+	plannerBase
+	populationSize      int
+	maxGenerations      int
+	crossoverRate       float64
+	mutationRate        float64
+	islands             []*island
+	bestIndex           int // index of the best recipe in pool
+	bestFitness         float64
+	currentGeneration   int
+	bestHistory         []float64 // best fitness so far after each generation
+	stagnationWindow    int
+	stagnationThreshold float64
+	elitism             int
+	numIslands          int
+	migrationInterval   int
+	migrants            int
 }
 
 func NewGeneticMealPlanner(
@@ -41,266 +47,215 @@ func NewGeneticMealPlanner(
 	unitConverter units.UnitConverterInterface,
 ) *GeneticMealPlanner {
 	return &GeneticMealPlanner{
+		plannerBase: plannerBase{
+			recipeRepo:    recipeRepo,
+			params:        params,
+			unitConverter: unitConverter,
+		},
 		populationSize: populationSize,
 		maxGenerations: maxGenerations,
 		crossoverRate:  crossoverRate,
 		mutationRate:   mutationRate,
-		recipeRepo:     recipeRepo,
-		params:         params,
-		unitConverter:  unitConverter,
+
+		stagnationWindow:    10,
+		stagnationThreshold: 0.01,
 	}
 }
 
+// SetConvergence stops evolving a day once the best fitness improved by no
+// more than threshold over the last window generations. A window of zero or
+// less only stops at the generation limit.
+func (g *GeneticMealPlanner) SetConvergence(window int, threshold float64) {
+	g.stagnationWindow = window
+	g.stagnationThreshold = threshold
+}
+
+// SetIslands splits planning into n populations of PopulationSize that
+// evolve in parallel. Every interval generations each island sends its
+// migrants best individuals to the next one.
+func (g *GeneticMealPlanner) SetIslands(n int, interval int, migrants int) {
+	g.numIslands = n
+	g.migrationInterval = interval
+	g.migrants = migrants
+}
+
+// SetElitism carries the n best individuals of every generation over to the
+// next one unchanged. Zero or less carries none over.
+func (g *GeneticMealPlanner) SetElitism(n int) {
+	g.elitism = n
+}
+
 func (g *GeneticMealPlanner) CreateMealPlans(
 	startDate time.Time,
 	endDate time.Time,
 	mealTime time.Time,
 	mealType models.MealType,
 ) ([]models.MealPlan, error) {
+	return g.CreateMealPlansContext(context.Background(), startDate, endDate, mealTime, mealType)
+}
+
+// CreateMealPlansContext is CreateMealPlans with cancellation. It returns
+// ctx.Err() if ctx is done before planning finishes.
+func (g *GeneticMealPlanner) CreateMealPlansContext(
+	ctx context.Context,
+	startDate time.Time,
+	endDate time.Time,
+	mealTime time.Time,
+	mealType models.MealType,
+) ([]models.MealPlan, error) {
+	days, pinned, err := g.begin(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
 	var mealPlans []models.MealPlan
-	duration := endDate.Sub(startDate)
-	days := int(duration.Round(time.Hour*24).Hours() / 24)
 	for i := 0; i < days; i++ {
-		err := g.initializePopulation(mealType)
-		if err != nil {
-			return nil, fmt.Errorf("unable to initialize population: %v", err)
+		if mealPlan, ok := pinned[i]; ok {
+			mealPlans = append(mealPlans, g.pinnedMeal(mealPlan, days))
+			continue
+		}
+
+		if g.pool == nil {
+			if err := g.loadPool(mealType); err != nil {
+				return nil, fmt.Errorf("unable to initialize population: %w", err)
+			}
 		}
+		g.initializePopulation()
 
-		for g.currentGeneration = 0; g.currentGeneration < g.maxGenerations; g.currentGeneration++ {
-			g.calculateFitness()
+		var reason TerminationReason
+		for g.currentGeneration = 0; ; g.currentGeneration++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			g.eachIsland(func(is *island) {
+				is.calculateFitness(g)
+			})
 			g.updateBest()
-			if g.terminate() {
+
+			reason = g.terminate()
+			g.reportProgress(i, days, reason)
+			if reason != "" {
 				break
 			}
 
-			g.selectNewPopulation()
-			g.crossover()
+			if g.migrationInterval > 0 && (g.currentGeneration+1)%g.migrationInterval == 0 {
+				g.migrate()
+			}
+			g.eachIsland(func(is *island) {
+				is.evolve(g)
+			})
 		}
 
-		mealPlans = append(mealPlans, g.bestMealPlan)
+		mealPlan, err := g.plannedMeal(g.bestIndex, startDate.AddDate(0, 0, i), mealTime, mealType)
+		if err != nil {
+			return nil, err
+		}
+		mealPlan.Fitness.Generations = g.currentGeneration + 1
+		mealPlan.Fitness.Termination = string(reason)
+		mealPlans = append(mealPlans, mealPlan)
 	}
 
 	return mealPlans, nil
 }
 
-func (g *GeneticMealPlanner) initializePopulation(mealType models.MealType) error {
-	recipes, err := g.recipeRepo.GetRecipesByType(mealType)
-	if err != nil {
-		return err
-	}
-	if len(recipes) == 0 {
-		return errors.New("no recipes available for this meal type")
-	}
-
-	populationMap := sync.Map{}
-
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, runtime.NumCPU()) // Semaphore
-
-	for i := 0; i < g.populationSize; i++ {
-		wg.Add(1)
-		go func(i int) {
-			sem <- struct{}{} // Acquire a token
-			defer wg.Done()
-			defer func() { <-sem }() // Release the token
-
-			randomIndex := rand.Intn(len(recipes))
-			recipe := recipes[randomIndex]
-
-			mealPlan := models.MealPlan{
-				Recipe:   &recipe,
-				RecipeID: recipe.ID,
-				Servings: g.params.Servings,
-			}
-
-			// Adjust servings
-			mealPlan.AdjustServings(g.unitConverter)
-
-			populationMap.Store(i, mealPlan)
-		}(i)
+func (g *GeneticMealPlanner) initializePopulation() {
+	g.islands = make([]*island, g.islandCount())
+	for i := range g.islands {
+		g.islands[i] = newIsland(g.newSeed())
 	}
-	wg.Wait()
-
-	g.population = make([]models.MealPlan, g.populationSize)
-	populationMap.Range(func(key, value interface{}) bool {
-		index := key.(int)
-		mealPlan := value.(models.MealPlan)
-		g.population[index] = mealPlan
-		return true
+	g.eachIsland(func(is *island) {
+		is.initialize(g)
 	})
-
-	return nil
 }
 
-func (g *GeneticMealPlanner) calculateFitness() {
-	fitnessValuesMap := sync.Map{}
-
+// eachIsland runs fn on every island in parallel and waits for all of them.
+func (g *GeneticMealPlanner) eachIsland(fn func(is *island)) {
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, runtime.NumCPU()) // Semaphore
-
-	for i, recipe := range g.population {
+	for _, is := range g.islands {
 		wg.Add(1)
-		go func(i int, recipe models.MealPlan) {
-			sem <- struct{}{} // Acquire a token
+		go func(is *island) {
 			defer wg.Done()
-			defer func() { <-sem }() // Release the token
-
-			totalNutrition := models.NutritionalValues{}
-			totalCost := 0.0
-			scalingFactor := float64(g.params.Servings) / float64(recipe.Servings)
-
-			for _, ingredient := range *recipe.Recipe.RecipeIngredients {
-				scaledQuantity := ingredient.Quantity * scalingFactor
-
-				totalNutrition.Calories += ingredient.Ingredient.Nutrients.Calories * scaledQuantity
-				totalNutrition.Protein += ingredient.Ingredient.Nutrients.Protein * scaledQuantity
-				totalNutrition.Fat += ingredient.Ingredient.Nutrients.Fat * scaledQuantity
-				totalNutrition.Carbs += ingredient.Ingredient.Nutrients.Carbs * scaledQuantity
-				totalNutrition.Fiber += ingredient.Ingredient.Nutrients.Fiber * scaledQuantity
-				totalNutrition.Sugar += ingredient.Ingredient.Nutrients.Sugar * scaledQuantity
-
-				totalCost += float64(ingredient.Ingredient.PricePerUnit) * scaledQuantity
-			}
-
-			fitness := 0.0
-			minTarget := g.params.MinNutrients
-			maxTarget := g.params.MaxNutrients
-			target := g.params.TargetNutrients
-
-			fitness += calculateNutrientFitness(totalNutrition.Calories, target.Calories, minTarget.Calories, maxTarget.Calories)
-			fitness += calculateNutrientFitness(totalNutrition.Protein, target.Protein, minTarget.Protein, maxTarget.Protein)
-			fitness += calculateNutrientFitness(totalNutrition.Fat, target.Fat, minTarget.Fat, maxTarget.Fat)
-			fitness += calculateNutrientFitness(totalNutrition.Carbs, target.Carbs, minTarget.Carbs, maxTarget.Carbs)
-			fitness += calculateNutrientFitness(totalNutrition.Fiber, target.Fiber, minTarget.Fiber, maxTarget.Fiber)
-			fitness += calculateNutrientFitness(totalNutrition.Sugar, target.Sugar, minTarget.Sugar, maxTarget.Sugar)
-
-			fitness -= totalCost // Consider reducing cost as improving fitness
-
-			fitnessValuesMap.Store(i, fitness)
-		}(i, recipe)
+			fn(is)
+		}(is)
 	}
 	wg.Wait()
-
-	g.fitnessValues = make([]float64, g.populationSize)
-	fitnessValuesMap.Range(func(key, value interface{}) bool {
-		index := key.(int)
-		fitness := value.(float64)
-		g.fitnessValues[index] = fitness
-		return true
-	})
 }
 
-func calculateNutrientFitness(value float64, target float64, minTarget float64, maxTarget float64) float64 {
-	if value < minTarget {
-		return (minTarget - value) * 2 // Penalize more heavily for falling below minimum
-	} else if value > maxTarget {
-		return (value - maxTarget) * 2 // Penalize more heavily for exceeding maximum
-	} else {
-		return math.Abs(target - value) // Encourage matching target
+func (g *GeneticMealPlanner) islandCount() int {
+	if g.numIslands < 1 {
+		return 1
 	}
+	return g.numIslands
 }
 
 func (g *GeneticMealPlanner) updateBest() {
-	bestFitnessIndex := 0
-	for i, fitness := range g.fitnessValues {
-		if fitness < g.fitnessValues[bestFitnessIndex] {
-			bestFitnessIndex = i
-		}
+	if g.currentGeneration == 0 {
+		g.bestHistory = g.bestHistory[:0]
 	}
-
-	if g.bestFitness > g.fitnessValues[bestFitnessIndex] || g.currentGeneration == 0 {
-		g.bestFitness = g.fitnessValues[bestFitnessIndex]
-		g.bestMealPlan = g.population[bestFitnessIndex]
+	for i, is := range g.islands {
+		index, fitness := is.best()
+		if g.bestFitness > fitness || (g.currentGeneration == 0 && i == 0) {
+			g.bestFitness = fitness
+			g.bestIndex = index
+		}
 	}
+	g.bestHistory = append(g.bestHistory, g.bestFitness)
 }
 
-func (g *GeneticMealPlanner) terminate() bool {
-	const improvementThreshold = 0.01
-	const stagnantGenerations = 10
-
-	if g.currentGeneration >= g.maxGenerations {
-		return true
+// migrate sends every island's best individuals to the next island in a
+// ring, replacing that island's least fit ones.
+func (g *GeneticMealPlanner) migrate() {
+	if len(g.islands) < 2 || g.migrants <= 0 {
+		return
 	}
 
-	// If the best fitness is stagnant for a certain number of generations
-	if g.currentGeneration > stagnantGenerations {
-		improved := false
-		for i := 0; i < stagnantGenerations; i++ {
-			if math.Abs(g.fitnessValues[g.currentGeneration-i]-g.fitnessValues[g.currentGeneration-i-1]) > improvementThreshold {
-				improved = true
-				break
-			}
-		}
-		if !improved {
-			return true
-		}
+	migrants := make([][]int, len(g.islands))
+	fitness := make([][]float64, len(g.islands))
+	for i, is := range g.islands {
+		migrants[i], fitness[i] = is.elites(g.migrants)
+	}
+	for i := range g.islands {
+		g.islands[(i+1)%len(g.islands)].receive(migrants[i], fitness[i])
 	}
-
-	return false
 }
 
-func (g *GeneticMealPlanner) selectNewPopulation() {
-	newPopulation := make([]models.MealPlan, g.populationSize)
-
-	for i := 0; i < g.populationSize; i++ {
-		index1, index2 := rand.Intn(g.populationSize), rand.Intn(g.populationSize)
-		// Ensure index1 and index2 are different
-		for index1 == index2 {
-			index2 = rand.Intn(g.populationSize)
+// terminate returns why evolution of the current day should stop, or an
+// empty reason to keep going. The best fitness never gets worse, so
+// stagnation compares it with its value stagnationWindow generations ago.
+func (g *GeneticMealPlanner) terminate() TerminationReason {
+	if window := g.stagnationWindow; window > 0 && len(g.bestHistory) > window {
+		latest := g.bestHistory[len(g.bestHistory)-1]
+		earlier := g.bestHistory[len(g.bestHistory)-1-window]
+		if earlier-latest <= g.stagnationThreshold {
+			return TerminationStagnation
 		}
+	}
 
-		competitor1 := g.population[index1]
-		competitor2 := g.population[index2]
-		fitness1 := g.fitnessValues[index1]
-		fitness2 := g.fitnessValues[index2]
-
-		if fitness1 < fitness2 {
-			newPopulation[i] = competitor1
-		} else {
-			newPopulation[i] = competitor2
-		}
+	if g.currentGeneration >= g.maxGenerations-1 {
+		return TerminationMaxGenerations
 	}
 
-	g.population = newPopulation
+	return ""
 }
 
-func (g *GeneticMealPlanner) crossover() {
-	crossoverLimit := g.populationSize
-	if g.populationSize%2 != 0 {
-		crossoverLimit = g.populationSize - 1
+func (g *GeneticMealPlanner) reportProgress(day int, days int, reason TerminationReason) {
+	if g.onProgress == nil {
+		return
 	}
 
-	populationMap := sync.Map{}
-
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, runtime.NumCPU()) // Semaphore
-
-	for i := 0; i < crossoverLimit; i += 2 {
-		wg.Add(1)
-		go func(i int) {
-			sem <- struct{}{} // Acquire a token
-			defer wg.Done()
-			defer func() { <-sem }() // Release the token
-
-			if rand.Float64() < g.crossoverRate {
-				parent1 := g.population[rand.Intn(g.populationSize)]
-				parent2 := g.population[rand.Intn(g.populationSize)]
-
-				if rand.Intn(2) == 0 {
-					populationMap.Store(i, parent2)
-					populationMap.Store(i+1, parent1)
-				}
-			}
-		}(i)
+	progress := Progress{
+		Day:               day,
+		Days:              days,
+		Generation:        g.currentGeneration,
+		MaxGenerations:    g.maxGenerations,
+		BestFitness:       g.bestFitness,
+		BestRecipeID:      g.pool[g.bestIndex].ID,
+		BestRecipeTitle:   g.pool[g.bestIndex].Title,
+		TerminationReason: reason,
 	}
-	wg.Wait()
 
-	populationMap.Range(func(key, value interface{}) bool {
-		index := key.(int)
-		mealPlan := value.(models.MealPlan)
-		g.population[index] = mealPlan
-		return true
-	})
+	g.onProgress(progress)
 }
 
 func (g *GeneticMealPlanner) PopulationSize() int {
@@ -327,6 +282,24 @@ func (g *GeneticMealPlanner) Params() models.MealPlanParams {
 	return g.params
 }
 
+// Population returns the individuals of all islands as meals of their
+// unscaled recipes.
 func (g *GeneticMealPlanner) Population() []models.MealPlan {
-	return g.population
+	var population []models.MealPlan
+	for _, is := range g.islands {
+		for _, index := range is.population {
+			population = append(population, models.MealPlan{
+				Recipe:   &g.pool[index],
+				RecipeID: g.pool[index].ID,
+				Servings: g.params.Servings,
+			})
+		}
+	}
+	return population
+}
+
+// BestFitnessHistory returns the best fitness after each generation of the
+// last planned day.
+func (g *GeneticMealPlanner) BestFitnessHistory() []float64 {
+	return g.bestHistory
 }
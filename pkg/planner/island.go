@@ -0,0 +1,157 @@
+package planner
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// island is one sub-population of the genetic planner. An individual is the
+// index of its recipe in the planner's recipe pool. Islands evolve in
+// parallel, each with its own random source, and only share the read-only
+// pool and its fitness. Their best individuals migrate between them every
+// few generations.
+type island struct {
+	rng           *rand.Rand
+	population    []int
+	fitnessValues []float64
+}
+
+func newIsland(seed int64) *island {
+	return &island{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (is *island) initialize(g *GeneticMealPlanner) {
+	is.population = make([]int, g.populationSize)
+	for i := range is.population {
+		is.population[i] = is.rng.Intn(len(g.pool))
+	}
+}
+
+func (is *island) calculateFitness(g *GeneticMealPlanner) {
+	if len(is.fitnessValues) != len(is.population) {
+		is.fitnessValues = make([]float64, len(is.population))
+	}
+	for i, index := range is.population {
+		is.fitnessValues[i] = g.fitness(index)
+	}
+}
+
+// ranked returns the indexes of the population, fittest first.
+func (is *island) ranked() []int {
+	indexes := make([]int, len(is.population))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return is.fitnessValues[indexes[a]] < is.fitnessValues[indexes[b]]
+	})
+	return indexes
+}
+
+// best returns the fittest individual and its fitness.
+func (is *island) best() (int, float64) {
+	best := 0
+	for i, fitness := range is.fitnessValues {
+		if fitness < is.fitnessValues[best] {
+			best = i
+		}
+	}
+	return is.population[best], is.fitnessValues[best]
+}
+
+// elites returns the n fittest individuals and their fitness, best first.
+func (is *island) elites(n int) ([]int, []float64) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if n > len(is.population) {
+		n = len(is.population)
+	}
+
+	ranked := is.ranked()
+	elites := make([]int, n)
+	fitness := make([]float64, n)
+	for i := range elites {
+		elites[i] = is.population[ranked[i]]
+		fitness[i] = is.fitnessValues[ranked[i]]
+	}
+	return elites, fitness
+}
+
+// receive replaces the least fit individuals with migrants from another
+// island.
+func (is *island) receive(migrants []int, fitness []float64) {
+	ranked := is.ranked()
+	for i, migrant := range migrants {
+		index := ranked[len(ranked)-1-i]
+		is.population[index] = migrant
+		is.fitnessValues[index] = fitness[i]
+	}
+}
+
+// evolve replaces the population with the next generation, keeping the
+// elites at the front.
+func (is *island) evolve(g *GeneticMealPlanner) {
+	elites, _ := is.elites(g.elitism)
+	is.selectNewPopulation()
+	is.crossover(g.crossoverRate)
+	is.mutate(g.mutationRate, len(g.pool))
+	copy(is.population, elites)
+}
+
+func (is *island) selectNewPopulation() {
+	size := len(is.population)
+	newPopulation := make([]int, size)
+
+	for i := 0; i < size; i++ {
+		index1, index2 := is.rng.Intn(size), is.rng.Intn(size)
+		// Ensure index1 and index2 are different
+		for index1 == index2 {
+			index2 = is.rng.Intn(size)
+		}
+
+		if is.fitnessValues[index1] < is.fitnessValues[index2] {
+			newPopulation[i] = is.population[index1]
+		} else {
+			newPopulation[i] = is.population[index2]
+		}
+	}
+
+	is.population = newPopulation
+}
+
+// mutate replaces each individual with a random recipe from a pool of
+// poolSize with probability mutationRate.
+func (is *island) mutate(mutationRate float64, poolSize int) {
+	for i := range is.population {
+		if is.rng.Float64() < mutationRate {
+			is.population[i] = is.rng.Intn(poolSize)
+		}
+	}
+}
+
+func (is *island) crossover(crossoverRate float64) {
+	size := len(is.population)
+	crossoverLimit := size
+	if size%2 != 0 {
+		crossoverLimit = size - 1
+	}
+
+	// Parents are drawn from the population before crossover
+	newPopulation := make([]int, size)
+	copy(newPopulation, is.population)
+
+	for i := 0; i < crossoverLimit; i += 2 {
+		if is.rng.Float64() < crossoverRate {
+			parent1 := is.population[is.rng.Intn(size)]
+			parent2 := is.population[is.rng.Intn(size)]
+
+			if is.rng.Intn(2) == 0 {
+				newPopulation[i] = parent2
+				newPopulation[i+1] = parent1
+			}
+		}
+	}
+
+	is.population = newPopulation
+}
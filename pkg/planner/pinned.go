@@ -0,0 +1,120 @@
+package planner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+)
+
+// slotTargets are the per-meal targets used to score the days that are
+// still being planned, after pinned meals have taken their share of the
+// plan's nutrients and budget. Every diner has their own nutrient limits.
+type slotTargets struct {
+	diners       []models.Diner
+	targetBudget float64
+	maxBudget    float64
+}
+
+// SetPinnedMeals fixes the meals on the days they fall on. Pinned meals are
+// returned as they are and count towards the plan's nutrient and budget
+// targets; only the remaining days are planned.
+func (b *plannerBase) SetPinnedMeals(mealPlans []models.MealPlan) {
+	b.pinnedMeals = mealPlans
+}
+
+// pinnedByDay indexes the pinned meals inside the planned period by day,
+// scaling their recipes to their servings.
+func (b *plannerBase) pinnedByDay(startDate time.Time, days int) (map[int]models.MealPlan, error) {
+	pinned := make(map[int]models.MealPlan)
+	for _, mealPlan := range b.pinnedMeals {
+		day := dayIndex(startDate, mealPlan.MealTime)
+		if day < 0 || day >= days {
+			continue
+		}
+
+		if mealPlan.Servings == 0 {
+			mealPlan.Servings = b.params.Servings
+		}
+		if mealPlan.Recipe != nil {
+			recipe := copyRecipe(*mealPlan.Recipe)
+			mealPlan.Recipe = &recipe
+		}
+		if err := mealPlan.AdjustServings(b.unitConverter); err != nil {
+			return nil, fmt.Errorf("pinned meal for recipe %d: %w", mealPlan.RecipeID, err)
+		}
+
+		pinned[day] = mealPlan
+	}
+	return pinned, nil
+}
+
+// calculateSlotTargets spreads what remains of the plan's nutrient targets
+// and budget, once the pinned meals are subtracted, over the free days.
+// Nutrient targets are per meal and budgets are for the whole plan.
+func (b *plannerBase) calculateSlotTargets(days int, pinned map[int]models.MealPlan) slotTargets {
+	free := days - len(pinned)
+	if free <= 0 {
+		return slotTargets{}
+	}
+
+	// What one serving of every pinned meal adds up to, and what all of
+	// their servings cost
+	var pinnedServing models.NutritionalValues
+	pinnedCost := 0.0
+	for _, mealPlan := range pinned {
+		profile := b.mealProfile(mealPlan)
+		_, cost := profile.scaled(mealPlan.Servings)
+		pinnedServing = pinnedServing.Add(profile.nutrients)
+		pinnedCost += cost
+	}
+
+	var diners []models.Diner
+	for _, diner := range b.params.PlannedDiners() {
+		pinnedNutrition := pinnedServing.Scale(diner.Portion)
+		remaining := func(perMeal models.NutritionalValues) models.NutritionalValues {
+			return perMeal.Scale(float64(days)).Sub(pinnedNutrition).Scale(1 / float64(free)).ClampMin(0)
+		}
+
+		diner.TargetNutrients = remaining(diner.TargetNutrients)
+		diner.MinNutrients = remaining(diner.MinNutrients)
+		diner.MaxNutrients = remaining(diner.MaxNutrients)
+		diners = append(diners, diner)
+	}
+
+	return slotTargets{
+		diners:       diners,
+		targetBudget: (b.params.TargetBudget - pinnedCost) / float64(free),
+		maxBudget:    (b.params.MaxBudget - pinnedCost) / float64(free),
+	}
+}
+
+// mealTargets are the per-meal targets of the plan, against which pinned
+// meals are scored.
+func (b *plannerBase) mealTargets(days int) slotTargets {
+	return slotTargets{
+		diners:       b.params.PlannedDiners(),
+		targetBudget: b.params.TargetBudget / float64(days),
+		maxBudget:    b.params.MaxBudget / float64(days),
+	}
+}
+
+// dayIndex returns the number of calendar days from startDate to t, in
+// startDate's location.
+func dayIndex(startDate time.Time, t time.Time) int {
+	t = t.In(startDate.Location())
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int(day.Sub(start).Hours() / 24)
+}
+
+// copyRecipe returns recipe with its own copy of the ingredients, so that
+// scaling it doesn't affect other meals sharing the recipe.
+func copyRecipe(recipe models.Recipe) models.Recipe {
+	if recipe.RecipeIngredients != nil {
+		ingredients := make([]models.RecipeIngredient, len(*recipe.RecipeIngredients))
+		copy(ingredients, *recipe.RecipeIngredients)
+		recipe.RecipeIngredients = &ingredients
+	}
+	return recipe
+}
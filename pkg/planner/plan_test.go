@@ -4,8 +4,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cvele/recipe/pkg/fixtures"
 	"github.com/cvele/recipe/pkg/models"
 	"github.com/cvele/recipe/pkg/planner"
+	"github.com/cvele/recipe/pkg/repositories"
 	"github.com/cvele/recipe/pkg/units"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -20,6 +22,11 @@ func (m *RecipeRepositoryMock) GetAllRecipes() ([]models.Recipe, error) {
 	return args.Get(0).([]models.Recipe), args.Error(1)
 }
 
+func (m *RecipeRepositoryMock) FindRecipes(filter repositories.RecipeFilter) ([]models.Recipe, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.Recipe), args.Error(1)
+}
+
 func (m *RecipeRepositoryMock) GetRecipeByID(id uint) (*models.Recipe, error) {
 	args := m.Called(id)
 	return args.Get(0).(*models.Recipe), args.Error(1)
@@ -50,6 +57,19 @@ func (m *RecipeRepositoryMock) GetRecipesByType(mealType models.MealType) ([]mod
 	return args.Get(0).([]models.Recipe), args.Error(1)
 }
 
+func (m *RecipeRepositoryMock) GetRecipeVersions(id uint) ([]models.RecipeVersion, error) {
+	args := m.Called(id)
+	return args.Get(0).([]models.RecipeVersion), args.Error(1)
+}
+
+func (m *RecipeRepositoryMock) GetRecipeVersion(id uint, version int) (*models.Recipe, error) {
+	args := m.Called(id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Recipe), args.Error(1)
+}
+
 func TestNewGeneticMealPlanner(t *testing.T) {
 	mockRepo := new(RecipeRepositoryMock)
 	params := models.MealPlanParams{}
@@ -67,12 +87,13 @@ func TestNewGeneticMealPlanner(t *testing.T) {
 
 func TestCreateMealPlans(t *testing.T) {
 	mockRepo := new(RecipeRepositoryMock)
-	params := models.MealPlanParams{}
+	params := models.MealPlanParams{Servings: 1}
 
 	mockRepo.On("GetRecipesByType", models.Breakfast).Return([]models.Recipe{
 		{
-			ID:          1,
-			IsBreakfast: true,
+			ID:        1,
+			Servings:  1,
+			MealSlots: models.MealTypes{models.Breakfast},
 			RecipeIngredients: &[]models.RecipeIngredient{
 				{
 					Ingredient: models.Ingredient{
@@ -80,9 +101,10 @@ func TestCreateMealPlans(t *testing.T) {
 						Name:         "Egg",
 						Nutrients:    models.NutritionalValues{},
 						PricePerUnit: 1,
+						UnitType:     "mass",
 					},
-					Quantity: 1,
-					Unit:     "unit",
+					Quantity: 50,
+					Unit:     "g",
 				},
 			},
 		},
@@ -103,7 +125,704 @@ func TestCreateMealPlans(t *testing.T) {
 
 	for _, mealPlan := range mealPlans {
 		assert.NotNil(t, mealPlan.Recipe)
-		assert.True(t, mealPlan.Recipe.IsBreakfast)
+		assert.True(t, mealPlan.Recipe.HasMealSlot(models.Breakfast))
 		assert.Equal(t, params.Servings, mealPlan.Servings)
 	}
 }
+
+func lunchRecipe(id uint, calories float64) models.Recipe {
+	return models.Recipe{
+		ID:        id,
+		Servings:  1,
+		MealSlots: models.MealTypes{models.Lunch},
+		RecipeIngredients: &[]models.RecipeIngredient{
+			{
+				Ingredient: models.Ingredient{
+					Name:         "Rice",
+					UnitType:     "mass",
+					PricePerUnit: 1,
+					Nutrients:    models.NutritionalValues{Calories: calories},
+				},
+				Quantity: 1,
+				Unit:     "g",
+			},
+		},
+	}
+}
+
+func TestCreateMealPlans_Cost(t *testing.T) {
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 1),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+	}
+	plan := func(recipes ...models.Recipe) models.MealPlan {
+		mockRepo := new(RecipeRepositoryMock)
+		mockRepo.On("GetRecipesByType", models.Lunch).Return(recipes, nil)
+		mealPlans, err := planner.NewExactMealPlanner(mockRepo, params, units.NewUnitConverter("g", "ml")).CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+		assert.NoError(t, err)
+		assert.Len(t, mealPlans, 1)
+		return mealPlans[0]
+	}
+
+	// Of two equally good recipes the cheaper is planned, a tenth of its
+	// cost of 1 being its only penalty
+	dear := lunchRecipe(2, 500)
+	(*dear.RecipeIngredients)[0].Ingredient.PricePerUnit = 300
+	mealPlan := plan(dear, lunchRecipe(1, 500))
+	assert.Equal(t, uint(1), mealPlan.RecipeID)
+	assert.InDelta(t, 0.1, mealPlan.Fitness.Total, 1e-9)
+
+	// But 100 kcal off target (100) outweighs 299 cents saved (29.9)
+	mealPlan = plan(dear, lunchRecipe(1, 600))
+	assert.Equal(t, uint(2), mealPlan.RecipeID)
+	assert.InDelta(t, 30.0, mealPlan.Fitness.Total, 1e-9)
+}
+
+func TestCreateMealPlans_Budget(t *testing.T) {
+	cheap, dear := lunchRecipe(1, 500), lunchRecipe(2, 500)
+	(*cheap.RecipeIngredients)[0].Ingredient.PricePerUnit = 300
+	(*dear.RecipeIngredients)[0].Ingredient.PricePerUnit = 700
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{cheap, dear}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 1),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+		TargetBudget:    500,
+		MaxBudget:       600,
+	}
+	budget := func(recipe models.Recipe) models.ObjectiveScore {
+		mockRepo := new(RecipeRepositoryMock)
+		mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{recipe}, nil)
+		mealPlans, err := planner.NewExactMealPlanner(mockRepo, params, units.NewUnitConverter("g", "ml")).CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+		assert.NoError(t, err)
+		for _, score := range mealPlans[0].Fitness.Objectives {
+			if score.Objective == models.ObjectiveBudget {
+				return score
+			}
+		}
+		return models.ObjectiveScore{}
+	}
+
+	// Spending less than the target isn't penalized, so the cheaper meal
+	// is better by its cost alone
+	assert.Equal(t, 0.0, budget(cheap).Penalty)
+
+	// Above the target every cent counts, and twice above the maximum
+	over := budget(dear)
+	assert.Equal(t, 100.0+2*100.0, over.Penalty)
+	assert.Equal(t, models.ViolationAboveMax, over.Violation)
+
+	mealPlans, err := planner.NewExactMealPlanner(mockRepo, params, units.NewUnitConverter("g", "ml")).CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), mealPlans[0].RecipeID)
+	assert.InDelta(t, 30.0, mealPlans[0].Fitness.Total, 1e-9)
+
+	// Without a target, spending up to the maximum is free
+	params.TargetBudget = 0
+	assert.Equal(t, 0.0, budget(cheap).Penalty)
+	assert.Equal(t, 2*100.0, budget(dear).Penalty)
+}
+
+func TestCreateMealPlans_Variety(t *testing.T) {
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 2),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+	}
+	plan := func(recipes ...models.Recipe) []models.MealPlan {
+		mockRepo := new(RecipeRepositoryMock)
+		mockRepo.On("GetRecipesByType", models.Lunch).Return(recipes, nil)
+		mealPlans, err := planner.NewExactMealPlanner(mockRepo, params, units.NewUnitConverter("g", "ml")).CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+		assert.NoError(t, err)
+		assert.Len(t, mealPlans, 2)
+		return mealPlans
+	}
+	variety := func(mealPlan models.MealPlan) models.ObjectiveScore {
+		for _, score := range mealPlan.Fitness.Objectives {
+			if score.Objective == models.ObjectiveVariety {
+				return score
+			}
+		}
+		return models.ObjectiveScore{}
+	}
+
+	// A second meal of recipe 1 (50) costs more than one of recipe 2 (30)
+	mealPlans := plan(lunchRecipe(1, 500), lunchRecipe(2, 530))
+	assert.Equal(t, []uint{1, 2}, []uint{mealPlans[0].RecipeID, mealPlans[1].RecipeID})
+	assert.Equal(t, 0.0, variety(mealPlans[1]).Penalty)
+
+	// But less than one of recipe 3 (100)
+	mealPlans = plan(lunchRecipe(1, 500), lunchRecipe(3, 600))
+	assert.Equal(t, []uint{1, 1}, []uint{mealPlans[0].RecipeID, mealPlans[1].RecipeID})
+	assert.Equal(t, models.ObjectiveScore{Objective: models.ObjectiveVariety, Value: 1, Penalty: 50}, variety(mealPlans[1]))
+}
+
+func TestCreateMealPlans_UnconvertibleRecipe(t *testing.T) {
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 1),
+		Servings:        2,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+	}
+	recipe := lunchRecipe(1, 500)
+	(*recipe.RecipeIngredients)[0].Unit = "cup"
+
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{recipe}, nil)
+	unitConverter := units.NewUnitConverter("g", "ml")
+
+	planners := map[string]planner.MealPlanner{
+		"genetic":   planner.NewGeneticMealPlanner(20, 10, 0.7, 0.1, mockRepo, params, unitConverter),
+		"annealing": planner.NewSimulatedAnnealingPlanner(100, 0, 0.99, mockRepo, params, unitConverter),
+		"exact":     planner.NewExactMealPlanner(mockRepo, params, unitConverter),
+	}
+	for name, p := range planners {
+		// A recipe that can't be scaled to the planned servings is an error,
+		// not a meal planned for the recipe's own servings
+		mealPlans, err := p.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+		assert.Error(t, err, name)
+		assert.Nil(t, mealPlans, name)
+	}
+}
+
+func TestCreateMealPlans_Pinned(t *testing.T) {
+	light, heavy := lunchRecipe(1, 100), lunchRecipe(2, 900)
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{light, heavy}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 2),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+	}
+
+	gmp := planner.NewGeneticMealPlanner(20, 10, 0.7, 0.1, mockRepo, params, units.NewUnitConverter("g", "ml"))
+	pinnedTime := startDate.Add(12 * time.Hour)
+	gmp.SetPinnedMeals([]models.MealPlan{{RecipeID: 2, Recipe: &heavy, Servings: 1, MealTime: pinnedTime}})
+
+	mealPlans, err := gmp.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Len(t, mealPlans, 2)
+
+	// The pinned meal stays, and the free day makes up for its calories
+	assert.Equal(t, uint(2), mealPlans[0].RecipeID)
+	assert.Equal(t, pinnedTime, mealPlans[0].MealTime)
+	assert.Equal(t, uint(1), mealPlans[1].RecipeID)
+}
+
+func TestCreateMealPlans_PinnedServingsBudget(t *testing.T) {
+	cheap, dear := lunchRecipe(1, 600), lunchRecipe(2, 500)
+	(*cheap.RecipeIngredients)[0].Ingredient.PricePerUnit = 300
+	(*dear.RecipeIngredients)[0].Ingredient.PricePerUnit = 500
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{cheap, dear}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 2),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+		TargetBudget:    1000,
+		MaxBudget:       1000,
+	}
+
+	pinnedRecipe := lunchRecipe(3, 500)
+	(*pinnedRecipe.RecipeIngredients)[0].Ingredient.PricePerUnit = 300
+	emp := planner.NewExactMealPlanner(mockRepo, params, units.NewUnitConverter("g", "ml"))
+	emp.SetPinnedMeals([]models.MealPlan{{RecipeID: 3, Recipe: &pinnedRecipe, Servings: 2, MealTime: startDate.Add(12 * time.Hour)}})
+
+	mealPlans, err := emp.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Len(t, mealPlans, 2)
+
+	// Two servings of the pinned meal cost 600, leaving 400 for the free
+	// day: the dearer meal is closer to the target but over budget
+	for _, score := range mealPlans[0].Fitness.Objectives {
+		if score.Objective == models.ObjectiveCost {
+			assert.Equal(t, 600.0, score.Value)
+		}
+	}
+	assert.Equal(t, uint(1), mealPlans[1].RecipeID)
+}
+
+func TestCreateMealPlans_FitnessBreakdown(t *testing.T) {
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{lunchRecipe(1, 500), lunchRecipe(2, 500)}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 2),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 600},
+		MinNutrients:    models.NutritionalValues{Calories: 550},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+	}
+
+	gmp := planner.NewGeneticMealPlanner(20, 10, 0.7, 0.1, mockRepo, params, units.NewUnitConverter("g", "ml"))
+	mealPlans, err := gmp.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Len(t, mealPlans, 2)
+
+	// Equally good recipes are not repeated
+	assert.NotEqual(t, mealPlans[0].RecipeID, mealPlans[1].RecipeID)
+
+	for _, mealPlan := range mealPlans {
+		fitness := mealPlan.Fitness
+		if assert.NotNil(t, fitness) {
+			violations := fitness.Violations()
+			assert.Len(t, violations, 1)
+			assert.Equal(t, "calories", violations[0].Objective)
+			assert.Equal(t, models.ViolationBelowMin, violations[0].Violation)
+			// 50 from the target to the minimum, 2 × 50 below it, plus a
+			// tenth of the cost of 1
+			assert.InDelta(t, 150.1, fitness.Total, 1e-9)
+		}
+	}
+}
+
+func TestCreateMealPlans_JustOutsideLimit(t *testing.T) {
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{lunchRecipe(1, 545), lunchRecipe(2, 555)}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 1),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 600},
+		MinNutrients:    models.NutritionalValues{Calories: 550},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+	}
+
+	gmp := planner.NewGeneticMealPlanner(20, 10, 0.7, 0.1, mockRepo, params, units.NewUnitConverter("g", "ml"))
+	mealPlans, err := gmp.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Len(t, mealPlans, 1)
+
+	// The meal just inside the minimum beats the one just below it, even
+	// though both are about as far from the target
+	assert.Equal(t, uint(2), mealPlans[0].RecipeID)
+	assert.Empty(t, mealPlans[0].Fitness.Violations())
+}
+
+func TestCreateMealPlans_UnconvertedNutrients(t *testing.T) {
+	// Rice has its nutrients per 100 ml, which grams can't be converted to
+	recipe := lunchRecipe(1, 500)
+	(*recipe.RecipeIngredients)[0].Ingredient.Quantity = 100
+	(*recipe.RecipeIngredients)[0].Ingredient.QuantityUnit = "ml"
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{recipe}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 1),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+	}
+
+	mealPlans, err := planner.NewExactMealPlanner(mockRepo, params, units.NewUnitConverter("g", "ml")).CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Len(t, mealPlans, 1)
+
+	// The meal is scored without the rice, and the breakdown says so
+	fitness := mealPlans[0].Fitness
+	assert.Equal(t, []string{"Rice"}, fitness.Unconverted)
+	assert.Contains(t, fitness.Explain(), "Its nutrients leave out Rice, whose quantities couldn't be converted.")
+	for _, score := range fitness.Objectives {
+		if score.Objective == "calories" {
+			assert.Equal(t, 0.0, score.Value)
+		}
+	}
+}
+
+func TestCreateMealPlans_Termination(t *testing.T) {
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{lunchRecipe(1, 500)}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{StartDate: startDate, EndDate: startDate.AddDate(0, 0, 1), Servings: 1}
+
+	// More generations than individuals, without stagnation detection
+	gmp := planner.NewGeneticMealPlanner(2, 30, 0.7, 0.1, mockRepo, params, units.NewUnitConverter("g", "ml"))
+	gmp.SetConvergence(0, 0)
+	mealPlans, err := gmp.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Equal(t, string(planner.TerminationMaxGenerations), mealPlans[0].Fitness.Termination)
+	assert.Equal(t, 30, mealPlans[0].Fitness.Generations)
+	assert.Len(t, gmp.BestFitnessHistory(), 30)
+
+	// A single recipe can't improve, so the run stops after the window
+	gmp = planner.NewGeneticMealPlanner(2, 30, 0.7, 0.1, mockRepo, params, units.NewUnitConverter("g", "ml"))
+	gmp.SetConvergence(5, 0.01)
+	mealPlans, err = gmp.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Equal(t, string(planner.TerminationStagnation), mealPlans[0].Fitness.Termination)
+	assert.Equal(t, 6, mealPlans[0].Fitness.Generations)
+}
+
+func TestCreateMealPlans_Elitism(t *testing.T) {
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{lunchRecipe(1, 500), lunchRecipe(2, 900)}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 1),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+	}
+
+	gmp := planner.NewGeneticMealPlanner(20, 5, 1, 0.1, mockRepo, params, units.NewUnitConverter("g", "ml"))
+	gmp.SetConvergence(0, 0)
+	gmp.SetElitism(1)
+	mealPlans, err := gmp.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), mealPlans[0].RecipeID)
+
+	// The best individual survives every generation at the front
+	assert.Equal(t, uint(1), gmp.Population()[0].RecipeID)
+
+	history := gmp.BestFitnessHistory()
+	for i := 1; i < len(history); i++ {
+		assert.LessOrEqual(t, history[i], history[i-1])
+	}
+}
+
+func TestCreateMealPlans_Islands(t *testing.T) {
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{lunchRecipe(1, 500), lunchRecipe(2, 900), lunchRecipe(3, 100)}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 3),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+	}
+
+	gmp := planner.NewGeneticMealPlanner(10, 10, 0.7, 0.1, mockRepo, params, units.NewUnitConverter("g", "ml"))
+	gmp.SetConvergence(0, 0)
+	gmp.SetIslands(4, 2, 2)
+	mealPlans, err := gmp.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Len(t, mealPlans, 3)
+	assert.Len(t, gmp.Population(), 40)
+
+	// Recipes are loaded once for the whole plan
+	mockRepo.AssertNumberOfCalls(t, "GetRecipesByType", 1)
+	assert.Equal(t, uint(1), mealPlans[0].RecipeID)
+}
+
+func TestCreateMealPlans_RecipeCache(t *testing.T) {
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	// Only nutrients with limits are scored
+	params := models.MealPlanParams{StartDate: startDate, EndDate: startDate.AddDate(0, 0, 1), Servings: 2, MaxNutrients: models.NutritionalValues{Calories: 10000}}
+	unitConverter := units.NewUnitConverter("g", "ml")
+	cache := planner.NewRecipeCache(unitConverter)
+
+	plannedCalories := func(recipe models.Recipe) float64 {
+		mockRepo := new(RecipeRepositoryMock)
+		mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{recipe}, nil)
+
+		gmp := planner.NewGeneticMealPlanner(4, 2, 0.7, 0.1, mockRepo, params, unitConverter)
+		gmp.SetRecipeCache(cache)
+		mealPlans, err := gmp.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+		assert.NoError(t, err)
+		return mealPlans[0].Fitness.Objectives[0].Value
+	}
+
+	recipe := lunchRecipe(1, 100)
+	recipe.Version = 1
+	assert.Equal(t, 200.0, plannedCalories(recipe))
+	assert.Equal(t, 1, cache.Len())
+
+	// Same version: the cached profile is used
+	changed := lunchRecipe(1, 400)
+	changed.Version = 1
+	assert.Equal(t, 200.0, plannedCalories(changed))
+
+	// A new version replaces it
+	changed.Version = 2
+	assert.Equal(t, 800.0, plannedCalories(changed))
+	assert.Equal(t, 1, cache.Len())
+
+	// So does an ingredient saved since, although the version is the same
+	edited := lunchRecipe(1, 300)
+	edited.Version = 2
+	(*edited.RecipeIngredients)[0].Ingredient.UpdatedAt = startDate
+	assert.Equal(t, 600.0, plannedCalories(edited))
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestGeneticMealPlanner_Mutation(t *testing.T) {
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 1),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 100},
+		MaxNutrients:    models.NutritionalValues{Calories: 10000},
+	}
+	unitConverter := units.NewUnitConverter("g", "ml")
+
+	// Recipe 1 is the only one on target
+	var recipes []models.Recipe
+	for id := uint(1); id <= 40; id++ {
+		recipes = append(recipes, lunchRecipe(id, float64(100*id)))
+	}
+	plannedRecipe := func(mutationRate float64, seed int64) uint {
+		mockRepo := new(RecipeRepositoryMock)
+		mockRepo.On("GetRecipesByType", models.Lunch).Return(recipes, nil)
+		gmp := planner.NewGeneticMealPlanner(2, 400, 0, mutationRate, mockRepo, params, unitConverter)
+		gmp.SetConvergence(0, 0)
+		gmp.SetElitism(1)
+		gmp.SetSeed(seed)
+		mealPlans, err := gmp.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+		assert.NoError(t, err)
+		return mealPlans[0].RecipeID
+	}
+
+	// Without mutation a population of two only ever holds the recipes it
+	// started with; with it the rest of the pool is explored
+	missed := 0
+	for seed := int64(1); seed <= 5; seed++ {
+		if plannedRecipe(0, seed) != 1 {
+			missed++
+		}
+		assert.Equal(t, uint(1), plannedRecipe(0.5, seed), "seed %d", seed)
+	}
+	assert.Greater(t, missed, 0)
+}
+
+func TestRecipeCache_MaxSize(t *testing.T) {
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{StartDate: startDate, EndDate: startDate.AddDate(0, 0, 1), Servings: 1}
+	unitConverter := units.NewUnitConverter("g", "ml")
+	cache := planner.NewRecipeCache(unitConverter)
+	cache.SetMaxSize(2)
+
+	for id := uint(1); id <= 3; id++ {
+		mockRepo := new(RecipeRepositoryMock)
+		mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{lunchRecipe(id, 100)}, nil)
+		gmp := planner.NewGeneticMealPlanner(4, 2, 0.7, 0.1, mockRepo, params, unitConverter)
+		gmp.SetRecipeCache(cache)
+		_, err := gmp.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, cache.Len(), 2)
+	}
+	assert.Equal(t, 2, cache.Len())
+
+	cache.SetMaxSize(1)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestPlanners_ExactIsOptimal(t *testing.T) {
+	params := comparisonParams()
+	recipeRepo := fixtures.NewRecipeRepository(fixtures.Recipes(30, 2))
+
+	totals := make(map[string]float64)
+	for _, name := range comparedPlanners {
+		mealPlans, err := newComparisonPlanner(name, recipeRepo, params).CreateMealPlans(params.StartDate, params.EndDate, params.StartDate, models.Lunch)
+		assert.NoError(t, err)
+		assert.Len(t, mealPlans, 7)
+		totals[name] = totalFitness(mealPlans)
+	}
+
+	assert.LessOrEqual(t, totals["exact"], totals["genetic"]+1e-9)
+	assert.LessOrEqual(t, totals["exact"], totals["annealing"]+1e-9)
+}
+
+func TestExactMealPlanner_Variety(t *testing.T) {
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{lunchRecipe(1, 500), lunchRecipe(2, 520), lunchRecipe(3, 900)}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 3),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+	}
+
+	pinnedRecipe := lunchRecipe(2, 520)
+	var progress []planner.Progress
+	exact := planner.NewExactMealPlanner(mockRepo, params, units.NewUnitConverter("g", "ml"))
+	exact.SetProgressFunc(func(p planner.Progress) { progress = append(progress, p) })
+	exact.SetPinnedMeals([]models.MealPlan{{RecipeID: 2, Recipe: &pinnedRecipe, Servings: 1, MealTime: startDate.AddDate(0, 0, 1)}})
+
+	mealPlans, err := exact.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+
+	// Recipe 1 fits best; repeating it (500 + 50) still beats recipe 2,
+	// which is already pinned (520 + 50), and recipe 3 (900)
+	var recipeIDs []uint
+	for _, mealPlan := range mealPlans {
+		recipeIDs = append(recipeIDs, mealPlan.RecipeID)
+	}
+	assert.Equal(t, []uint{1, 2, 1}, recipeIDs)
+	assert.True(t, mealPlans[1].Fitness.Pinned)
+	assert.Equal(t, string(planner.TerminationOptimal), mealPlans[2].Fitness.Termination)
+	if assert.Len(t, progress, 1) {
+		assert.Equal(t, 1.0, progress[0].Fraction())
+	}
+}
+
+func TestSimulatedAnnealingPlanner_CreateMealPlans(t *testing.T) {
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{lunchRecipe(1, 500), lunchRecipe(2, 900), lunchRecipe(3, 100)}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 2),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+	}
+
+	annealing := planner.NewSimulatedAnnealingPlanner(2000, 0, 0.995, mockRepo, params, units.NewUnitConverter("g", "ml"))
+	mealPlans, err := annealing.CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Len(t, mealPlans, 2)
+
+	// The best plan uses recipe 1 once; the second day is worse either way
+	recipeIDs := []uint{mealPlans[0].RecipeID, mealPlans[1].RecipeID}
+	assert.Contains(t, recipeIDs, uint(1))
+	assert.Equal(t, time.Date(2023, 6, 6, 0, 0, 0, 0, time.UTC), mealPlans[1].MealTime)
+}
+
+func TestCreateMealPlans_MicronutrientLimit(t *testing.T) {
+	salty, plain := lunchRecipe(1, 600), lunchRecipe(2, 500)
+	(*salty.RecipeIngredients)[0].Ingredient.Nutrients.Sodium = 1800
+	(*plain.RecipeIngredients)[0].Ingredient.Nutrients.Sodium = 400
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return([]models.Recipe{salty, plain}, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 1),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 600},
+		MaxNutrients:    models.NutritionalValues{Calories: 800, Sodium: 700},
+	}
+
+	mealPlans, err := planner.NewExactMealPlanner(mockRepo, params, units.NewUnitConverter("g", "ml")).CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	assert.Len(t, mealPlans, 1)
+	assert.Equal(t, uint(2), mealPlans[0].RecipeID)
+
+	// Sodium is only a cap, and nutrients without limits aren't scored
+	var objectives []string
+	for _, score := range mealPlans[0].Fitness.Objectives {
+		objectives = append(objectives, score.Objective)
+		if score.Objective == "sodium" {
+			assert.Equal(t, 0.0, score.Penalty)
+		}
+	}
+	assert.Equal(t, []string{"calories", "sodium", models.ObjectiveCost, models.ObjectiveVariety}, objectives)
+}
+
+func TestCreateMealPlans_TagsAndCuisines(t *testing.T) {
+	recipes := []models.Recipe{lunchRecipe(1, 500), lunchRecipe(2, 500), lunchRecipe(3, 500), lunchRecipe(4, 900)}
+	recipes[0].Tags, recipes[0].Cuisine = models.StringList{"vegetarian", "quick"}, "Thai"
+	recipes[1].Tags = models.StringList{"vegetarian", "spicy"}
+	recipes[2].Tags = models.StringList{"fish"}
+	recipes[3].Tags, recipes[3].Cuisine = models.StringList{"Vegetarian"}, "Italian"
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return(recipes, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:        startDate,
+		EndDate:          startDate.AddDate(0, 0, 3),
+		Servings:         1,
+		TargetNutrients:  models.NutritionalValues{Calories: 500},
+		MaxNutrients:     models.NutritionalValues{Calories: 1000},
+		Tags:             []string{" VEGETARIAN"},
+		ExcludedTags:     []string{"spicy"},
+		ExcludedCuisines: []string{"thai"},
+	}
+
+	// Only recipe 4 is vegetarian, not spicy and not Thai, so it's planned
+	// every day despite its calories
+	mealPlans, err := planner.NewExactMealPlanner(mockRepo, params, units.NewUnitConverter("g", "ml")).CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+	for _, mealPlan := range mealPlans {
+		assert.Equal(t, uint(4), mealPlan.RecipeID)
+	}
+
+	params.Tags, params.ExcludedTags, params.ExcludedCuisines = nil, nil, nil
+	params.Cuisines = []string{"Mexican"}
+	_, err = planner.NewExactMealPlanner(mockRepo, params, units.NewUnitConverter("g", "ml")).CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.ErrorIs(t, err, planner.ErrNoRecipes)
+}
+
+func TestExactMealPlanner_CuisineVariety(t *testing.T) {
+	recipes := []models.Recipe{lunchRecipe(1, 500), lunchRecipe(2, 505), lunchRecipe(3, 508)}
+	recipes[0].Cuisine, recipes[1].Cuisine, recipes[2].Cuisine = "Italian", "italian", "Mexican"
+	mockRepo := new(RecipeRepositoryMock)
+	mockRepo.On("GetRecipesByType", models.Lunch).Return(recipes, nil)
+
+	startDate := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	params := models.MealPlanParams{
+		StartDate:       startDate,
+		EndDate:         startDate.AddDate(0, 0, 2),
+		Servings:        1,
+		TargetNutrients: models.NutritionalValues{Calories: 500},
+		MaxNutrients:    models.NutritionalValues{Calories: 1000},
+	}
+
+	mealPlans, err := planner.NewExactMealPlanner(mockRepo, params, units.NewUnitConverter("g", "ml")).CreateMealPlans(params.StartDate, params.EndDate, startDate, models.Lunch)
+	assert.NoError(t, err)
+
+	// Recipe 2 fits better than recipe 3, but a second Italian meal
+	// (5 + 10) costs more than a Mexican one (8)
+	assert.Equal(t, []uint{1, 3}, []uint{mealPlans[0].RecipeID, mealPlans[1].RecipeID})
+	var cuisineVariety []models.ObjectiveScore
+	for _, score := range mealPlans[1].Fitness.Objectives {
+		if score.Objective == models.ObjectiveCuisineVariety {
+			cuisineVariety = append(cuisineVariety, score)
+		}
+	}
+	assert.Equal(t, []models.ObjectiveScore{{Objective: models.ObjectiveCuisineVariety}}, cuisineVariety)
+}
+
+func TestGeneticTuning_Off(t *testing.T) {
+	defaults := planner.GeneticTuning{PopulationSize: 10, MaxGenerations: 5, StagnationWindow: 5, Elitism: 2}
+	tuning := planner.GeneticTuning{StagnationWindow: planner.TuningOff, Elitism: planner.TuningOff}
+
+	// Requests are prepared again when they're run, which must not bring the
+	// defaults back
+	tuning = tuning.WithDefaults(defaults).WithDefaults(defaults)
+	assert.Equal(t, planner.TuningOff, tuning.StagnationWindow)
+	assert.Equal(t, planner.TuningOff, tuning.Elitism)
+	assert.NoError(t, tuning.Validate(planner.DefaultTuningLimits))
+
+	tuning.StagnationWindow = -2
+	tuning.Elitism = -2
+	assert.Error(t, tuning.Validate(planner.DefaultTuningLimits))
+}
@@ -1,6 +1,7 @@
 package planner
 
 import (
+	"context"
 	"time"
 
 	"github.com/cvele/recipe/pkg/models"
@@ -14,3 +15,19 @@ type MealPlanner interface {
 		mealType models.MealType,
 	) ([]models.MealPlan, error)
 }
+
+// ContextMealPlanner is a MealPlanner the Service can run: it can be
+// cancelled, reports progress and plans around pinned meals.
+type ContextMealPlanner interface {
+	MealPlanner
+	CreateMealPlansContext(
+		ctx context.Context,
+		startDate time.Time,
+		endDate time.Time,
+		mealTime time.Time,
+		mealType models.MealType,
+	) ([]models.MealPlan, error)
+	SetProgressFunc(fn ProgressFunc)
+	SetPinnedMeals(mealPlans []models.MealPlan)
+	SetRecipeCache(cache *RecipeCache)
+}
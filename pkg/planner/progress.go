@@ -0,0 +1,41 @@
+package planner
+
+// TerminationReason says why a planner stopped evolving a slot.
+type TerminationReason string
+
+const (
+	TerminationMaxGenerations TerminationReason = "max_generations"
+	TerminationStagnation     TerminationReason = "stagnation"
+	TerminationOptimal        TerminationReason = "optimal" // the exact planner proved the plan optimal
+)
+
+// Progress is reported by planners while they run. The last update for
+// each day carries the reason planning of that day stopped, and its best
+// recipe is the one chosen for the day. Planners that plan all days at once
+// report a single day, and count their steps as generations.
+type Progress struct {
+	Day               int               `json:"day"`  // index of the day being planned
+	Days              int               `json:"days"` // number of days in the plan
+	Generation        int               `json:"generation"`
+	MaxGenerations    int               `json:"max_generations"`
+	BestFitness       float64           `json:"best_fitness"`
+	BestRecipeID      uint              `json:"best_recipe_id"`
+	BestRecipeTitle   string            `json:"best_recipe_title"`
+	TerminationReason TerminationReason `json:"termination_reason,omitempty"`
+}
+
+// ProgressFunc receives progress updates. It is called synchronously from
+// the planner and should return quickly.
+type ProgressFunc func(Progress)
+
+// Fraction estimates how much of the plan is complete, between 0 and 1.
+func (p Progress) Fraction() float64 {
+	if p.Days == 0 || p.MaxGenerations == 0 {
+		return 0
+	}
+	if p.TerminationReason != "" {
+		return float64(p.Day+1) / float64(p.Days)
+	}
+	dayFraction := float64(p.Generation+1) / float64(p.MaxGenerations)
+	return (float64(p.Day) + dayFraction) / float64(p.Days)
+}
@@ -0,0 +1,146 @@
+package planner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/nutrition"
+	"github.com/cvele/recipe/pkg/units"
+)
+
+// recipeProfile is what a recipe contributes to fitness, per serving.
+type recipeProfile struct {
+	version   int
+	updatedAt time.Time // of the most recently changed ingredient
+	cuisine   string    // as counted for variety
+	nutrients models.NutritionalValues
+	cost      float64 // in cents
+	// Ingredients whose quantities can't be converted, left out of nutrients
+	unconverted []string
+}
+
+// scaled returns the nutrients and cost of servings servings.
+func (p recipeProfile) scaled(servings int) (models.NutritionalValues, float64) {
+	return p.nutrients.Scale(float64(servings)), p.cost * float64(servings)
+}
+
+// newRecipeProfile sums the recipe's ingredients, nutrients by the
+// ingredients' reference quantities and cost in the converter's default
+// units, and divides them by the recipe's servings. Nutrients leave out
+// quantities that can't be converted, as nutrition facts do; costs use them
+// as they are.
+func newRecipeProfile(recipe models.Recipe, converter units.UnitConverterInterface) recipeProfile {
+	profile := recipeProfile{version: recipe.Version, updatedAt: ingredientsUpdatedAt(recipe), cuisine: cuisineKey(recipe)}
+	if recipe.RecipeIngredients == nil {
+		return profile
+	}
+
+	for _, recipeIngredient := range *recipe.RecipeIngredients {
+		ingredient := recipeIngredient.Ingredient
+		nutrients, err := nutrition.IngredientNutrients(recipeIngredient, converter)
+		if err != nil {
+			profile.unconverted = append(profile.unconverted, ingredient.Name)
+		}
+		profile.nutrients = profile.nutrients.Add(nutrients)
+		profile.cost += float64(ingredient.PricePerUnit) * nutrition.IngredientQuantity(recipeIngredient, converter)
+	}
+
+	if recipe.Servings > 0 {
+		profile.nutrients = profile.nutrients.Scale(1 / float64(recipe.Servings))
+		profile.cost /= float64(recipe.Servings)
+	}
+	return profile
+}
+
+// ingredientsUpdatedAt returns when the recipe's most recently changed
+// ingredient was last saved. Catalog edits change ingredients without
+// saving a new version of the recipe.
+func ingredientsUpdatedAt(recipe models.Recipe) time.Time {
+	var updatedAt time.Time
+	if recipe.RecipeIngredients == nil {
+		return updatedAt
+	}
+	for _, recipeIngredient := range *recipe.RecipeIngredients {
+		if recipeIngredient.Ingredient.UpdatedAt.After(updatedAt) {
+			updatedAt = recipeIngredient.Ingredient.UpdatedAt
+		}
+	}
+	return updatedAt
+}
+
+// DefaultRecipeCacheSize is how many recipes a RecipeCache keeps unless
+// SetMaxSize says otherwise.
+const DefaultRecipeCacheSize = 10000
+
+// RecipeCache keeps recipe profiles between planning runs so that recipes
+// are only summed again after they change. Entries are keyed by recipe ID
+// and replaced when the recipe's version differs or one of its ingredients
+// was saved since. When the cache is full, an arbitrary entry makes room
+// for a new one. It is safe for concurrent use.
+type RecipeCache struct {
+	converter units.UnitConverterInterface
+	mu        sync.RWMutex
+	profiles  map[uint]recipeProfile
+	maxSize   int
+}
+
+func NewRecipeCache(converter units.UnitConverterInterface) *RecipeCache {
+	return &RecipeCache{
+		converter: converter,
+		profiles:  make(map[uint]recipeProfile),
+		maxSize:   DefaultRecipeCacheSize,
+	}
+}
+
+// SetMaxSize sets how many recipes the cache keeps, evicting entries
+// beyond it.
+func (c *RecipeCache) SetMaxSize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxSize = size
+	c.evict(0)
+}
+
+// Len returns the number of cached recipes.
+func (c *RecipeCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.profiles)
+}
+
+func (c *RecipeCache) profile(recipe models.Recipe) recipeProfile {
+	// Unsaved recipes have no identity to cache them by
+	if recipe.ID == 0 {
+		return newRecipeProfile(recipe, c.converter)
+	}
+
+	c.mu.RLock()
+	profile, ok := c.profiles[recipe.ID]
+	c.mu.RUnlock()
+	if ok && profile.version == recipe.Version && profile.updatedAt.Equal(ingredientsUpdatedAt(recipe)) {
+		return profile
+	}
+
+	profile = newRecipeProfile(recipe, c.converter)
+	c.mu.Lock()
+	if _, cached := c.profiles[recipe.ID]; !cached {
+		c.evict(1)
+	}
+	if c.maxSize > 0 {
+		c.profiles[recipe.ID] = profile
+	}
+	c.mu.Unlock()
+	return profile
+}
+
+// evict removes entries until room more of them fit. The caller holds
+// the lock.
+func (c *RecipeCache) evict(room int) {
+	for id := range c.profiles {
+		if len(c.profiles)+room <= c.maxSize {
+			return
+		}
+		delete(c.profiles, id)
+	}
+}
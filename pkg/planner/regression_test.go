@@ -0,0 +1,130 @@
+package planner_test
+
+import (
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/cvele/recipe/pkg/fixtures"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var updateBaselines = flag.Bool("update-baselines", false, "rewrite testdata/baselines.json from the current planners")
+
+const baselinesFile = "testdata/baselines.json"
+
+// fitnessTolerance is how much worse than its baseline, relative to it, a
+// plan's fitness may get before the test fails. It only absorbs floating
+// point differences between platforms; the runs are seeded.
+const fitnessTolerance = 0.001
+
+// allocsTolerance is how many more allocations than its baseline, relative
+// to it, a plan may take. Goroutine scheduling makes the count vary a
+// little between runs. Time isn't checked since it depends on the machine;
+// BenchmarkPlanners measures it.
+const allocsTolerance = 0.2
+
+// qualityBaseline is what a planner achieved on a catalog when the baseline
+// was recorded.
+type qualityBaseline struct {
+	Fitness   float64 `json:"fitness"`
+	Satisfied float64 `json:"satisfied"`
+	Allocs    float64 `json:"allocs"`
+}
+
+func loadBaselines(t *testing.T) map[string]qualityBaseline {
+	baselines := make(map[string]qualityBaseline)
+	data, err := os.ReadFile(baselinesFile)
+	if os.IsNotExist(err) {
+		return baselines
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &baselines); err != nil {
+		t.Fatal(err)
+	}
+	return baselines
+}
+
+func saveBaselines(t *testing.T, baselines map[string]qualityBaseline) {
+	// encoding/json sorts map keys, which keeps the file's diffs readable
+	data, err := json.MarshalIndent(baselines, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(baselinesFile, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPlannerQuality plans the same week with every planner on every
+// catalog and fails when a plan's fitness or share of meals within limits
+// got worse, or it allocated noticeably more, than its recorded baseline. After an intended change, record
+// new baselines with:
+//
+//	go test ./pkg/planner -run TestPlannerQuality -update-baselines
+func TestPlannerQuality(t *testing.T) {
+	params := comparisonParams()
+	baselines := loadBaselines(t)
+	results := make(map[string]qualityBaseline)
+
+	for _, size := range catalogSizes {
+		recipeRepo := fixtures.NewRecipeRepository(fixtures.Recipes(size, 1))
+
+		for _, name := range comparedPlanners {
+			key := name + "/" + strconv.Itoa(size)
+			mealPlanner := newComparisonPlanner(name, recipeRepo, params)
+
+			var mealPlans []models.MealPlan
+			var err error
+			allocs := testing.AllocsPerRun(1, func() {
+				mealPlans, err = mealPlanner.CreateMealPlans(params.StartDate, params.EndDate, params.StartDate, models.Lunch)
+			})
+			if !assert.NoError(t, err, key) {
+				continue
+			}
+			result := qualityBaseline{Fitness: totalFitness(mealPlans), Satisfied: satisfiedMeals(mealPlans), Allocs: allocs}
+			results[key] = result
+
+			baseline, ok := baselines[key]
+			if *updateBaselines {
+				continue
+			}
+			if !ok {
+				t.Errorf("%s: no baseline, record one with -update-baselines", key)
+				continue
+			}
+
+			// Fitness may be negative, so the tolerance is taken of its size
+			slack := math.Abs(baseline.Fitness) * fitnessTolerance
+			assert.LessOrEqual(t, result.Fitness, baseline.Fitness+slack, "%s: fitness regressed", key)
+			assert.GreaterOrEqual(t, result.Satisfied, baseline.Satisfied, "%s: fewer meals within limits", key)
+			assert.LessOrEqual(t, result.Allocs, baseline.Allocs*(1+allocsTolerance), "%s: allocations regressed", key)
+			if result.Fitness < baseline.Fitness-slack {
+				t.Logf("%s: fitness improved from %.2f to %.2f, consider updating the baselines", key, baseline.Fitness, result.Fitness)
+			}
+		}
+	}
+
+	if *updateBaselines {
+		saveBaselines(t, results)
+		return
+	}
+
+	// Baselines for planners or catalogs that are gone would hide nothing,
+	// but they'd go stale silently
+	var stale []string
+	for key := range baselines {
+		if _, ok := results[key]; !ok {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(stale)
+	assert.Empty(t, stale, "baselines without a run")
+}
@@ -0,0 +1,462 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/nutrition"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
+	"github.com/jinzhu/gorm"
+)
+
+// PinnedMeal fixes a recipe on the day of MealTime. Zero servings use the
+// request's servings.
+type PinnedMeal struct {
+	RecipeID uint      `json:"recipe_id"`
+	MealTime time.Time `json:"meal_time"`
+	Servings int       `json:"servings"`
+}
+
+// Request is MealPlanParams plus how to run the planner. MealType names the
+// meal slot to plan. MealTime sets the time of day of every planned meal,
+// the slot's default time when it is zero. Algorithm picks the planner,
+// genetic by default; zero tuning fields use the service's defaults.
+//
+// With HouseholdID set, which also needs the UserID of the household's
+// owner, the household's members attending the meal slot are the plan's
+// diners, their dietary restrictions exclude recipes and, unless
+// given, the servings are what the diners eat together.
+//
+// With a Profile, nutrient limits left at zero are recommended for the
+// person's share of the day's intake at the meal slot, times the servings.
+// Members of a household have their own profiles instead.
+//
+// Pinned meals are kept and only the other days are planned. KeepExisting
+// also keeps the user's stored meals of the same meal type, except on the
+// Regenerate days, whose meals are planned again and replaced when the plan
+// is persisted.
+type Request struct {
+	models.MealPlanParams
+	UserID       uint            `json:"user_id"`
+	HouseholdID  uint            `json:"household_id"`
+	Profile      *models.Profile `json:"profile,omitempty"`
+	MealTime     time.Time       `json:"meal_time"`
+	Algorithm    Algorithm       `json:"algorithm"`
+	Tuning       GeneticTuning   `json:"tuning"`
+	Annealing    AnnealingTuning `json:"annealing"`
+	Persist      bool            `json:"persist"`
+	Pinned       []PinnedMeal    `json:"pinned"`
+	KeepExisting bool            `json:"keep_existing"`
+	Regenerate   []time.Time     `json:"regenerate"`
+}
+
+// Validate checks the request, with tunings bounded by limits.
+func (r Request) Validate(limits TuningLimits) error {
+	var errs models.ValidationErrors
+
+	var paramErrs models.ValidationErrors
+	if errors.As(r.MealPlanParams.Validate(), &paramErrs) {
+		errs = append(errs, paramErrs...)
+	}
+	switch r.Algorithm {
+	case "", AlgorithmGenetic:
+		var tuningErrs models.ValidationErrors
+		if errors.As(r.Tuning.Validate(limits), &tuningErrs) {
+			errs = append(errs, tuningErrs...)
+		}
+	case AlgorithmAnnealing:
+		var tuningErrs models.ValidationErrors
+		if errors.As(r.Annealing.Validate(limits), &tuningErrs) {
+			errs = append(errs, tuningErrs...)
+		}
+	case AlgorithmExact:
+	default:
+		errs.Add("algorithm", "must be one of genetic, annealing or exact")
+	}
+	if r.Persist && r.UserID == 0 {
+		errs.Add("user_id", "is required to persist the plan")
+	}
+	if r.KeepExisting && r.UserID == 0 {
+		errs.Add("user_id", "is required to keep existing meals")
+	}
+	if r.HouseholdID != 0 && r.UserID == 0 {
+		errs.Add("user_id", "is required to plan for a household")
+	}
+	if len(r.Regenerate) > 0 && !r.KeepExisting {
+		errs.Add("regenerate", "requires keep_existing")
+	}
+	if r.Profile != nil {
+		var profileErrs models.ValidationErrors
+		if errors.As(r.Profile.Validate(), &profileErrs) {
+			for _, e := range profileErrs {
+				errs.Add("profile."+e.Field, e.Message)
+			}
+		}
+		var recommendErrs models.ValidationErrors
+		if errors.As(r.Profile.ValidateRecommendable(), &recommendErrs) {
+			for _, e := range recommendErrs {
+				errs.Add("profile."+e.Field, e.Message)
+			}
+		}
+	}
+
+	pinnedDays := make(map[int]bool)
+	for i, pinned := range r.Pinned {
+		field := fmt.Sprintf("pinned[%d]", i)
+		if pinned.RecipeID == 0 {
+			errs.Add(field+".recipe_id", "is required")
+		}
+		if pinned.Servings < 0 {
+			errs.Add(field+".servings", "must not be negative")
+		}
+		if !r.inPeriod(pinned.MealTime) {
+			errs.Add(field+".meal_time", "must be between start_date and end_date")
+			continue
+		}
+		day := dayIndex(r.StartDate, pinned.MealTime)
+		if pinnedDays[day] {
+			errs.Add(field+".meal_time", "another meal is already pinned on this day")
+		}
+		pinnedDays[day] = true
+	}
+	for i, day := range r.Regenerate {
+		if !r.inPeriod(day) {
+			errs.Add(fmt.Sprintf("regenerate[%d]", i), "must be between start_date and end_date")
+		}
+	}
+
+	return errs.Err()
+}
+
+func (r Request) inPeriod(t time.Time) bool {
+	return !t.Before(r.StartDate) && t.Before(r.EndDate)
+}
+
+// Service runs planning requests with the genetic planner and optionally
+// stores the results. Without a meal slot repository only the default meal
+// slots can be planned.
+type Service struct {
+	recipeRepo    repositories.RecipeRepositoryInterface
+	mealPlanRepo  repositories.MealPlanRepository
+	householdRepo repositories.HouseholdRepository
+	mealSlotRepo  repositories.MealSlotRepository
+	unitConverter units.UnitConverterInterface
+	defaults      GeneticTuning
+	limits        TuningLimits
+	recipeCache   *RecipeCache
+}
+
+func NewService(
+	recipeRepo repositories.RecipeRepositoryInterface,
+	mealPlanRepo repositories.MealPlanRepository,
+	householdRepo repositories.HouseholdRepository,
+	mealSlotRepo repositories.MealSlotRepository,
+	unitConverter units.UnitConverterInterface,
+	defaults GeneticTuning,
+) *Service {
+	return &Service{
+		recipeRepo:    recipeRepo,
+		mealPlanRepo:  mealPlanRepo,
+		householdRepo: householdRepo,
+		mealSlotRepo:  mealSlotRepo,
+		unitConverter: unitConverter,
+		defaults:      defaults,
+		limits:        DefaultTuningLimits,
+		recipeCache:   NewRecipeCache(unitConverter),
+	}
+}
+
+// SetTuningLimits bounds the tunings requests may ask for.
+func (s *Service) SetTuningLimits(limits TuningLimits) {
+	s.limits = limits
+}
+
+// Prepare fills in the default algorithm and tuning and the household's
+// diners, and validates the request and that its meal slot and pinned
+// recipes exist.
+func (s *Service) Prepare(req Request) (Request, error) {
+	if req.Algorithm == "" {
+		req.Algorithm = AlgorithmGenetic
+	}
+	var errs models.ValidationErrors
+	slot, err := s.mealSlot(req.MealType)
+	if err != nil && !errors.As(err, &errs) {
+		return req, err
+	}
+	switch {
+	case err != nil:
+		// Households and profiles need the slot; the rest of the request
+		// is still validated.
+	case req.HouseholdID != 0 && req.UserID == 0:
+		// Without a user the household's owner can't be checked, which
+		// Validate reports
+	case req.HouseholdID != 0:
+		if req, err = s.withHousehold(req, slot); err != nil {
+			return req, err
+		}
+	case req.Profile != nil && req.Profile.CanRecommend():
+		perMeal := nutrition.DailyTarget(*req.Profile).Scale(slot.Share * float64(req.Servings))
+		intake := nutrition.Complete(req.TargetNutrients, req.MinNutrients, req.MaxNutrients, perMeal)
+		req.TargetNutrients = intake.TargetNutrients
+		req.MinNutrients = intake.MinNutrients
+		req.MaxNutrients = intake.MaxNutrients
+	}
+	req.Tuning = req.Tuning.WithDefaults(s.defaults)
+	req.Annealing = req.Annealing.WithDefaults(DefaultAnnealingTuning)
+	var requestErrs models.ValidationErrors
+	if err := req.Validate(s.limits); err != nil && !errors.As(err, &requestErrs) {
+		return req, err
+	}
+	errs = append(errs, requestErrs...)
+	if len(errs) > 0 {
+		return req, errs
+	}
+	if _, err := s.pinnedRecipes(req); err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// Plan creates one meal per day for the request, reporting progress to
+// onProgress when it is not nil. With Persist set the meals are stored for
+// the user before they are returned.
+func (s *Service) Plan(ctx context.Context, req Request, onProgress ProgressFunc) ([]models.MealPlan, error) {
+	req, err := s.Prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	mealPlanner := s.newPlanner(req)
+	mealPlanner.SetProgressFunc(onProgress)
+	mealPlanner.SetRecipeCache(s.recipeCache)
+
+	pinned, replaced, err := s.pinnedMeals(req)
+	if err != nil {
+		return nil, err
+	}
+	mealPlanner.SetPinnedMeals(pinned)
+
+	slot, err := s.mealSlot(req.MealType)
+	if err != nil {
+		return nil, err
+	}
+	mealTime := req.MealTime
+	if mealTime.IsZero() {
+		mealTime = slot.At(req.StartDate)
+	}
+
+	mealPlans, err := mealPlanner.CreateMealPlansContext(ctx, req.StartDate, req.EndDate, mealTime, req.MealType)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range mealPlans {
+		mealPlans[i].UserID = req.UserID
+		if mealPlans[i].Duration == 0 {
+			mealPlans[i].Duration = slot.Duration
+		}
+	}
+
+	if req.Persist {
+		if req.KeepExisting {
+			err = s.mealPlanRepo.Replace(replaced, mealPlans)
+		} else {
+			err = s.mealPlanRepo.CreateAll(mealPlans)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return mealPlans, nil
+}
+
+// mealSlot returns the meal slot named mealType, reporting a missing or
+// unknown slot as a validation error.
+func (s *Service) mealSlot(mealType models.MealType) (models.MealSlot, error) {
+	var errs models.ValidationErrors
+	if mealType == "" {
+		errs.Add("meal_type", "is required")
+		return models.MealSlot{}, errs
+	}
+
+	if s.mealSlotRepo == nil {
+		if slot, ok := models.DefaultMealSlot(mealType); ok {
+			return slot, nil
+		}
+		errs.Add("meal_type", "is not a meal slot")
+		return models.MealSlot{}, errs
+	}
+
+	slot, err := s.mealSlotRepo.FindByName(mealType)
+	if gorm.IsRecordNotFoundError(err) {
+		errs.Add("meal_type", "is not a meal slot")
+		return models.MealSlot{}, errs
+	}
+	if err != nil {
+		return models.MealSlot{}, fmt.Errorf("meal slot %s: %w", mealType, err)
+	}
+	return *slot, nil
+}
+
+// withHousehold plans the request for the household's members attending
+// the meal slot, recommending the targets they haven't set.
+func (s *Service) withHousehold(req Request, slot models.MealSlot) (Request, error) {
+	var errs models.ValidationErrors
+	if s.householdRepo == nil {
+		errs.Add("household_id", "households are not available")
+		return req, errs
+	}
+
+	household, err := s.householdRepo.FindByID(req.HouseholdID)
+	if gorm.IsRecordNotFoundError(err) {
+		errs.Add("household_id", "does not exist")
+		return req, errs
+	}
+	if err != nil {
+		return req, fmt.Errorf("household %d: %w", req.HouseholdID, err)
+	}
+	if household.UserID != req.UserID {
+		errs.Add("household_id", "does not belong to the user")
+		return req, errs
+	}
+
+	for i, member := range household.Members {
+		household.Members[i] = nutrition.CompleteMember(member)
+	}
+	diners := household.Diners(slot)
+	if len(diners) == 0 {
+		errs.Add("household_id", fmt.Sprintf("no member attends %s", req.MealType))
+		return req, errs
+	}
+
+	req.Diners = diners
+	req.ExcludedIngredients = append(req.ExcludedIngredients, household.Restrictions(req.MealType)...)
+	if req.Servings == 0 {
+		req.Servings = models.DinerServings(diners)
+	}
+	return req, nil
+}
+
+// newPlanner returns the planner for the request's algorithm.
+func (s *Service) newPlanner(req Request) ContextMealPlanner {
+	switch req.Algorithm {
+	case AlgorithmAnnealing:
+		return NewSimulatedAnnealingPlanner(
+			req.Annealing.Steps,
+			req.Annealing.InitialTemperature,
+			req.Annealing.CoolingRate,
+			s.recipeRepo,
+			req.MealPlanParams,
+			s.unitConverter,
+		)
+	case AlgorithmExact:
+		return NewExactMealPlanner(s.recipeRepo, req.MealPlanParams, s.unitConverter)
+	default:
+		gmp := NewGeneticMealPlanner(
+			req.Tuning.PopulationSize,
+			req.Tuning.MaxGenerations,
+			req.Tuning.CrossoverRate,
+			req.Tuning.MutationRate,
+			s.recipeRepo,
+			req.MealPlanParams,
+			s.unitConverter,
+		)
+		gmp.SetConvergence(req.Tuning.StagnationWindow, req.Tuning.StagnationThreshold)
+		gmp.SetElitism(req.Tuning.Elitism)
+		gmp.SetIslands(req.Tuning.Islands, req.Tuning.MigrationInterval, req.Tuning.Migrants)
+		return gmp
+	}
+}
+
+// pinnedRecipes loads the recipes of the request's pinned meals, in order,
+// reporting the ones that don't exist as validation errors.
+func (s *Service) pinnedRecipes(req Request) ([]*models.Recipe, error) {
+	var errs models.ValidationErrors
+	recipes := make([]*models.Recipe, len(req.Pinned))
+	for i, pin := range req.Pinned {
+		recipe, err := s.recipeRepo.GetRecipeByID(pin.RecipeID)
+		if gorm.IsRecordNotFoundError(err) {
+			errs.Add(fmt.Sprintf("pinned[%d].recipe_id", i), "does not exist")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pinned recipe %d: %w", pin.RecipeID, err)
+		}
+		recipes[i] = recipe
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return recipes, nil
+}
+
+// pinnedMeals loads the request's pinned recipes and, with KeepExisting, the
+// user's stored meals that are kept. It also returns the IDs of stored meals
+// that the new plan replaces.
+func (s *Service) pinnedMeals(req Request) ([]models.MealPlan, []uint, error) {
+	var pinned []models.MealPlan
+	pinnedDays := make(map[int]bool)
+
+	recipes, err := s.pinnedRecipes(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, pin := range req.Pinned {
+		recipe := recipes[i]
+		servings := pin.Servings
+		if servings == 0 {
+			servings = req.Servings
+		}
+
+		pinned = append(pinned, models.MealPlan{
+			RecipeID:      recipe.ID,
+			Recipe:        recipe,
+			RecipeVersion: recipe.Version,
+			Servings:      servings,
+			MealType:      req.MealType,
+			MealTime:      pin.MealTime,
+		})
+		pinnedDays[dayIndex(req.StartDate, pin.MealTime)] = true
+	}
+
+	if !req.KeepExisting {
+		return pinned, nil, nil
+	}
+
+	regenerate := make(map[int]bool)
+	for _, day := range req.Regenerate {
+		regenerate[dayIndex(req.StartDate, day)] = true
+	}
+
+	existing, err := s.mealPlanRepo.FindByUserIDAndDateRange(req.UserID, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var replaced []uint
+	kept := make(map[int]bool)
+	for _, mealPlan := range existing {
+		if mealPlan.MealType != req.MealType {
+			continue
+		}
+
+		day := dayIndex(req.StartDate, mealPlan.MealTime)
+		if regenerate[day] || pinnedDays[day] {
+			replaced = append(replaced, mealPlan.ID)
+			continue
+		}
+		if kept[day] {
+			continue
+		}
+
+		pinned = append(pinned, *mealPlan)
+		kept[day] = true
+	}
+
+	return pinned, replaced, nil
+}
@@ -0,0 +1,198 @@
+package planner
+
+import (
+	"fmt"
+
+	"github.com/cvele/recipe/pkg/models"
+)
+
+// GeneticTuning holds the parameters that control a GeneticMealPlanner run.
+//
+// A day stops evolving early when the best fitness improved by no more than
+// StagnationThreshold over the last StagnationWindow generations. Elitism is
+// the number of best individuals carried over unchanged into every new
+// generation. As a zero field takes its default, a StagnationWindow or
+// Elitism of TuningOff turns the feature off instead.
+//
+// Islands is the number of populations of PopulationSize that evolve in
+// parallel. Every MigrationInterval generations each island sends its
+// Migrants best individuals to the next one.
+type GeneticTuning struct {
+	PopulationSize      int     `json:"population_size"`
+	MaxGenerations      int     `json:"max_generations"`
+	CrossoverRate       float64 `json:"crossover_rate"`
+	MutationRate        float64 `json:"mutation_rate"`
+	StagnationWindow    int     `json:"stagnation_window"`
+	StagnationThreshold float64 `json:"stagnation_threshold"`
+	Elitism             int     `json:"elitism"`
+	Islands             int     `json:"islands"`
+	MigrationInterval   int     `json:"migration_interval"`
+	Migrants            int     `json:"migrants"`
+}
+
+// TuningOff turns off stagnation checks or elitism when used as their
+// tuning value.
+const TuningOff = -1
+
+// WithDefaults returns a copy of t with every zero field taken from
+// defaults.
+func (t GeneticTuning) WithDefaults(defaults GeneticTuning) GeneticTuning {
+	if t.PopulationSize == 0 {
+		t.PopulationSize = defaults.PopulationSize
+	}
+	if t.MaxGenerations == 0 {
+		t.MaxGenerations = defaults.MaxGenerations
+	}
+	if t.CrossoverRate == 0 {
+		t.CrossoverRate = defaults.CrossoverRate
+	}
+	if t.MutationRate == 0 {
+		t.MutationRate = defaults.MutationRate
+	}
+	if t.StagnationWindow == 0 {
+		t.StagnationWindow = defaults.StagnationWindow
+	}
+	if t.StagnationThreshold == 0 {
+		t.StagnationThreshold = defaults.StagnationThreshold
+	}
+	if t.Elitism == 0 {
+		t.Elitism = defaults.Elitism
+	}
+	if t.Islands == 0 {
+		t.Islands = defaults.Islands
+	}
+	if t.MigrationInterval == 0 {
+		t.MigrationInterval = defaults.MigrationInterval
+	}
+	if t.Migrants == 0 {
+		t.Migrants = defaults.Migrants
+	}
+	return t
+}
+
+// TuningLimits are the largest runs a request may ask for, so that a single
+// request can't exhaust the server's memory or CPU. A zero limit leaves the
+// value unbounded.
+// Every island holds a full population, so islands count towards memory as
+// much as the population size does.
+type TuningLimits struct {
+	MaxPopulationSize int
+	MaxGenerations    int
+	MaxIslands        int
+	MaxAnnealingSteps int
+}
+
+// DefaultTuningLimits are used unless the service is given its own.
+var DefaultTuningLimits = TuningLimits{
+	MaxPopulationSize: 1000,
+	MaxGenerations:    1000,
+	MaxIslands:        64,
+	MaxAnnealingSteps: 1000000,
+}
+
+// Validate checks the tuning, including that it stays within limits.
+func (t GeneticTuning) Validate(limits TuningLimits) error {
+	var errs models.ValidationErrors
+
+	// Tournament selection needs two distinct competitors
+	if t.PopulationSize < 2 {
+		errs.Add("tuning.population_size", "must be at least 2")
+	} else if exceeds(t.PopulationSize, limits.MaxPopulationSize) {
+		errs.Add("tuning.population_size", fmt.Sprintf("must be at most %d", limits.MaxPopulationSize))
+	}
+	if t.MaxGenerations < 1 {
+		errs.Add("tuning.max_generations", "must be at least 1")
+	} else if exceeds(t.MaxGenerations, limits.MaxGenerations) {
+		errs.Add("tuning.max_generations", fmt.Sprintf("must be at most %d", limits.MaxGenerations))
+	}
+	if t.CrossoverRate < 0 || t.CrossoverRate > 1 {
+		errs.Add("tuning.crossover_rate", "must be between 0 and 1")
+	}
+	if t.MutationRate < 0 || t.MutationRate > 1 {
+		errs.Add("tuning.mutation_rate", "must be between 0 and 1")
+	}
+	if t.StagnationWindow < TuningOff {
+		errs.Add("tuning.stagnation_window", "must be -1 (off) or more")
+	}
+	if t.StagnationThreshold < 0 {
+		errs.Add("tuning.stagnation_threshold", "must not be negative")
+	}
+	if t.Elitism < TuningOff || t.Elitism >= t.PopulationSize {
+		errs.Add("tuning.elitism", "must be -1 (off) or between 0 and population_size - 1")
+	}
+	if t.Islands < 0 {
+		errs.Add("tuning.islands", "must not be negative")
+	} else if exceeds(t.Islands, limits.MaxIslands) {
+		errs.Add("tuning.islands", fmt.Sprintf("must be at most %d", limits.MaxIslands))
+	}
+	if t.MigrationInterval < 0 {
+		errs.Add("tuning.migration_interval", "must not be negative")
+	}
+	if t.Migrants < 0 || t.Migrants >= t.PopulationSize {
+		errs.Add("tuning.migrants", "must be between 0 and population_size - 1")
+	}
+
+	return errs.Err()
+}
+
+// exceeds reports whether value is above limit, where zero means no limit.
+func exceeds(value int, limit int) bool {
+	return limit > 0 && value > limit
+}
+
+// Algorithm selects the planner that runs a request.
+type Algorithm string
+
+const (
+	AlgorithmGenetic   Algorithm = "genetic"
+	AlgorithmAnnealing Algorithm = "annealing"
+	AlgorithmExact     Algorithm = "exact"
+)
+
+// AnnealingTuning holds the parameters of a SimulatedAnnealingPlanner run.
+// A zero initial temperature is derived from the recipes.
+type AnnealingTuning struct {
+	Steps              int     `json:"steps"`
+	InitialTemperature float64 `json:"initial_temperature"`
+	CoolingRate        float64 `json:"cooling_rate"`
+}
+
+// DefaultAnnealingTuning is used for the zero fields of requests.
+var DefaultAnnealingTuning = AnnealingTuning{
+	Steps:       20000,
+	CoolingRate: 0.9995,
+}
+
+// WithDefaults returns a copy of t with every zero field taken from
+// defaults.
+func (t AnnealingTuning) WithDefaults(defaults AnnealingTuning) AnnealingTuning {
+	if t.Steps == 0 {
+		t.Steps = defaults.Steps
+	}
+	if t.InitialTemperature == 0 {
+		t.InitialTemperature = defaults.InitialTemperature
+	}
+	if t.CoolingRate == 0 {
+		t.CoolingRate = defaults.CoolingRate
+	}
+	return t
+}
+
+// Validate checks the tuning, including that it stays within limits.
+func (t AnnealingTuning) Validate(limits TuningLimits) error {
+	var errs models.ValidationErrors
+
+	if t.Steps < 1 {
+		errs.Add("annealing.steps", "must be at least 1")
+	} else if exceeds(t.Steps, limits.MaxAnnealingSteps) {
+		errs.Add("annealing.steps", fmt.Sprintf("must be at most %d", limits.MaxAnnealingSteps))
+	}
+	if t.InitialTemperature < 0 {
+		errs.Add("annealing.initial_temperature", "must not be negative")
+	}
+	if t.CoolingRate <= 0 || t.CoolingRate > 1 {
+		errs.Add("annealing.cooling_rate", "must be greater than 0 and at most 1")
+	}
+
+	return errs.Err()
+}
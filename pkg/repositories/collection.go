@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/jinzhu/gorm"
+)
+
+// ErrUnknownRecipe is returned when a collection is given a recipe that
+// doesn't exist.
+var ErrUnknownRecipe = errors.New("recipe not found")
+
+var _ CollectionRepository = &GormCollectionRepository{}
+
+type GormCollectionRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCollectionRepository(db *gorm.DB) *GormCollectionRepository {
+	return &GormCollectionRepository{
+		db: db,
+	}
+}
+
+func (r *GormCollectionRepository) FindByID(id uint) (*models.Collection, error) {
+	var collection models.Collection
+	if err := r.db.Preload("Recipes").First(&collection, id).Error; err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+func (r *GormCollectionRepository) FindByUserID(userID uint) ([]models.Collection, error) {
+	var collections []models.Collection
+	if err := r.db.Preload("Recipes").Where("user_id = ?", userID).Order("name").Find(&collections).Error; err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+// Create stores the collection with the recipes in its RecipeIDs.
+func (r *GormCollectionRepository) Create(collection *models.Collection) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		collection.Recipes = nil
+		if err := tx.Create(collection).Error; err != nil {
+			return err
+		}
+		return replaceRecipes(tx, collection)
+	})
+}
+
+// Update saves the collection and, when RecipeIDs is set, replaces its
+// recipes with them.
+func (r *GormCollectionRepository) Update(collection *models.Collection) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.Collection
+		if err := tx.First(&existing, collection.ID).Error; err != nil {
+			return err
+		}
+		collection.CreatedAt = existing.CreatedAt
+		collection.Recipes = nil
+		if err := tx.Save(collection).Error; err != nil {
+			return err
+		}
+		if collection.RecipeIDs == nil {
+			return tx.Model(collection).Association("Recipes").Find(&collection.Recipes).Error
+		}
+		return replaceRecipes(tx, collection)
+	})
+}
+
+func (r *GormCollectionRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var collection models.Collection
+		if err := tx.First(&collection, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&collection).Association("Recipes").Clear().Error; err != nil {
+			return err
+		}
+		return tx.Delete(&collection).Error
+	})
+}
+
+// AddRecipe adds the recipe to the collection. Adding a recipe the
+// collection has already changes nothing.
+func (r *GormCollectionRepository) AddRecipe(collectionID uint, recipeID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var collection models.Collection
+		if err := tx.First(&collection, collectionID).Error; err != nil {
+			return err
+		}
+		recipes, err := findRecipes(tx, []uint{recipeID})
+		if err != nil {
+			return err
+		}
+		return tx.Model(&collection).Association("Recipes").Append(recipes).Error
+	})
+}
+
+// RemoveRecipe removes the recipe from the collection.
+func (r *GormCollectionRepository) RemoveRecipe(collectionID uint, recipeID uint) error {
+	var collection models.Collection
+	if err := r.db.First(&collection, collectionID).Error; err != nil {
+		return err
+	}
+	return r.db.Model(&collection).Association("Recipes").Delete(&models.Recipe{ID: recipeID}).Error
+}
+
+// replaceRecipes makes the collection's recipes the ones in its RecipeIDs.
+func replaceRecipes(tx *gorm.DB, collection *models.Collection) error {
+	recipes, err := findRecipes(tx, collection.RecipeIDs)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(collection).Association("Recipes").Clear().Error; err != nil {
+		return err
+	}
+	if len(recipes) > 0 {
+		if err := tx.Model(collection).Association("Recipes").Append(recipes).Error; err != nil {
+			return err
+		}
+	}
+	collection.Recipes = recipes
+	return nil
+}
+
+// findRecipes returns the recipes with the IDs, or ErrUnknownRecipe when
+// one of them doesn't exist.
+func findRecipes(tx *gorm.DB, ids []uint) ([]models.Recipe, error) {
+	recipes := []models.Recipe{}
+	if len(ids) == 0 {
+		return recipes, nil
+	}
+	if err := tx.Where("id IN (?)", ids).Order("id").Find(&recipes).Error; err != nil {
+		return nil, err
+	}
+	unique := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(recipes) != len(unique) {
+		return nil, ErrUnknownRecipe
+	}
+	return recipes, nil
+}
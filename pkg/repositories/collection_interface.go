@@ -0,0 +1,13 @@
+package repositories
+
+import "github.com/cvele/recipe/pkg/models"
+
+type CollectionRepository interface {
+	FindByID(id uint) (*models.Collection, error)
+	FindByUserID(userID uint) ([]models.Collection, error)
+	Create(collection *models.Collection) error
+	Update(collection *models.Collection) error
+	Delete(id uint) error
+	AddRecipe(collectionID uint, recipeID uint) error
+	RemoveRecipe(collectionID uint, recipeID uint) error
+}
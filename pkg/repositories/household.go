@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/jinzhu/gorm"
+)
+
+var _ HouseholdRepository = &GormHouseholdRepository{}
+
+type GormHouseholdRepository struct {
+	db *gorm.DB
+}
+
+func NewGormHouseholdRepository(db *gorm.DB) *GormHouseholdRepository {
+	return &GormHouseholdRepository{
+		db: db,
+	}
+}
+
+func (r *GormHouseholdRepository) FindByID(id uint) (*models.Household, error) {
+	var household models.Household
+	if err := r.db.Preload("Members").First(&household, id).Error; err != nil {
+		return nil, err
+	}
+	return &household, nil
+}
+
+func (r *GormHouseholdRepository) FindByUserID(userID uint) ([]models.Household, error) {
+	var households []models.Household
+	if err := r.db.Preload("Members").Where("user_id = ?", userID).Order("name").Find(&households).Error; err != nil {
+		return nil, err
+	}
+	return households, nil
+}
+
+func (r *GormHouseholdRepository) Create(household *models.Household) error {
+	return r.db.Create(household).Error
+}
+
+// Update saves the household and replaces its members with the given ones.
+func (r *GormHouseholdRepository) Update(household *models.Household) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.Household
+		if err := tx.First(&existing, household.ID).Error; err != nil {
+			return err
+		}
+		household.CreatedAt = existing.CreatedAt
+		if err := tx.Where("household_id = ?", household.ID).Delete(&models.HouseholdMember{}).Error; err != nil {
+			return err
+		}
+		for i := range household.Members {
+			household.Members[i].ID = 0
+		}
+		return tx.Save(household).Error
+	})
+}
+
+func (r *GormHouseholdRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("household_id = ?", id).Delete(&models.HouseholdMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Household{}).Error
+	})
+}
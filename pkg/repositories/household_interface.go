@@ -0,0 +1,11 @@
+package repositories
+
+import "github.com/cvele/recipe/pkg/models"
+
+type HouseholdRepository interface {
+	FindByID(id uint) (*models.Household, error)
+	FindByUserID(userID uint) ([]models.Household, error)
+	Create(household *models.Household) error
+	Update(household *models.Household) error
+	Delete(id uint) error
+}
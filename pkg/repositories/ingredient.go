@@ -1,10 +1,14 @@
 package repositories
 
 import (
+	"strings"
+
 	"github.com/cvele/recipe/pkg/models"
 	"github.com/jinzhu/gorm"
 )
 
+var _ IngredientRepository = &GormIngredientRepository{}
+
 type GormIngredientRepository struct {
 	db *gorm.DB
 }
@@ -17,8 +21,91 @@ func NewGormIngredientRepository(db *gorm.DB) *GormIngredientRepository {
 
 func (r *GormIngredientRepository) FindByID(id uint) (*models.Ingredient, error) {
 	var ingredient models.Ingredient
-	if err := r.db.First(&ingredient, id).Error; err != nil {
+	if err := r.db.Preload("Aliases").First(&ingredient, id).Error; err != nil {
 		return nil, err
 	}
 	return &ingredient, nil
 }
+
+// FindAll returns every ingredient with its aliases.
+func (r *GormIngredientRepository) FindAll() ([]models.Ingredient, error) {
+	var ingredients []models.Ingredient
+	if err := r.db.Preload("Aliases").Order("id").Find(&ingredients).Error; err != nil {
+		return nil, err
+	}
+	return ingredients, nil
+}
+
+// Search returns a page of ingredients whose name or one of whose aliases
+// contains query, together with the total number of matches. An empty query
+// matches every ingredient.
+func (r *GormIngredientRepository) Search(query string, offset int, limit int) ([]models.Ingredient, int, error) {
+	scope := r.db.Model(&models.Ingredient{})
+	if query != "" {
+		pattern := "%" + escapeLike(strings.ToLower(query)) + "%"
+		aliases := r.db.Table("ingredient_aliases").
+			Select("ingredient_id").
+			Where("LOWER(name) LIKE ? AND deleted_at IS NULL", pattern).
+			SubQuery()
+		scope = scope.Where("LOWER(name) LIKE ? OR id IN (?)", pattern, aliases)
+	}
+
+	var total int
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var ingredients []models.Ingredient
+	if err := scope.Preload("Aliases").Order("name").Offset(offset).Limit(limit).Find(&ingredients).Error; err != nil {
+		return nil, 0, err
+	}
+	return ingredients, total, nil
+}
+
+func (r *GormIngredientRepository) Create(ingredient *models.Ingredient) error {
+	if err := r.db.Create(ingredient).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// Update saves the ingredient and replaces its aliases with the given ones.
+func (r *GormIngredientRepository) Update(ingredient *models.Ingredient) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.Ingredient
+		if err := tx.First(&existing, ingredient.ID).Error; err != nil {
+			return err
+		}
+		ingredient.CreatedAt = existing.CreatedAt
+		if err := tx.Where("ingredient_id = ?", ingredient.ID).Delete(&models.IngredientAlias{}).Error; err != nil {
+			return err
+		}
+		for i := range ingredient.Aliases {
+			ingredient.Aliases[i].ID = 0
+		}
+		return tx.Save(ingredient).Error
+	})
+}
+
+// Delete removes the ingredient and its aliases, returning
+// gorm.ErrRecordNotFound when no such ingredient exists.
+func (r *GormIngredientRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("ingredient_id = ?", id).Delete(&models.IngredientAlias{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&models.Ingredient{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// escapeLike escapes the LIKE wildcards in s so they match literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
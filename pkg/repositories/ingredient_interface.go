@@ -4,4 +4,9 @@ import "github.com/cvele/recipe/pkg/models"
 
 type IngredientRepository interface {
 	FindByID(id uint) (*models.Ingredient, error)
+	FindAll() ([]models.Ingredient, error)
+	Search(query string, offset int, limit int) ([]models.Ingredient, int, error)
+	Create(ingredient *models.Ingredient) error
+	Update(ingredient *models.Ingredient) error
+	Delete(id uint) error
 }
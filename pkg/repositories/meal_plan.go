@@ -1,10 +1,14 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/cvele/recipe/pkg/models"
 	"github.com/jinzhu/gorm"
 )
 
+var _ MealPlanRepository = &GormMealPlanRepository{}
+
 type GormMealPlanRepository struct {
 	db *gorm.DB
 }
@@ -15,13 +19,62 @@ func NewGormMealPlanRepository(db *gorm.DB) *GormMealPlanRepository {
 	}
 }
 
+// Create stores the meal plan without touching its Recipe, which planners
+// hand back with quantities already scaled to the planned servings.
 func (r *GormMealPlanRepository) Create(mealPlan *models.MealPlan) error {
-	if err := r.db.Create(mealPlan).Error; err != nil {
+	if err := r.db.Set("gorm:save_associations", false).Create(mealPlan).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
+// CreateAll stores all meal plans in a single transaction.
+func (r *GormMealPlanRepository) CreateAll(mealPlans []models.MealPlan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range mealPlans {
+			if err := tx.Set("gorm:save_associations", false).Create(&mealPlans[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Replace deletes the meal plans with oldIDs and stores the new meal plans
+// (those without an ID) in a single transaction, so a re-planned week is
+// never left half written.
+func (r *GormMealPlanRepository) Replace(oldIDs []uint, mealPlans []models.MealPlan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(oldIDs) > 0 {
+			if err := tx.Where("id IN (?)", oldIDs).Delete(&models.MealPlan{}).Error; err != nil {
+				return err
+			}
+		}
+		for i := range mealPlans {
+			if mealPlans[i].ID != 0 {
+				continue
+			}
+			if err := tx.Set("gorm:save_associations", false).Create(&mealPlans[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *GormMealPlanRepository) FindByID(id uint) (*models.MealPlan, error) {
+	var mealPlan models.MealPlan
+	if err := r.db.Preload("Recipe").
+		Preload("Recipe.RecipeIngredients.Ingredient").
+		First(&mealPlan, id).Error; err != nil {
+		return nil, err
+	}
+	if err := r.resolveRecipeVersions([]*models.MealPlan{&mealPlan}); err != nil {
+		return nil, err
+	}
+	return &mealPlan, nil
+}
+
 func (r *GormMealPlanRepository) FindByUserID(userID uint) ([]*models.MealPlan, error) {
 	var mealPlans []*models.MealPlan
 	if err := r.db.Where("user_id = ?", userID).Find(&mealPlans).Error; err != nil {
@@ -29,3 +82,102 @@ func (r *GormMealPlanRepository) FindByUserID(userID uint) ([]*models.MealPlan,
 	}
 	return mealPlans, nil
 }
+
+// FindByUserIDAndDateRange returns the user's meals planned in [from, to),
+// ordered by meal time.
+func (r *GormMealPlanRepository) FindByUserIDAndDateRange(userID uint, from time.Time, to time.Time) ([]*models.MealPlan, error) {
+	var mealPlans []*models.MealPlan
+	if err := r.db.Preload("Recipe").
+		Preload("Recipe.RecipeIngredients.Ingredient").
+		Where("user_id = ? AND meal_time >= ? AND meal_time < ?", userID, from, to).
+		Order("meal_time").
+		Find(&mealPlans).Error; err != nil {
+		return nil, err
+	}
+	if err := r.resolveRecipeVersions(mealPlans); err != nil {
+		return nil, err
+	}
+	return mealPlans, nil
+}
+
+// UpdateSlot moves a meal to another time and meal slot, taking the
+// slot's duration. Moved meals are marked as not synced so calendars pick
+// up the change.
+func (r *GormMealPlanRepository) UpdateSlot(id uint, mealTime time.Time, slot models.MealSlot) error {
+	return r.update(id, map[string]interface{}{
+		"meal_time": mealTime,
+		"meal_slot": slot.Name,
+		"duration":  slot.Duration,
+		"synced":    false,
+	})
+}
+
+func (r *GormMealPlanRepository) UpdateServings(id uint, servings int) error {
+	return r.update(id, map[string]interface{}{
+		"servings": servings,
+	})
+}
+
+func (r *GormMealPlanRepository) MarkCooked(id uint, cookedAt time.Time) error {
+	return r.update(id, map[string]interface{}{
+		"cooked_at": cookedAt,
+	})
+}
+
+func (r *GormMealPlanRepository) Delete(id uint) error {
+	if err := r.db.Where("id = ?", id).Delete(&models.MealPlan{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// resolveRecipeVersions replaces the preloaded recipe of meal plans that
+// were planned with another version of it by that version. Versions that
+// no longer exist leave the recipe as it is.
+func (r *GormMealPlanRepository) resolveRecipeVersions(mealPlans []*models.MealPlan) error {
+	type versionKey struct {
+		id      uint
+		version int
+	}
+	resolved := make(map[versionKey]*models.Recipe)
+
+	for _, mealPlan := range mealPlans {
+		if mealPlan.RecipeVersion == 0 || (mealPlan.Recipe != nil && mealPlan.Recipe.Version == mealPlan.RecipeVersion) {
+			continue
+		}
+		key := versionKey{id: mealPlan.RecipeID, version: mealPlan.RecipeVersion}
+		recipe, ok := resolved[key]
+		if !ok {
+			var err error
+			recipe, err = findRecipeVersion(r.db, key.id, key.version)
+			if err != nil && !gorm.IsRecordNotFoundError(err) {
+				return err
+			}
+			resolved[key] = recipe
+		}
+		if recipe != nil {
+			// Meal plans adjust their recipe, so each gets its own copy
+			copied := *recipe
+			if recipe.RecipeIngredients != nil {
+				ingredients := append([]models.RecipeIngredient(nil), *recipe.RecipeIngredients...)
+				copied.RecipeIngredients = &ingredients
+			}
+			mealPlan.Recipe = &copied
+		}
+	}
+	return nil
+}
+
+// update applies fields to a single meal plan, returning
+// gorm.ErrRecordNotFound when no such meal plan exists. The meal plan is
+// looked up first: MySQL counts only changed rows as affected, so an update
+// that changes nothing can't tell a missing meal plan apart.
+func (r *GormMealPlanRepository) update(id uint, fields map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.MealPlan
+		if err := tx.Select("id").First(&existing, id).Error; err != nil {
+			return err
+		}
+		return tx.Model(&existing).Updates(fields).Error
+	})
+}
@@ -1,8 +1,20 @@
 package repositories
 
-import "github.com/cvele/recipe/pkg/models"
+import (
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+)
 
 type MealPlanRepository interface {
 	Create(mealPlan *models.MealPlan) error
+	CreateAll(mealPlans []models.MealPlan) error
+	Replace(oldIDs []uint, mealPlans []models.MealPlan) error
+	FindByID(id uint) (*models.MealPlan, error)
 	FindByUserID(userID uint) ([]*models.MealPlan, error)
+	FindByUserIDAndDateRange(userID uint, from time.Time, to time.Time) ([]*models.MealPlan, error)
+	UpdateSlot(id uint, mealTime time.Time, slot models.MealSlot) error
+	UpdateServings(id uint, servings int) error
+	MarkCooked(id uint, cookedAt time.Time) error
+	Delete(id uint) error
 }
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/jinzhu/gorm"
+)
+
+var _ MealSlotRepository = &GormMealSlotRepository{}
+
+type GormMealSlotRepository struct {
+	db *gorm.DB
+}
+
+func NewGormMealSlotRepository(db *gorm.DB) *GormMealSlotRepository {
+	return &GormMealSlotRepository{
+		db: db,
+	}
+}
+
+// FindAll returns the meal slots in the order of their default times.
+func (r *GormMealSlotRepository) FindAll() ([]models.MealSlot, error) {
+	var slots []models.MealSlot
+	if err := r.db.Order("default_time").Order("name").Find(&slots).Error; err != nil {
+		return nil, err
+	}
+	return slots, nil
+}
+
+func (r *GormMealSlotRepository) FindByID(id uint) (*models.MealSlot, error) {
+	var slot models.MealSlot
+	if err := r.db.First(&slot, id).Error; err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+func (r *GormMealSlotRepository) FindByName(name models.MealType) (*models.MealSlot, error) {
+	var slot models.MealSlot
+	if err := r.db.Where("name = ?", name).First(&slot).Error; err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+func (r *GormMealSlotRepository) Create(slot *models.MealSlot) error {
+	return r.db.Create(slot).Error
+}
+
+// Update saves the slot. Its name is kept, since recipes, meal plans and
+// households refer to the slot by name.
+func (r *GormMealSlotRepository) Update(slot *models.MealSlot) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.MealSlot
+		if err := tx.First(&existing, slot.ID).Error; err != nil {
+			return err
+		}
+		slot.Name = existing.Name
+		slot.CreatedAt = existing.CreatedAt
+		return tx.Save(slot).Error
+	})
+}
+
+// Delete deletes the slot and unlinks its recipes. Meals planned for the
+// slot keep its name. The slot is removed for good so that its name can be
+// used again.
+func (r *GormMealSlotRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("meal_slot_id = ?", id).Delete(&models.RecipeMealSlot{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id = ?", id).Delete(&models.MealSlot{}).Error
+	})
+}
@@ -0,0 +1,12 @@
+package repositories
+
+import "github.com/cvele/recipe/pkg/models"
+
+type MealSlotRepository interface {
+	FindAll() ([]models.MealSlot, error)
+	FindByID(id uint) (*models.MealSlot, error)
+	FindByName(name models.MealType) (*models.MealSlot, error)
+	Create(slot *models.MealSlot) error
+	Update(slot *models.MealSlot) error
+	Delete(id uint) error
+}
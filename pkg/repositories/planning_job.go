@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/jinzhu/gorm"
+)
+
+var _ PlanningJobRepository = &GormPlanningJobRepository{}
+
+type GormPlanningJobRepository struct {
+	db *gorm.DB
+}
+
+func NewGormPlanningJobRepository(db *gorm.DB) *GormPlanningJobRepository {
+	return &GormPlanningJobRepository{
+		db: db,
+	}
+}
+
+func (r *GormPlanningJobRepository) Create(job *models.PlanningJob) error {
+	if err := r.db.Create(job).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *GormPlanningJobRepository) FindByID(id uint) (*models.PlanningJob, error) {
+	var job models.PlanningJob
+	if err := r.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindByStatus returns jobs in the given status, oldest first.
+func (r *GormPlanningJobRepository) FindByStatus(status models.PlanningJobStatus) ([]*models.PlanningJob, error) {
+	var jobs []*models.PlanningJob
+	if err := r.db.Where("status = ?", status).Order("id").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *GormPlanningJobRepository) Update(job *models.PlanningJob) error {
+	if err := r.db.Save(job).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateProgress only touches the progress column, so it never overwrites a
+// status change made concurrently.
+func (r *GormPlanningJobRepository) UpdateProgress(id uint, progress float64) error {
+	if err := r.db.Model(&models.PlanningJob{}).Where("id = ?", id).UpdateColumn("progress", progress).Error; err != nil {
+		return err
+	}
+	return nil
+}
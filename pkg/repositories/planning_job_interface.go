@@ -0,0 +1,11 @@
+package repositories
+
+import "github.com/cvele/recipe/pkg/models"
+
+type PlanningJobRepository interface {
+	Create(job *models.PlanningJob) error
+	FindByID(id uint) (*models.PlanningJob, error)
+	FindByStatus(status models.PlanningJobStatus) ([]*models.PlanningJob, error)
+	Update(job *models.PlanningJob) error
+	UpdateProgress(id uint, progress float64) error
+}
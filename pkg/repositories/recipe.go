@@ -1,12 +1,22 @@
 package repositories
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
+	"strings"
 
 	"github.com/cvele/recipe/pkg/models"
 	"github.com/jinzhu/gorm"
 )
 
+// ErrVersionConflict is returned when a recipe is updated from a version
+// that is no longer its latest.
+var ErrVersionConflict = errors.New("recipe was changed since the version the update is based on")
+
+// ErrUnknownMealSlot is returned when a recipe is given a meal slot that
+// doesn't exist.
+var ErrUnknownMealSlot = errors.New("meal slot not found")
+
 type GormRecipeRepository struct {
 	db *gorm.DB
 }
@@ -17,35 +27,105 @@ func NewGormRecipeRepository(db *gorm.DB) *GormRecipeRepository {
 	}
 }
 
+// GetAllRecipes returns the latest version of every recipe.
 func (r *GormRecipeRepository) GetAllRecipes() ([]models.Recipe, error) {
 	var recipes []models.Recipe
-	subquery := r.db.Table("recipes").
-		Select("id, MAX(version) as max_version").
-		Group("id").SubQuery()
+	if err := r.db.Find(&recipes).Error; err != nil {
+		return nil, err
+	}
+	if err := loadMealSlots(r.db, recipes); err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
+
+// FindRecipes returns the latest version of the recipes matching filter.
+func (r *GormRecipeRepository) FindRecipes(filter RecipeFilter) ([]models.Recipe, error) {
+	scope := r.db
+	for _, tag := range models.NormalizeTags(filter.Tags) {
+		// Tags are stored as a JSON list of normalized tags
+		encoded, err := json.Marshal(tag)
+		if err != nil {
+			return nil, err
+		}
+		scope = scope.Where("tags LIKE ?", "%"+escapeLike(string(encoded))+"%")
+	}
+	if cuisine := strings.TrimSpace(filter.Cuisine); cuisine != "" {
+		scope = scope.Where("LOWER(cuisine) = ?", strings.ToLower(cuisine))
+	}
+	if filter.CollectionID != 0 {
+		collected := r.db.Table("collection_recipes").
+			Select("recipe_id").
+			Where("collection_id = ?", filter.CollectionID).
+			SubQuery()
+		scope = scope.Where("id IN (?)", collected)
+	}
 
-	if err := r.db.Table("recipes").
-		Joins("JOIN (?) AS latest ON recipes.id = latest.id AND recipes.version = latest.max_version", subquery).
-		Find(&recipes).Error; err != nil {
+	var recipes []models.Recipe
+	if err := scope.Order("id").Find(&recipes).Error; err != nil {
+		return nil, err
+	}
+	if err := loadMealSlots(r.db, recipes); err != nil {
 		return nil, err
 	}
 	return recipes, nil
 }
 
+// CreateRecipe stores the recipe as its first version.
 func (r *GormRecipeRepository) CreateRecipe(recipe *models.Recipe) error {
-	if err := r.db.Create(recipe).Error; err != nil {
-		return err
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		recipe.Version = 1
+		if err := tx.Create(recipe).Error; err != nil {
+			return err
+		}
+		if err := saveMealSlots(tx, recipe); err != nil {
+			return err
+		}
+		version := models.NewRecipeVersion(*recipe)
+		return tx.Create(&version).Error
+	})
 }
 
+// UpdateRecipe stores the recipe as the next version of the recipe with its
+// ID, replacing its ingredients. The previous versions are kept. The
+// recipe's Version is the version the update is based on: when another
+// version was saved since, it returns ErrVersionConflict. Zero updates
+// whichever version is the latest. Its meal slots are replaced when
+// MealSlots is set.
 func (r *GormRecipeRepository) UpdateRecipe(recipe *models.Recipe) error {
-	recipe.Version++
-	if err := r.db.Create(recipe).Error; err != nil {
-		return err
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var current models.Recipe
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&current, recipe.ID).Error; err != nil {
+			return err
+		}
+		if recipe.Version != 0 && recipe.Version != current.Version {
+			return ErrVersionConflict
+		}
+		recipe.Version = current.Version + 1
+		recipe.CreatedAt = current.CreatedAt
+
+		if err := tx.Where("recipe_id = ?", recipe.ID).Delete(&models.RecipeIngredient{}).Error; err != nil {
+			return err
+		}
+		if recipe.RecipeIngredients != nil {
+			for i := range *recipe.RecipeIngredients {
+				(*recipe.RecipeIngredients)[i].ID = 0
+			}
+		}
+		if err := tx.Save(recipe).Error; err != nil {
+			return err
+		}
+		if err := saveMealSlots(tx, recipe); err != nil {
+			return err
+		}
+
+		version := models.NewRecipeVersion(*recipe)
+		return tx.Create(&version).Error
+	})
 }
 
+// DeleteRecipe deletes the recipe. Its versions are kept for the meal plans
+// that use them.
 func (r *GormRecipeRepository) DeleteRecipe(id uint) error {
 	if err := r.db.Where("id = ?", id).Delete(&models.Recipe{}).Error; err != nil {
 		return err
@@ -55,35 +135,196 @@ func (r *GormRecipeRepository) DeleteRecipe(id uint) error {
 
 func (r *GormRecipeRepository) GetRandomRecipeByType(mealType models.MealType) (*models.Recipe, error) {
 	var recipe models.Recipe
-	if err := r.db.Where("meal_type = ?", mealType).Order("version DESC").Order(gorm.Expr("rand()")).Limit(1).Find(&recipe).Error; err != nil {
+	if err := r.db.Where("id IN (?)", recipesInSlot(r.db, mealType)).Order(gorm.Expr("rand()")).Limit(1).Find(&recipe).Error; err != nil {
+		return nil, err
+	}
+	if err := loadRecipeMealSlots(r.db, &recipe); err != nil {
 		return nil, err
 	}
 	return &recipe, nil
 }
 
+// GetRecipesByType returns the recipes that can be planned for the meal
+// slot named mealType.
 func (r *GormRecipeRepository) GetRecipesByType(mealType models.MealType) ([]models.Recipe, error) {
 	var recipes []models.Recipe
-	subquery := r.db.Table("recipes").
-		Select("id, MAX(version) as max_version").
-		//mealType is safe
-		Where(fmt.Sprintf("is_%s = ?", mealType.String()), true).
-		Group("id").SubQuery()
-
-	query := r.db.Table("recipes").
-		Joins("JOIN (?) AS latest ON recipes.id = latest.id AND recipes.version = latest.max_version", subquery).
-		Where(fmt.Sprintf("is_%s = ?", mealType.String()), true).
-		Order("version DESC")
+	query := r.db.
+		Preload("RecipeIngredients.Ingredient").
+		Where("id IN (?)", recipesInSlot(r.db, mealType))
 
 	if err := query.Find(&recipes).Error; err != nil {
 		return nil, err
 	}
+	if err := loadMealSlots(r.db, recipes); err != nil {
+		return nil, err
+	}
 	return recipes, nil
 }
 
 func (r *GormRecipeRepository) GetRecipeByID(id uint) (*models.Recipe, error) {
 	var recipe models.Recipe
-	if err := r.db.Where("id = ?", id).Limit(1).Find(&recipe).Error; err != nil {
+	if err := r.db.Preload("RecipeIngredients.Ingredient").Where("id = ?", id).Limit(1).Find(&recipe).Error; err != nil {
+		return nil, err
+	}
+	if err := loadRecipeMealSlots(r.db, &recipe); err != nil {
+		return nil, err
+	}
+	return &recipe, nil
+}
+
+// GetRecipeVersions returns the recipe's versions, oldest first, without
+// their content.
+func (r *GormRecipeRepository) GetRecipeVersions(id uint) ([]models.RecipeVersion, error) {
+	var versions []models.RecipeVersion
+	if err := r.db.Select("id, created_at, updated_at, deleted_at, recipe_id, version, title").
+		Where("recipe_id = ?", id).
+		Order("version").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetRecipeVersion returns the recipe as it was at version, with the
+// current catalog entries of its ingredients.
+func (r *GormRecipeRepository) GetRecipeVersion(id uint, version int) (*models.Recipe, error) {
+	return findRecipeVersion(r.db, id, version)
+}
+
+// findRecipeVersion returns the recipe at version. Recipes saved before
+// versions were kept only have their latest version.
+func findRecipeVersion(db *gorm.DB, id uint, version int) (*models.Recipe, error) {
+	var recipeVersion models.RecipeVersion
+	err := db.Where("recipe_id = ? AND version = ?", id, version).First(&recipeVersion).Error
+	if gorm.IsRecordNotFoundError(err) {
+		var recipe models.Recipe
+		if err := db.Preload("RecipeIngredients.Ingredient").Where("id = ? AND version = ?", id, version).First(&recipe).Error; err != nil {
+			return nil, err
+		}
+		if err := loadRecipeMealSlots(db, &recipe); err != nil {
+			return nil, err
+		}
+		return &recipe, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	recipe := models.Recipe(recipeVersion.Recipe)
+	if err := loadIngredients(db, &recipe); err != nil {
 		return nil, err
 	}
 	return &recipe, nil
 }
+
+// loadIngredients replaces the recipe's ingredients with their catalog
+// entries. Ingredients no longer in the catalog keep what the version has.
+func loadIngredients(db *gorm.DB, recipe *models.Recipe) error {
+	if recipe.RecipeIngredients == nil || len(*recipe.RecipeIngredients) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(*recipe.RecipeIngredients))
+	for i, recipeIngredient := range *recipe.RecipeIngredients {
+		ids[i] = recipeIngredient.IngredientID
+	}
+	var ingredients []models.Ingredient
+	if err := db.Where("id IN (?)", ids).Find(&ingredients).Error; err != nil {
+		return err
+	}
+
+	byID := make(map[uint]models.Ingredient, len(ingredients))
+	for _, ingredient := range ingredients {
+		byID[ingredient.ID] = ingredient
+	}
+	for i, recipeIngredient := range *recipe.RecipeIngredients {
+		if ingredient, ok := byID[recipeIngredient.IngredientID]; ok {
+			(*recipe.RecipeIngredients)[i].Ingredient = ingredient
+		}
+	}
+	return nil
+}
+
+// recipesInSlot selects the IDs of the recipes linked to the meal slot.
+func recipesInSlot(db *gorm.DB, mealType models.MealType) interface{} {
+	return db.Table("recipe_meal_slots").
+		Select("recipe_meal_slots.recipe_id").
+		Joins("JOIN meal_slots ON meal_slots.id = recipe_meal_slots.meal_slot_id").
+		Where("meal_slots.name = ? AND meal_slots.deleted_at IS NULL", mealType).
+		SubQuery()
+}
+
+// loadMealSlots sets the names of the recipes' meal slots.
+func loadMealSlots(db *gorm.DB, recipes []models.Recipe) error {
+	if len(recipes) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(recipes))
+	for i, recipe := range recipes {
+		ids[i] = recipe.ID
+	}
+	var links []struct {
+		RecipeID uint
+		Name     models.MealType
+	}
+	if err := db.Table("recipe_meal_slots").
+		Select("recipe_meal_slots.recipe_id, meal_slots.name").
+		Joins("JOIN meal_slots ON meal_slots.id = recipe_meal_slots.meal_slot_id").
+		Where("recipe_meal_slots.recipe_id IN (?) AND meal_slots.deleted_at IS NULL", ids).
+		Order("meal_slots.default_time, meal_slots.name").
+		Scan(&links).Error; err != nil {
+		return err
+	}
+
+	byRecipe := make(map[uint]models.MealTypes, len(recipes))
+	for _, link := range links {
+		byRecipe[link.RecipeID] = append(byRecipe[link.RecipeID], link.Name)
+	}
+	for i := range recipes {
+		recipes[i].MealSlots = byRecipe[recipes[i].ID]
+		if recipes[i].MealSlots == nil {
+			recipes[i].MealSlots = models.MealTypes{}
+		}
+	}
+	return nil
+}
+
+// loadRecipeMealSlots sets the names of the recipe's meal slots.
+func loadRecipeMealSlots(db *gorm.DB, recipe *models.Recipe) error {
+	recipes := []models.Recipe{*recipe}
+	if err := loadMealSlots(db, recipes); err != nil {
+		return err
+	}
+	recipe.MealSlots = recipes[0].MealSlots
+	return nil
+}
+
+// saveMealSlots links the recipe to the meal slots in its MealSlots,
+// replacing the slots it had. A nil MealSlots keeps them.
+func saveMealSlots(tx *gorm.DB, recipe *models.Recipe) error {
+	if recipe.MealSlots == nil {
+		return loadRecipeMealSlots(tx, recipe)
+	}
+
+	var slots []models.MealSlot
+	if len(recipe.MealSlots) > 0 {
+		if err := tx.Where("name IN (?)", []models.MealType(recipe.MealSlots)).Find(&slots).Error; err != nil {
+			return err
+		}
+	}
+	if len(slots) != len(recipe.MealSlots) {
+		return ErrUnknownMealSlot
+	}
+
+	if err := tx.Where("recipe_id = ?", recipe.ID).Delete(&models.RecipeMealSlot{}).Error; err != nil {
+		return err
+	}
+	for _, slot := range slots {
+		link := models.RecipeMealSlot{RecipeID: recipe.ID, MealSlotID: slot.ID}
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,12 +4,24 @@ import (
 	"github.com/cvele/recipe/pkg/models"
 )
 
+// RecipeFilter selects recipes by their labels. Recipes match when they
+// have every tag, the cuisine, ignoring case, and are in the collection.
+// Zero fields match every recipe.
+type RecipeFilter struct {
+	Tags         []string
+	Cuisine      string
+	CollectionID uint
+}
+
 type RecipeRepositoryInterface interface {
 	GetAllRecipes() ([]models.Recipe, error)
+	FindRecipes(filter RecipeFilter) ([]models.Recipe, error)
 	GetRecipeByID(id uint) (*models.Recipe, error)
 	CreateRecipe(recipe *models.Recipe) error
 	UpdateRecipe(recipe *models.Recipe) error
 	DeleteRecipe(id uint) error
 	GetRandomRecipeByType(mealType models.MealType) (*models.Recipe, error)
 	GetRecipesByType(mealType models.MealType) ([]models.Recipe, error)
+	GetRecipeVersions(id uint) ([]models.RecipeVersion, error)
+	GetRecipeVersion(id uint, version int) (*models.Recipe, error)
 }
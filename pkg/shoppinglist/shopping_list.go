@@ -2,6 +2,7 @@ package shoppinglist
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/cvele/recipe/pkg/models"
 	"github.com/cvele/recipe/pkg/units"
@@ -14,9 +15,17 @@ type ShoppingListService struct {
 	MealPlans     []models.MealPlan
 }
 
+// shoppingEntry accumulates an item's exact quantity and cost before they
+// are converted for display.
+type shoppingEntry struct {
+	quantity units.Quantity
+	cost     units.Quantity
+	unit     string
+}
+
 func (s *ShoppingListService) GenerateShoppingList() ([]models.ShoppingItem, *int, error) {
-	shoppingListMap := make(map[string]models.ShoppingItem)
-	totalCost := 0
+	shoppingListMap := make(map[string]*shoppingEntry)
+	var names []string
 
 	for _, mealPlan := range s.MealPlans {
 		recipe := mealPlan.Recipe
@@ -25,7 +34,7 @@ func (s *ShoppingListService) GenerateShoppingList() ([]models.ShoppingItem, *in
 			return nil, nil, fmt.Errorf("servings in meal plan or recipe cannot be 0")
 		}
 
-		servingsRatio := float64(mealPlan.Servings) / float64(recipe.Servings)
+		servingsRatio := units.NewQuantity(int64(mealPlan.Servings), int64(recipe.Servings))
 
 		for _, recipeIngredient := range *recipe.RecipeIngredients {
 			ingredient := recipeIngredient.Ingredient
@@ -40,37 +49,55 @@ func (s *ShoppingListService) GenerateShoppingList() ([]models.ShoppingItem, *in
 			}
 
 			// Convert the RecipeIngredient's unit to the default unit
-			convertedQuantity, err := s.UnitConverter.ConvertUnits(recipeIngredient.Quantity, recipeIngredient.Unit, defaultUnit, ingredient.UnitType)
+			convertedQuantity, err := units.ConvertQuantity(s.UnitConverter, units.QuantityFromFloat(recipeIngredient.Quantity), recipeIngredient.Unit, defaultUnit, ingredient.UnitType)
 			if err != nil {
 				return nil, nil, err
 			}
 
 			// Adjust the quantity according to the servings ratio
-			adjustedQuantity := servingsRatio * convertedQuantity
+			adjustedQuantity := convertedQuantity.Mul(servingsRatio)
 
-			// Calculate the cost
-			cost := int(adjustedQuantity * float64(ingredient.PricePerUnit)) // calculate cost in cents
-			totalCost += cost
+			// Calculate the cost in cents
+			cost := adjustedQuantity.MulInt(ingredient.PricePerUnit)
 
-			if item, exists := shoppingListMap[ingredient.Name]; exists {
-				item.Quantity += adjustedQuantity
-				item.Cost += cost
-				shoppingListMap[ingredient.Name] = item
+			if entry, exists := shoppingListMap[ingredient.Name]; exists {
+				entry.quantity = entry.quantity.Add(adjustedQuantity)
+				entry.cost = entry.cost.Add(cost)
 			} else {
-				shoppingListMap[ingredient.Name] = models.ShoppingItem{
-					Name:     ingredient.Name,
-					Quantity: adjustedQuantity,
-					Unit:     defaultUnit,
-					Cost:     cost,
+				shoppingListMap[ingredient.Name] = &shoppingEntry{
+					quantity: adjustedQuantity,
+					cost:     cost,
+					unit:     defaultUnit,
 				}
+				names = append(names, ingredient.Name)
 			}
 		}
 	}
 
+	// Round once per item, so the total always matches the listed costs
+	totalCost := 0
 	shoppingList := make([]models.ShoppingItem, 0, len(shoppingListMap))
-	for _, item := range shoppingListMap {
+	for _, name := range names {
+		entry := shoppingListMap[name]
+		item := models.ShoppingItem{
+			Name:     name,
+			Quantity: entry.quantity.Float64(),
+			Unit:     entry.unit,
+			Cost:     entry.cost.Round(),
+		}
+		totalCost = addSaturating(totalCost, item.Cost)
 		shoppingList = append(shoppingList, item)
 	}
 
 	return shoppingList, &totalCost, nil
 }
+
+func addSaturating(a int, b int) int {
+	if b > 0 && a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	if b < 0 && a < math.MinInt-b {
+		return math.MinInt
+	}
+	return a + b
+}
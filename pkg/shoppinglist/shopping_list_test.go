@@ -7,6 +7,7 @@ import (
 
 	"github.com/cvele/recipe/pkg/models"
 	"github.com/cvele/recipe/pkg/shoppinglist"
+	"github.com/cvele/recipe/pkg/units"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -493,3 +494,42 @@ func TestGenerateShoppingList_BoundaryCases(t *testing.T) {
 		Cost:     math.MaxInt64,
 	})
 }
+
+func TestGenerateShoppingList_ExactAggregation(t *testing.T) {
+	mealPlan := models.MealPlan{
+		Servings: 1,
+		Recipe: &models.Recipe{
+			Servings: 1,
+			RecipeIngredients: &[]models.RecipeIngredient{
+				{
+					Ingredient: models.Ingredient{
+						Name:         "Vanilla Extract",
+						UnitType:     "volume",
+						PricePerUnit: 30,
+					},
+					Quantity: 1,
+					Unit:     "tsp",
+				},
+			},
+		},
+	}
+
+	// Three teaspoons should add up to exactly one tablespoon
+	svc := &shoppinglist.ShoppingListService{
+		UnitConverter: units.NewUnitConverter("g", "tbsp"),
+		MealPlans:     []models.MealPlan{mealPlan, mealPlan, mealPlan},
+	}
+
+	list, totalCost, err := svc.GenerateShoppingList()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []models.ShoppingItem{
+		{
+			Name:     "Vanilla Extract",
+			Quantity: 1,
+			Unit:     "tbsp",
+			Cost:     30,
+		},
+	}, list)
+	assert.Equal(t, 30, *totalCost)
+}
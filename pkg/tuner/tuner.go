@@ -0,0 +1,187 @@
+// Package tuner searches genetic planner parameters by running seeded plans
+// over a fixed recipe catalog and comparing plan quality with run time.
+package tuner
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/planner"
+	"github.com/cvele/recipe/pkg/repositories"
+	"github.com/cvele/recipe/pkg/units"
+)
+
+// Space lists the values tried for each parameter. Every combination is
+// evaluated.
+type Space struct {
+	PopulationSizes []int
+	MaxGenerations  []int
+	CrossoverRates  []float64
+	MutationRates   []float64
+}
+
+// DefaultSpace spans the usual ranges around the current defaults.
+var DefaultSpace = Space{
+	PopulationSizes: []int{25, 50, 100, 200},
+	MaxGenerations:  []int{10, 25, 50, 100},
+	CrossoverRates:  []float64{0.5, 0.7, 0.9},
+	MutationRates:   []float64{0.01, 0.05, 0.1, 0.2},
+}
+
+// Tunings returns every combination in the space on top of base.
+func (s Space) Tunings(base planner.GeneticTuning) []planner.GeneticTuning {
+	var tunings []planner.GeneticTuning
+	for _, populationSize := range s.PopulationSizes {
+		for _, maxGenerations := range s.MaxGenerations {
+			for _, crossoverRate := range s.CrossoverRates {
+				for _, mutationRate := range s.MutationRates {
+					tuning := base
+					tuning.PopulationSize = populationSize
+					tuning.MaxGenerations = maxGenerations
+					tuning.CrossoverRate = crossoverRate
+					tuning.MutationRate = mutationRate
+					tunings = append(tunings, tuning)
+				}
+			}
+		}
+	}
+	return tunings
+}
+
+// Result is how a tuning did over all seeds. Fitness is the mean total
+// fitness of the plans; lower is better.
+type Result struct {
+	Tuning   planner.GeneticTuning
+	Fitness  float64
+	Duration time.Duration // mean time per plan
+}
+
+// Tuner runs the genetic planner with candidate tunings on the same
+// request and seeds.
+type Tuner struct {
+	recipeRepo    repositories.RecipeRepositoryInterface
+	params        models.MealPlanParams
+	mealType      models.MealType
+	unitConverter units.UnitConverterInterface
+	seeds         []int64
+}
+
+func NewTuner(
+	recipeRepo repositories.RecipeRepositoryInterface,
+	params models.MealPlanParams,
+	mealType models.MealType,
+	unitConverter units.UnitConverterInterface,
+	seeds []int64,
+) *Tuner {
+	return &Tuner{
+		recipeRepo:    recipeRepo,
+		params:        params,
+		mealType:      mealType,
+		unitConverter: unitConverter,
+		seeds:         seeds,
+	}
+}
+
+// Evaluate plans once per seed with tuning.
+func (t *Tuner) Evaluate(tuning planner.GeneticTuning) (Result, error) {
+	result := Result{Tuning: tuning}
+	cache := planner.NewRecipeCache(t.unitConverter)
+
+	var elapsed time.Duration
+	for _, seed := range t.seeds {
+		gmp := planner.NewGeneticMealPlanner(
+			tuning.PopulationSize,
+			tuning.MaxGenerations,
+			tuning.CrossoverRate,
+			tuning.MutationRate,
+			t.recipeRepo,
+			t.params,
+			t.unitConverter,
+		)
+		gmp.SetConvergence(tuning.StagnationWindow, tuning.StagnationThreshold)
+		gmp.SetElitism(tuning.Elitism)
+		gmp.SetIslands(tuning.Islands, tuning.MigrationInterval, tuning.Migrants)
+		gmp.SetRecipeCache(cache)
+		gmp.SetSeed(seed)
+
+		start := time.Now()
+		mealPlans, err := gmp.CreateMealPlans(t.params.StartDate, t.params.EndDate, t.params.StartDate, t.mealType)
+		elapsed += time.Since(start)
+		if err != nil {
+			return Result{}, err
+		}
+
+		for _, mealPlan := range mealPlans {
+			result.Fitness += mealPlan.Fitness.Total
+		}
+	}
+
+	result.Fitness /= float64(len(t.seeds))
+	result.Duration = elapsed / time.Duration(len(t.seeds))
+	return result, nil
+}
+
+// Search evaluates every tuning, calling onResult after each when it is not
+// nil.
+func (t *Tuner) Search(tunings []planner.GeneticTuning, onResult func(Result)) ([]Result, error) {
+	results := make([]Result, 0, len(tunings))
+	for _, tuning := range tunings {
+		result, err := t.Evaluate(tuning)
+		if err != nil {
+			return nil, err
+		}
+		if onResult != nil {
+			onResult(result)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Front returns the quality-versus-time curve: the results that no other
+// result beats on both fitness and duration, fastest first.
+func Front(results []Result) []Result {
+	sorted := make([]Result, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Duration != sorted[j].Duration {
+			return sorted[i].Duration < sorted[j].Duration
+		}
+		return sorted[i].Fitness < sorted[j].Fitness
+	})
+
+	var front []Result
+	for _, result := range sorted {
+		if len(front) == 0 || result.Fitness < front[len(front)-1].Fitness {
+			front = append(front, result)
+		}
+	}
+	return front
+}
+
+// Recommend returns the fastest result whose fitness is within tolerance,
+// relative to the best fitness, of the best one. A tolerance of 0.01 gives
+// up at most 1% of quality for speed.
+func Recommend(results []Result, tolerance float64) (Result, bool) {
+	front := Front(results)
+	if len(front) == 0 {
+		return Result{}, false
+	}
+
+	best := front[len(front)-1].Fitness
+	limit := best + tolerance*abs(best)
+	for _, result := range front {
+		if result.Fitness <= limit {
+			return result, true
+		}
+	}
+	return front[len(front)-1], true
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,81 @@
+package tuner_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cvele/recipe/pkg/fixtures"
+	"github.com/cvele/recipe/pkg/models"
+	"github.com/cvele/recipe/pkg/planner"
+	"github.com/cvele/recipe/pkg/tuner"
+	"github.com/cvele/recipe/pkg/units"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpace_Tunings(t *testing.T) {
+	space := tuner.Space{
+		PopulationSizes: []int{10, 20},
+		MaxGenerations:  []int{5},
+		CrossoverRates:  []float64{0.5, 0.9},
+		MutationRates:   []float64{0.1},
+	}
+
+	tunings := space.Tunings(planner.GeneticTuning{Islands: 1})
+
+	assert.Len(t, tunings, 4)
+	for _, tuning := range tunings {
+		assert.Equal(t, 1, tuning.Islands)
+		assert.Equal(t, 5, tuning.MaxGenerations)
+	}
+}
+
+func TestTuner_EvaluateIsDeterministic(t *testing.T) {
+	repo := fixtures.NewRecipeRepository(fixtures.Recipes(30, 1))
+	params := fixtures.WeekOfLunches(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tn := tuner.NewTuner(repo, params, models.Lunch, units.NewUnitConverter("g", "ml"), []int64{1, 2})
+	tuning := planner.GeneticTuning{
+		PopulationSize:      20,
+		MaxGenerations:      10,
+		CrossoverRate:       0.7,
+		MutationRate:        0.1,
+		StagnationWindow:    10,
+		StagnationThreshold: 0.01,
+		Islands:             1,
+		MigrationInterval:   5,
+		Migrants:            1,
+	}
+
+	first, err := tn.Evaluate(tuning)
+	assert.NoError(t, err)
+	second, err := tn.Evaluate(tuning)
+	assert.NoError(t, err)
+
+	assert.Greater(t, first.Fitness, 0.0)
+	assert.Equal(t, first.Fitness, second.Fitness)
+}
+
+func TestFrontAndRecommend(t *testing.T) {
+	results := []tuner.Result{
+		{Tuning: planner.GeneticTuning{PopulationSize: 1}, Fitness: 100, Duration: 1 * time.Millisecond},
+		{Tuning: planner.GeneticTuning{PopulationSize: 2}, Fitness: 110, Duration: 2 * time.Millisecond},
+		{Tuning: planner.GeneticTuning{PopulationSize: 3}, Fitness: 90.5, Duration: 3 * time.Millisecond},
+		{Tuning: planner.GeneticTuning{PopulationSize: 4}, Fitness: 90, Duration: 4 * time.Millisecond},
+	}
+
+	front := tuner.Front(results)
+	assert.Len(t, front, 3)
+	assert.Equal(t, 1, front[0].Tuning.PopulationSize)
+	assert.Equal(t, 3, front[1].Tuning.PopulationSize)
+	assert.Equal(t, 4, front[2].Tuning.PopulationSize)
+
+	recommended, ok := tuner.Recommend(results, 0.01)
+	assert.True(t, ok)
+	assert.Equal(t, 3, recommended.Tuning.PopulationSize)
+
+	recommended, ok = tuner.Recommend(results, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 4, recommended.Tuning.PopulationSize)
+
+	_, ok = tuner.Recommend(nil, 0.01)
+	assert.False(t, ok)
+}
@@ -6,3 +6,24 @@ type UnitConverterInterface interface {
 	IsValidUnit(unit string, unitType string) bool
 	GetDefaultUnit(unitType string) string
 }
+
+// ExactUnitConverterInterface is implemented by converters that can convert
+// Quantity values without rounding.
+type ExactUnitConverterInterface interface {
+	UnitConverterInterface
+	ConvertQuantity(quantity Quantity, fromUnit string, toUnit string, unitType string) (Quantity, error)
+}
+
+// ConvertQuantity converts quantity with converter, exactly when the
+// converter supports it and through ConvertUnits otherwise.
+func ConvertQuantity(converter UnitConverterInterface, quantity Quantity, fromUnit string, toUnit string, unitType string) (Quantity, error) {
+	if exact, ok := converter.(ExactUnitConverterInterface); ok {
+		return exact.ConvertQuantity(quantity, fromUnit, toUnit, unitType)
+	}
+
+	converted, err := converter.ConvertUnits(quantity.Float64(), fromUnit, toUnit, unitType)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return QuantityFromFloat(converted), nil
+}
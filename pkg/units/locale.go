@@ -0,0 +1,151 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Locale describes the units and number format a user expects to see.
+type Locale struct {
+	Tag              string
+	TemperatureUnit  string // "c" or "f"
+	ShowGasMark      bool   // append the gas mark to oven temperatures
+	LengthUnit       string // "cm" or "in"
+	DecimalSeparator string
+}
+
+var locales = map[string]Locale{
+	"en-US": {Tag: "en-US", TemperatureUnit: "f", LengthUnit: "in", DecimalSeparator: "."},
+	"en-GB": {Tag: "en-GB", TemperatureUnit: "c", ShowGasMark: true, LengthUnit: "cm", DecimalSeparator: "."},
+	"de-DE": {Tag: "de-DE", TemperatureUnit: "c", LengthUnit: "cm", DecimalSeparator: ","},
+	"fr-FR": {Tag: "fr-FR", TemperatureUnit: "c", LengthUnit: "cm", DecimalSeparator: ","},
+	"it-IT": {Tag: "it-IT", TemperatureUnit: "c", LengthUnit: "cm", DecimalSeparator: ","},
+	"es-ES": {Tag: "es-ES", TemperatureUnit: "c", LengthUnit: "cm", DecimalSeparator: ","},
+	"sr-RS": {Tag: "sr-RS", TemperatureUnit: "c", LengthUnit: "cm", DecimalSeparator: ","},
+}
+
+// languageLocales picks a locale for tags that only match by language.
+var languageLocales = map[string]string{
+	"en": "en-US",
+	"de": "de-DE",
+	"fr": "fr-FR",
+	"it": "it-IT",
+	"es": "es-ES",
+	"sr": "sr-RS",
+}
+
+// DefaultLocale is metric with a decimal point.
+var DefaultLocale = Locale{Tag: "und", TemperatureUnit: "c", LengthUnit: "cm", DecimalSeparator: "."}
+
+// GetLocale resolves a language tag such as "de-AT" or "en_GB", falling back
+// to the language and then to DefaultLocale.
+func GetLocale(tag string) Locale {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	language, region, _ := strings.Cut(tag, "-")
+	language = strings.ToLower(language)
+
+	if locale, ok := locales[language+"-"+strings.ToUpper(region)]; ok {
+		return locale
+	}
+	if locale, ok := locales[languageLocales[language]]; ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Formatter renders recipe instruction measurements for a locale.
+type Formatter struct {
+	converter ExactUnitConverterInterface
+	locale    Locale
+}
+
+func NewFormatter(converter ExactUnitConverterInterface, locale Locale) *Formatter {
+	return &Formatter{
+		converter: converter,
+		locale:    locale,
+	}
+}
+
+// FormatTemperature converts an oven temperature to the locale's scale,
+// rounded to the nearest 5 degrees, e.g. "180 °C (gas mark 4)".
+func (f *Formatter) FormatTemperature(value float64, unit string) (string, error) {
+	quantity := QuantityFromFloat(value)
+
+	converted, err := f.converter.ConvertQuantity(quantity, unit, f.locale.TemperatureUnit, "temperature")
+	if err != nil {
+		return "", err
+	}
+
+	text := fmt.Sprintf("%s °%s", f.formatNumber(converted, NewQuantity(5, 1)), strings.ToUpper(f.locale.TemperatureUnit))
+	if f.locale.ShowGasMark {
+		if mark, err := f.converter.ConvertQuantity(quantity, unit, "gas-mark", "temperature"); err == nil {
+			text += fmt.Sprintf(" (gas mark %s)", formatGasMark(mark))
+		}
+	}
+
+	return text, nil
+}
+
+// FormatLength converts a length such as a pan size to the locale's unit,
+// rounded to the nearest half unit, e.g. "22,5 cm".
+func (f *Formatter) FormatLength(value float64, unit string) (string, error) {
+	converted, err := f.converter.ConvertQuantity(QuantityFromFloat(value), unit, f.locale.LengthUnit, "length")
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s %s", f.formatNumber(converted, NewQuantity(1, 2)), f.locale.LengthUnit), nil
+}
+
+// FormatDuration renders a duration as hours, minutes and seconds, e.g.
+// "1 h 30 min".
+func (f *Formatter) FormatDuration(value float64, unit string) (string, error) {
+	seconds, err := f.converter.ConvertQuantity(QuantityFromFloat(value), unit, "s", "time")
+	if err != nil {
+		return "", err
+	}
+
+	total := seconds.Round()
+	if total < 0 {
+		return "", errors.New("negative duration")
+	}
+
+	var parts []string
+	if hours := total / 3600; hours > 0 {
+		parts = append(parts, fmt.Sprintf("%d h", hours))
+	}
+	if minutes := total % 3600 / 60; minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%d min", minutes))
+	}
+	if secs := total % 60; secs > 0 {
+		parts = append(parts, fmt.Sprintf("%d s", secs))
+	}
+	if len(parts) == 0 {
+		return "0 min", nil
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// formatNumber rounds quantity to a multiple of step and formats it with the
+// locale's decimal separator.
+func (f *Formatter) formatNumber(quantity Quantity, step Quantity) string {
+	steps, _ := quantity.Div(step)
+	rounded := step.MulInt(steps.Round())
+
+	text := strconv.FormatFloat(rounded.Float64(), 'f', -1, 64)
+	return strings.Replace(text, ".", f.locale.DecimalSeparator, 1)
+}
+
+func formatGasMark(mark Quantity) string {
+	switch mark.String() {
+	case "1/4":
+		return "¼"
+	case "1/2":
+		return "½"
+	default:
+		return mark.String()
+	}
+}
@@ -0,0 +1,105 @@
+package units_test
+
+import (
+	"testing"
+
+	"github.com/cvele/recipe/pkg/units"
+)
+
+func TestGetLocale(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want string
+	}{
+		{"Exact match", "en-GB", "en-GB"},
+		{"Underscore and case", "de_de", "de-DE"},
+		{"Language fallback", "de-AT", "de-DE"},
+		{"Unknown locale", "xx-YY", "und"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := units.GetLocale(tt.tag).Tag; got != tt.want {
+				t.Errorf("GetLocale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatter_FormatTemperature(t *testing.T) {
+	unitConverter := units.NewUnitConverter("g", "ml")
+
+	tests := []struct {
+		name   string
+		locale string
+		value  float64
+		unit   string
+		want   string
+	}{
+		{"Fahrenheit for German reader", "de-DE", 350, "f", "175 °C"},
+		{"Celsius for US reader", "en-US", 200, "c", "390 °F"},
+		{"Gas mark for British reader", "en-GB", 350, "f", "175 °C (gas mark 4)"},
+		{"Gas mark source", "en-GB", 0.25, "gas-mark", "105 °C (gas mark ¼)"},
+		{"Outside gas mark range", "en-GB", 50, "c", "50 °C"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter := units.NewFormatter(unitConverter, units.GetLocale(tt.locale))
+			got, err := formatter.FormatTemperature(tt.value, tt.unit)
+			if err != nil {
+				t.Fatalf("FormatTemperature() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FormatTemperature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatter_FormatLength(t *testing.T) {
+	unitConverter := units.NewUnitConverter("g", "ml")
+
+	german := units.NewFormatter(unitConverter, units.GetLocale("de-DE"))
+	if got, _ := german.FormatLength(9, "in"); got != "23 cm" {
+		t.Errorf("FormatLength(9 in) = %v, want 23 cm", got)
+	}
+	if got, _ := german.FormatLength(225, "mm"); got != "22,5 cm" {
+		t.Errorf("FormatLength(225 mm) = %v, want 22,5 cm", got)
+	}
+
+	american := units.NewFormatter(unitConverter, units.GetLocale("en-US"))
+	if got, _ := american.FormatLength(23, "cm"); got != "9 in" {
+		t.Errorf("FormatLength(23 cm) = %v, want 9 in", got)
+	}
+
+	if _, err := american.FormatLength(1, "kg"); err == nil {
+		t.Errorf("FormatLength() expected error for mass unit")
+	}
+}
+
+func TestFormatter_FormatDuration(t *testing.T) {
+	formatter := units.NewFormatter(units.NewUnitConverter("g", "ml"), units.DefaultLocale)
+
+	tests := []struct {
+		value float64
+		unit  string
+		want  string
+	}{
+		{90, "min", "1 h 30 min"},
+		{1.5, "h", "1 h 30 min"},
+		{45, "s", "45 s"},
+		{0, "min", "0 min"},
+	}
+
+	for _, tt := range tests {
+		got, err := formatter.FormatDuration(tt.value, tt.unit)
+		if err != nil {
+			t.Fatalf("FormatDuration() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("FormatDuration(%v %v) = %v, want %v", tt.value, tt.unit, got, tt.want)
+		}
+	}
+}
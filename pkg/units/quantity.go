@@ -0,0 +1,148 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Quantity is an exact amount backed by a rational number. It is used for
+// scaling and aggregating ingredient quantities so that repeated conversions
+// do not accumulate floating point drift. Values are immutable; every
+// operation returns a new Quantity. The zero value is 0.
+type Quantity struct {
+	rat *big.Rat
+}
+
+var (
+	maxIntRat = new(big.Rat).SetInt64(math.MaxInt)
+	minIntRat = new(big.Rat).SetInt64(math.MinInt)
+)
+
+// NewQuantity returns the exact fraction num/denom.
+func NewQuantity(num int64, denom int64) Quantity {
+	if denom == 0 {
+		panic("units: zero denominator in quantity")
+	}
+	return Quantity{rat: big.NewRat(num, denom)}
+}
+
+// QuantityFromFloat converts f using its shortest decimal representation, so
+// 0.1 becomes exactly 1/10 rather than the nearest binary fraction.
+func QuantityFromFloat(f float64) Quantity {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Quantity{}
+	}
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	if !ok {
+		return Quantity{rat: new(big.Rat).SetFloat64(f)}
+	}
+	return Quantity{rat: r}
+}
+
+// ParseQuantity parses decimals ("1.25"), fractions ("1/3") and mixed
+// numbers ("1 1/2").
+func ParseQuantity(s string) (Quantity, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return Quantity{}, errors.New("invalid quantity")
+	}
+
+	whole, ok := new(big.Rat).SetString(fields[0])
+	if !ok {
+		return Quantity{}, errors.New("invalid quantity")
+	}
+	if len(fields) == 1 {
+		return Quantity{rat: whole}, nil
+	}
+
+	// Mixed number: a whole part followed by an unsigned fraction
+	if !whole.IsInt() || !strings.Contains(fields[1], "/") || strings.ContainsAny(fields[1], "+-") {
+		return Quantity{}, errors.New("invalid quantity")
+	}
+	fraction, ok := new(big.Rat).SetString(fields[1])
+	if !ok {
+		return Quantity{}, errors.New("invalid quantity")
+	}
+	if whole.Sign() < 0 {
+		fraction.Neg(fraction)
+	}
+
+	return Quantity{rat: whole.Add(whole, fraction)}, nil
+}
+
+func (q Quantity) value() *big.Rat {
+	if q.rat == nil {
+		return new(big.Rat)
+	}
+	return q.rat
+}
+
+func (q Quantity) Add(other Quantity) Quantity {
+	return Quantity{rat: new(big.Rat).Add(q.value(), other.value())}
+}
+
+func (q Quantity) Sub(other Quantity) Quantity {
+	return Quantity{rat: new(big.Rat).Sub(q.value(), other.value())}
+}
+
+func (q Quantity) Mul(other Quantity) Quantity {
+	return Quantity{rat: new(big.Rat).Mul(q.value(), other.value())}
+}
+
+func (q Quantity) Div(other Quantity) (Quantity, error) {
+	if other.IsZero() {
+		return Quantity{}, errors.New("division by zero quantity")
+	}
+	return Quantity{rat: new(big.Rat).Quo(q.value(), other.value())}, nil
+}
+
+// MulInt scales the quantity by n.
+func (q Quantity) MulInt(n int) Quantity {
+	return q.Mul(NewQuantity(int64(n), 1))
+}
+
+func (q Quantity) Cmp(other Quantity) int {
+	return q.value().Cmp(other.value())
+}
+
+func (q Quantity) IsZero() bool {
+	return q.value().Sign() == 0
+}
+
+// Float64 returns the nearest float64 value. Use it only for display and
+// for APIs that still work with floats.
+func (q Quantity) Float64() float64 {
+	f, _ := q.value().Float64()
+	return f
+}
+
+// Round returns the quantity rounded half away from zero to the nearest
+// integer, saturating at the bounds of int.
+func (q Quantity) Round() int {
+	r := q.value()
+	if r.Cmp(maxIntRat) >= 0 {
+		return math.MaxInt
+	}
+	if r.Cmp(minIntRat) <= 0 {
+		return math.MinInt
+	}
+
+	num := new(big.Int).Abs(r.Num())
+	quo, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		quo.Neg(quo)
+	}
+
+	return int(quo.Int64())
+}
+
+// String returns the exact value as "a/b", or "a" for whole numbers.
+func (q Quantity) String() string {
+	return q.value().RatString()
+}
@@ -0,0 +1,96 @@
+package units_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cvele/recipe/pkg/units"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		expectErr bool
+	}{
+		{"Integer", "3", "3", false},
+		{"Decimal", "1.25", "5/4", false},
+		{"Fraction", "1/3", "1/3", false},
+		{"Mixed number", "1 1/2", "3/2", false},
+		{"Negative mixed number", "-1 1/2", "-3/2", false},
+		{"Fractional whole part", "1.5 1/2", "", true},
+		{"Decimal second part", "1 0.5", "", true},
+		{"Empty", "", "", true},
+		{"Garbage", "cup", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := units.ParseQuantity(tt.input)
+
+			if (err != nil) != tt.expectErr {
+				t.Errorf("ParseQuantity() error = %v, expectErr %v", err, tt.expectErr)
+				return
+			}
+
+			if !tt.expectErr && got.String() != tt.want {
+				t.Errorf("ParseQuantity() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantityFromFloat(t *testing.T) {
+	if got := units.QuantityFromFloat(0.1).String(); got != "1/10" {
+		t.Errorf("QuantityFromFloat(0.1) = %v, want 1/10", got)
+	}
+
+	if got := units.QuantityFromFloat(math.MaxFloat64).Float64(); got != math.MaxFloat64 {
+		t.Errorf("QuantityFromFloat(MaxFloat64) round trip = %v", got)
+	}
+}
+
+func TestQuantity_ScalingRoundTrip(t *testing.T) {
+	third := units.NewQuantity(1, 3)
+
+	scaled := third.MulInt(3)
+	if scaled.Cmp(units.NewQuantity(1, 1)) != 0 || scaled.Float64() != 1 {
+		t.Errorf("1/3 * 3 = %v, want 1", scaled)
+	}
+
+	back, err := scaled.Div(units.NewQuantity(3, 1))
+	if err != nil {
+		t.Fatalf("Div() error = %v", err)
+	}
+	if back.Cmp(third) != 0 {
+		t.Errorf("1 / 3 = %v, want 1/3", back)
+	}
+
+	if _, err := third.Div(units.Quantity{}); err == nil {
+		t.Errorf("Div() by zero expected error")
+	}
+}
+
+func TestQuantity_Round(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity units.Quantity
+		want     int
+	}{
+		{"Round down", units.NewQuantity(5, 4), 1},
+		{"Half away from zero", units.NewQuantity(5, 2), 3},
+		{"Negative half away from zero", units.NewQuantity(-5, 2), -3},
+		{"Zero value", units.Quantity{}, 0},
+		{"Saturates high", units.QuantityFromFloat(math.MaxFloat64), math.MaxInt},
+		{"Saturates low", units.QuantityFromFloat(-math.MaxFloat64), math.MinInt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quantity.Round(); got != tt.want {
+				t.Errorf("Round() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
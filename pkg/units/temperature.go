@@ -0,0 +1,105 @@
+package units
+
+import "errors"
+
+var temperatureUnits = []string{"c", "f", "gas-mark"}
+
+// gasMarks maps each UK gas mark to its oven temperature in Fahrenheit.
+// From mark 1 upwards every mark adds 25 °F.
+var gasMarks = []struct {
+	mark       Quantity
+	fahrenheit Quantity
+}{
+	{NewQuantity(1, 4), NewQuantity(225, 1)},
+	{NewQuantity(1, 2), NewQuantity(250, 1)},
+	{NewQuantity(1, 1), NewQuantity(275, 1)},
+	{NewQuantity(2, 1), NewQuantity(300, 1)},
+	{NewQuantity(3, 1), NewQuantity(325, 1)},
+	{NewQuantity(4, 1), NewQuantity(350, 1)},
+	{NewQuantity(5, 1), NewQuantity(375, 1)},
+	{NewQuantity(6, 1), NewQuantity(400, 1)},
+	{NewQuantity(7, 1), NewQuantity(425, 1)},
+	{NewQuantity(8, 1), NewQuantity(450, 1)},
+	{NewQuantity(9, 1), NewQuantity(475, 1)},
+}
+
+// gasMarkTolerance is how far, in Fahrenheit, a temperature may fall outside
+// the gas mark scale and still be rounded onto it.
+var gasMarkTolerance = NewQuantity(25, 2)
+
+func isTemperatureUnit(unit string) bool {
+	for _, u := range temperatureUnits {
+		if u == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// convertTemperature converts between Celsius, Fahrenheit and gas marks.
+// Conversions to gas marks round to the nearest mark, since ovens only offer
+// those settings.
+func convertTemperature(quantity Quantity, fromUnit string, toUnit string) (Quantity, error) {
+	if !isTemperatureUnit(fromUnit) || !isTemperatureUnit(toUnit) {
+		return Quantity{}, errors.New("unsupported unit conversion")
+	}
+	if fromUnit == toUnit {
+		return quantity, nil
+	}
+
+	var fahrenheit Quantity
+	switch fromUnit {
+	case "c":
+		fahrenheit = quantity.Mul(NewQuantity(9, 5)).Add(NewQuantity(32, 1))
+	case "f":
+		fahrenheit = quantity
+	case "gas-mark":
+		found := false
+		for _, gm := range gasMarks {
+			if gm.mark.Cmp(quantity) == 0 {
+				fahrenheit = gm.fahrenheit
+				found = true
+				break
+			}
+		}
+		if !found {
+			return Quantity{}, errors.New("invalid gas mark")
+		}
+	}
+
+	switch toUnit {
+	case "c":
+		return fahrenheit.Sub(NewQuantity(32, 1)).Mul(NewQuantity(5, 9)), nil
+	case "f":
+		return fahrenheit, nil
+	default:
+		return nearestGasMark(fahrenheit)
+	}
+}
+
+func nearestGasMark(fahrenheit Quantity) (Quantity, error) {
+	lowest := gasMarks[0].fahrenheit.Sub(gasMarkTolerance)
+	highest := gasMarks[len(gasMarks)-1].fahrenheit.Add(gasMarkTolerance)
+	if fahrenheit.Cmp(lowest) < 0 || fahrenheit.Cmp(highest) > 0 {
+		return Quantity{}, errors.New("temperature outside gas mark range")
+	}
+
+	best := gasMarks[0]
+	bestDistance := absQuantity(fahrenheit.Sub(best.fahrenheit))
+	for _, gm := range gasMarks[1:] {
+		distance := absQuantity(fahrenheit.Sub(gm.fahrenheit))
+		if distance.Cmp(bestDistance) < 0 {
+			best = gm
+			bestDistance = distance
+		}
+	}
+
+	return best.mark, nil
+}
+
+func absQuantity(q Quantity) Quantity {
+	if q.Cmp(Quantity{}) < 0 {
+		return Quantity{}.Sub(q)
+	}
+	return q
+}
@@ -0,0 +1,64 @@
+package units_test
+
+import (
+	"testing"
+
+	"github.com/cvele/recipe/pkg/units"
+)
+
+func TestUnitConverter_ConvertTemperature(t *testing.T) {
+	unitConverter := units.NewUnitConverter("kg", "l")
+
+	tests := []struct {
+		name      string
+		quantity  float64
+		fromUnit  string
+		toUnit    string
+		want      float64
+		expectErr bool
+	}{
+		{"Celsius to Fahrenheit", 100, "c", "f", 212, false},
+		{"Fahrenheit to Celsius", 212, "f", "c", 100, false},
+		{"Gas mark to Fahrenheit", 4, "gas-mark", "f", 350, false},
+		{"Celsius to nearest gas mark", 180, "c", "gas-mark", 4, false},
+		{"Fahrenheit to low gas mark", 250, "f", "gas-mark", 0.5, false},
+		{"Invalid gas mark", 10, "gas-mark", "c", 0, true},
+		{"Outside gas mark range", 20, "c", "gas-mark", 0, true},
+		{"Unknown unit", 100, "k", "c", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unitConverter.ConvertUnits(tt.quantity, tt.fromUnit, tt.toUnit, "temperature")
+
+			if (err != nil) != tt.expectErr {
+				t.Errorf("ConvertUnits() error = %v, expectErr %v", err, tt.expectErr)
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("ConvertUnits() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnitConverter_LengthAndTimeUnits(t *testing.T) {
+	unitConverter := units.NewUnitConverter("kg", "l")
+
+	if got, err := unitConverter.ConvertUnits(9, "in", "cm", "length"); err != nil || got != 22.86 {
+		t.Errorf("ConvertUnits(9 in to cm) = %v, %v", got, err)
+	}
+	if got, err := unitConverter.ConvertUnits(90, "min", "h", "time"); err != nil || got != 1.5 {
+		t.Errorf("ConvertUnits(90 min to h) = %v, %v", got, err)
+	}
+	if !unitConverter.IsValidUnit("gas-mark", "temperature") || unitConverter.IsValidUnit("in", "time") {
+		t.Errorf("IsValidUnit() returned wrong result for instruction units")
+	}
+	if len(unitConverter.GetAvailableUnits("length")) == 0 {
+		t.Errorf("GetAvailableUnits() returned empty for length units")
+	}
+	if got := unitConverter.GetDefaultUnit("temperature"); got != "c" {
+		t.Errorf("GetDefaultUnit(temperature) = %v, want c", got)
+	}
+}
@@ -2,9 +2,15 @@ package units
 
 import "errors"
 
+var _ ExactUnitConverterInterface = &UnitConverter{}
+
 type UnitConverter struct {
 	massConversionRates   map[string]map[string]float64
 	volumeConversionRates map[string]map[string]float64
+	massUnitSizes         map[string]Quantity // exact size of each unit in grams
+	volumeUnitSizes       map[string]Quantity // exact size of each unit in milliliters
+	lengthUnitSizes       map[string]Quantity // exact size of each unit in centimeters
+	timeUnitSizes         map[string]Quantity // exact size of each unit in minutes
 	defaultMassUnit       string
 	defaultVolumeUnit     string
 }
@@ -13,6 +19,35 @@ func NewUnitConverter(defaultMassUnit string, defaultVolumeUnit string) *UnitCon
 	return &UnitConverter{
 		defaultMassUnit:   defaultMassUnit,
 		defaultVolumeUnit: defaultVolumeUnit,
+		// International avoirdupois pound and US customary volume definitions
+		massUnitSizes: map[string]Quantity{
+			"g":  mustParseQuantity("1"),
+			"kg": mustParseQuantity("1000"),
+			"lb": mustParseQuantity("453.59237"),
+			"oz": mustParseQuantity("28.349523125"),
+		},
+		volumeUnitSizes: map[string]Quantity{
+			"ml":    mustParseQuantity("1"),
+			"l":     mustParseQuantity("1000"),
+			"fl-oz": mustParseQuantity("29.5735295625"),
+			"cups":  mustParseQuantity("236.5882365"),
+			"pt":    mustParseQuantity("473.176473"),
+			"qt":    mustParseQuantity("946.352946"),
+			"gal":   mustParseQuantity("3785.411784"),
+			"tsp":   mustParseQuantity("4.92892159375"),
+			"tbsp":  mustParseQuantity("14.78676478125"),
+		},
+		lengthUnitSizes: map[string]Quantity{
+			"mm": mustParseQuantity("1/10"),
+			"cm": mustParseQuantity("1"),
+			"m":  mustParseQuantity("100"),
+			"in": mustParseQuantity("2.54"),
+		},
+		timeUnitSizes: map[string]Quantity{
+			"s":   mustParseQuantity("1/60"),
+			"min": mustParseQuantity("1"),
+			"h":   mustParseQuantity("60"),
+		},
 		massConversionRates: map[string]map[string]float64{
 			"kg": {
 				"g":  1000,
@@ -136,6 +171,16 @@ func (u *UnitConverter) ConvertUnits(quantity float64, fromUnit string, toUnit s
 		return quantity, nil
 	}
 
+	// Unit families without a legacy rate table are always converted exactly
+	switch unitType {
+	case "temperature", "length", "time":
+		converted, err := u.ConvertQuantity(QuantityFromFloat(quantity), fromUnit, toUnit, unitType)
+		if err != nil {
+			return 0, err
+		}
+		return converted.Float64(), nil
+	}
+
 	var conversionRates map[string]map[string]float64
 	if unitType == "mass" {
 		conversionRates = u.massConversionRates
@@ -153,6 +198,34 @@ func (u *UnitConverter) ConvertUnits(quantity float64, fromUnit string, toUnit s
 	return quantity * conversionRate, nil
 }
 
+// ConvertQuantity converts quantity exactly, using unit sizes rather than the
+// rounded pairwise rates used by ConvertUnits.
+func (u *UnitConverter) ConvertQuantity(quantity Quantity, fromUnit string, toUnit string, unitType string) (Quantity, error) {
+	if fromUnit == toUnit {
+		return quantity, nil
+	}
+
+	if unitType == "temperature" {
+		return convertTemperature(quantity, fromUnit, toUnit)
+	}
+
+	unitSizes := u.unitSizes(unitType)
+	if unitSizes == nil {
+		return Quantity{}, errors.New("unknown unit type")
+	}
+
+	fromSize, ok := unitSizes[fromUnit]
+	if !ok {
+		return Quantity{}, errors.New("unsupported unit conversion")
+	}
+	toSize, ok := unitSizes[toUnit]
+	if !ok {
+		return Quantity{}, errors.New("unsupported unit conversion")
+	}
+
+	return quantity.Mul(fromSize).Div(toSize)
+}
+
 func (u *UnitConverter) GetAvailableUnits(unitType string) []string {
 	var units []string
 
@@ -165,6 +238,12 @@ func (u *UnitConverter) GetAvailableUnits(unitType string) []string {
 		for unit := range u.volumeConversionRates {
 			units = append(units, unit)
 		}
+	case "temperature":
+		units = append(units, temperatureUnits...)
+	case "length", "time":
+		for unit := range u.unitSizes(unitType) {
+			units = append(units, unit)
+		}
 	default:
 		return nil
 	}
@@ -180,6 +259,11 @@ func (u *UnitConverter) IsValidUnit(unit string, unitType string) bool {
 	case "volume":
 		_, isValid := u.volumeConversionRates[unit]
 		return isValid
+	case "temperature":
+		return isTemperatureUnit(unit)
+	case "length", "time":
+		_, isValid := u.unitSizes(unitType)[unit]
+		return isValid
 	default:
 		return false
 	}
@@ -190,6 +274,35 @@ func (u *UnitConverter) GetDefaultUnit(unitType string) string {
 		return u.defaultMassUnit
 	} else if unitType == "volume" {
 		return u.defaultVolumeUnit
+	} else if unitType == "temperature" {
+		return "c"
+	} else if unitType == "length" {
+		return "cm"
+	} else if unitType == "time" {
+		return "min"
 	}
 	return ""
 }
+
+func (u *UnitConverter) unitSizes(unitType string) map[string]Quantity {
+	switch unitType {
+	case "mass":
+		return u.massUnitSizes
+	case "volume":
+		return u.volumeUnitSizes
+	case "length":
+		return u.lengthUnitSizes
+	case "time":
+		return u.timeUnitSizes
+	default:
+		return nil
+	}
+}
+
+func mustParseQuantity(s string) Quantity {
+	q, err := ParseQuantity(s)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
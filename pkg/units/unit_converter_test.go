@@ -81,3 +81,40 @@ func TestUnitConverter_IsValidUnit(t *testing.T) {
 		})
 	}
 }
+
+func TestUnitConverter_ConvertQuantity(t *testing.T) {
+	unitConverter := units.NewUnitConverter("kg", "l")
+
+	tests := []struct {
+		name      string
+		quantity  units.Quantity
+		fromUnit  string
+		toUnit    string
+		unitType  string
+		want      units.Quantity
+		expectErr bool
+	}{
+		{"Volume: tsp to tbsp", units.NewQuantity(1, 1), "tsp", "tbsp", "volume", units.NewQuantity(1, 3), false},
+		{"Volume: 3 tsp to tbsp", units.NewQuantity(3, 1), "tsp", "tbsp", "volume", units.NewQuantity(1, 1), false},
+		{"Volume: cups to fl-oz", units.NewQuantity(1, 2), "cups", "fl-oz", "volume", units.NewQuantity(4, 1), false},
+		{"Mass: lb to oz", units.NewQuantity(1, 1), "lb", "oz", "mass", units.NewQuantity(16, 1), false},
+		{"Mass: kg to g", units.NewQuantity(3, 2), "kg", "g", "mass", units.NewQuantity(1500, 1), false},
+		{"Invalid unit", units.NewQuantity(1, 1), "kg", "ml", "mass", units.Quantity{}, true},
+		{"Unknown unit type", units.NewQuantity(1, 1), "kg", "g", "length", units.Quantity{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unitConverter.ConvertQuantity(tt.quantity, tt.fromUnit, tt.toUnit, tt.unitType)
+
+			if (err != nil) != tt.expectErr {
+				t.Errorf("ConvertQuantity() error = %v, expectErr %v", err, tt.expectErr)
+				return
+			}
+
+			if !tt.expectErr && got.Cmp(tt.want) != 0 {
+				t.Errorf("ConvertQuantity() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}